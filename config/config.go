@@ -1,19 +1,456 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
 
+// Policies for env_setup_on_error, which controls what happens when the env
+// setup script exits with a non-zero status.
+const (
+	EnvSetupOnErrorAbort = "abort"
+	EnvSetupOnErrorWarn  = "warn"
+)
+
+// ToolPathsEnvVar is the environment variable holding extra tool paths.
+const ToolPathsEnvVar = "UBER_TOOL_PATHS"
+
+// Modes for tool_paths_env_mode, which controls how the tool paths from
+// UBER_TOOL_PATHS combine with the configured ones.
+const (
+	ToolPathsEnvAppend  = "append"
+	ToolPathsEnvReplace = "replace"
+)
+
 // Config holds the configuration from the .uber TOML file
 type Config struct {
-	ToolPaths    []string `toml:"tool_paths"`
-	EnvSetup     string   `toml:"env_setup"`
-	ReportingCmd string   `toml:"reporting_cmd"`
+	ToolPaths            []string              `toml:"tool_paths,omitempty" json:"tool_paths,omitempty"`
+	EnvSetup             string                `toml:"env_setup,omitempty" json:"env_setup,omitempty"`
+	ReportingCmds        StringList            `toml:"reporting_cmd,omitempty" json:"reporting_cmd,omitempty"`
+	Strict               bool                  `toml:"strict,omitempty" json:"strict,omitempty"`
+	EnvCacheInputs       []string              `toml:"env_cache_inputs,omitempty" json:"env_cache_inputs,omitempty"`
+	MinUberVersion       string                `toml:"min_uber_version,omitempty" json:"min_uber_version,omitempty"`
+	GitEnv               bool                  `toml:"git_env,omitempty" json:"git_env,omitempty"`
+	EnvSetupMaxLineBytes int                   `toml:"env_setup_max_line_bytes,omitzero" json:"env_setup_max_line_bytes,omitempty"`
+	Tools                map[string]ToolConfig `toml:"tools,omitempty" json:"tools,omitempty"`
+	AllowTools           []string              `toml:"allow_tools,omitempty" json:"allow_tools,omitempty"`
+	DenyTools            []string              `toml:"deny_tools,omitempty" json:"deny_tools,omitempty"`
+	Umask                string                `toml:"umask,omitempty" json:"umask,omitempty"`
+	EnvSetupOnError      string                `toml:"env_setup_on_error,omitempty" json:"env_setup_on_error,omitempty"`
+	ExecWrapper          string                `toml:"exec_wrapper,omitempty" json:"exec_wrapper,omitempty"`
+	ReportOnFailure      bool                  `toml:"report_on_failure,omitempty" json:"report_on_failure,omitempty"`
+	PrependToolPaths     bool                  `toml:"prepend_tool_paths_to_path,omitempty" json:"prepend_tool_paths_to_path,omitempty"`
+	NamespaceSeparator   string                `toml:"namespace_separator,omitempty" json:"namespace_separator,omitempty"`
+	RequireOwner         bool                  `toml:"require_owner,omitempty" json:"require_owner,omitempty"`
+	ForwardSignals       []string              `toml:"forward_signals,omitempty" json:"forward_signals,omitempty"`
+	Interactive          bool                  `toml:"interactive,omitempty" json:"interactive,omitempty"`
+	ToolCache            bool                  `toml:"tool_cache,omitempty" json:"tool_cache,omitempty"`
+	DefaultShell         string                `toml:"default_shell,omitempty" json:"default_shell,omitempty"`
+	EnvSetupCmd          string                `toml:"env_setup_cmd,omitempty" json:"env_setup_cmd,omitempty"`
+	ToolPathsEnvMode     string                `toml:"tool_paths_env_mode,omitempty" json:"tool_paths_env_mode,omitempty"`
+	MaxMemory            string                `toml:"max_memory,omitempty" json:"max_memory,omitempty"`
+	AfterSuccessCmd      StringList            `toml:"after_success_cmd,omitempty" json:"after_success_cmd,omitempty"`
+	AfterFailureCmd      StringList            `toml:"after_failure_cmd,omitempty" json:"after_failure_cmd,omitempty"`
+	Sequences            map[string][]string   `toml:"sequences,omitempty" json:"sequences,omitempty"`
+	UberBinPath          string                `toml:"uber_bin_path,omitempty" json:"uber_bin_path,omitempty"`
+	EnvSetupOutputFile   string                `toml:"env_setup_output_file,omitempty" json:"env_setup_output_file,omitempty"`
+	MessagePrefix        string                `toml:"message_prefix,omitempty" json:"message_prefix,omitempty"`
+	TerminalSizeEnv      bool                  `toml:"terminal_size_env,omitempty" json:"terminal_size_env,omitempty"`
+	IncludeHidden        bool                  `toml:"include_hidden,omitempty" json:"include_hidden,omitempty"`
+	PathCommands         bool                  `toml:"path_commands,omitempty" json:"path_commands,omitempty"`
+	TrackUsage           bool                  `toml:"track_usage,omitempty" json:"track_usage,omitempty"`
+
+	// Commands holds the [[command]] entries: tools defined in the
+	// configuration as a shell snippet instead of a file in a tool path
+	Commands []InlineCommand `toml:"command,omitempty" json:"command,omitempty"`
+
+	// ReportingCmd is the first of ReportingCmds, kept for callers written
+	// when reporting_cmd held a single command.
+	//
+	// Deprecated: use ReportingCommands, which includes every command.
+	ReportingCmd string `toml:"-" json:"-"`
+
+	// ToolPathTables holds the [[tool_path]] entries. Load appends their
+	// paths to ToolPaths and clears this field.
+	ToolPathTables []ToolPath `toml:"tool_path,omitempty" json:"tool_path,omitempty"`
+	// RequiredToolPaths lists the entries of ToolPaths that must exist
+	RequiredToolPaths []string `toml:"-" json:"-"`
+
+	// Warnings collects non-fatal problems found while loading the
+	// configuration, for the caller to report.
+	Warnings []string `toml:"-" json:"-"`
+}
+
+// ReportingCommands returns the reporting_cmd commands. A ReportingCmd set
+// by a caller that predates the list replaces the first of ReportingCmds.
+func (c *Config) ReportingCommands() []string {
+	if c.ReportingCmd == "" || (len(c.ReportingCmds) > 0 && c.ReportingCmds[0] == c.ReportingCmd) {
+		return c.ReportingCmds
+	}
+	if len(c.ReportingCmds) == 0 {
+		return []string{c.ReportingCmd}
+	}
+	return append([]string{c.ReportingCmd}, c.ReportingCmds[1:]...)
+}
+
+// InlineCommand is a tool declared with the [[command]] table form. Run is
+// a shell snippet that gets the tool's arguments as "$@".
+type InlineCommand struct {
+	Name string `toml:"name" json:"name"`
+	Run  string `toml:"run" json:"run"`
+	// Source is the file the command was loaded from, set by LoadFromFile
+	Source string `toml:"-" json:"-"`
+}
+
+// Command returns the inline command named name. ok is false if there is
+// none.
+func (c *Config) Command(name string) (InlineCommand, bool) {
+	for _, command := range c.Commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+	return InlineCommand{}, false
+}
+
+// ToolPath is a tool path declared with the [[tool_path]] table form
+type ToolPath struct {
+	Path string `toml:"path" json:"path"`
+	// Required makes uber fail when the path doesn't exist instead of
+	// skipping it
+	Required bool `toml:"required,omitempty" json:"required,omitempty"`
+}
+
+// ToolConfig holds the settings for a single tool from a [tools.<name>] table
+type ToolConfig struct {
+	// Cwd is the working directory the tool runs in, relative to the project root
+	Cwd string `toml:"cwd,omitempty" json:"cwd,omitempty"`
+	// Pidfile is where --detach records the tool's process ID, relative to
+	// the project root
+	Pidfile string `toml:"pidfile,omitempty" json:"pidfile,omitempty"`
+	// LogFile receives the output of the tool when run with --detach,
+	// relative to the project root
+	LogFile string `toml:"log_file,omitempty" json:"log_file,omitempty"`
+	// ArgRewrite replaces arguments passed to the tool before it runs
+	ArgRewrite []ArgRewrite `toml:"arg_rewrite,omitempty" json:"arg_rewrite,omitempty"`
+	// FileArgs expands arguments of the form @path to the contents of the
+	// file at path, and @- to the contents of stdin
+	FileArgs bool `toml:"file_args,omitempty" json:"file_args,omitempty"`
+	// MaxArgs is the largest number of arguments the tool accepts, or nil
+	// for no limit
+	MaxArgs *int `toml:"max_args,omitempty" json:"max_args,omitempty"`
+	// ArgsSchema is a JSON schema, relative to the project root, that the
+	// tool's arguments must match before it runs
+	ArgsSchema string `toml:"args_schema,omitempty" json:"args_schema,omitempty"`
+	// Interactive marks a tool that reads from stdin or needs a terminal,
+	// which --benchmark refuses to run
+	Interactive bool `toml:"interactive,omitempty" json:"interactive,omitempty"`
+	// RequiresEnv lists environment variables that must be set and not
+	// empty, after env setup, for the tool to run
+	RequiresEnv []string `toml:"requires_env,omitempty" json:"requires_env,omitempty"`
+	// Deprecated is a message, such as the tool to use instead, printed as a
+	// warning whenever the tool runs
+	Deprecated string `toml:"deprecated,omitempty" json:"deprecated,omitempty"`
+	// Healthcheck holds the arguments that make the tool check that it is
+	// usable, run before the tool and cached for HealthcheckTTL
+	Healthcheck []string `toml:"healthcheck,omitempty" json:"healthcheck,omitempty"`
+	// HealthcheckTTL is how long a healthcheck result is reused, as a Go
+	// duration such as "12h"
+	HealthcheckTTL string `toml:"healthcheck_ttl,omitempty" json:"healthcheck_ttl,omitempty"`
+	// Nice is added to uber's nice value for the tool on Unix, as with
+	// nice -n: positive values lower its priority and 0 keeps uber's
+	Nice int `toml:"nice,omitzero" json:"nice,omitempty"`
+}
+
+// The range of nice adjustments accepted by nice(1).
+const (
+	MinNice = -20
+	MaxNice = 19
+)
+
+// DefaultHealthcheckTTL is how long a healthcheck result is reused when
+// healthcheck_ttl isn't set.
+const DefaultHealthcheckTTL = 24 * time.Hour
+
+// ParseHealthcheckTTL returns the healthcheck_ttl option as a duration, or
+// DefaultHealthcheckTTL if it isn't set.
+func (t ToolConfig) ParseHealthcheckTTL() (time.Duration, error) {
+	if t.HealthcheckTTL == "" {
+		return DefaultHealthcheckTTL, nil
+	}
+	ttl, err := time.ParseDuration(t.HealthcheckTTL)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid healthcheck_ttl '%s': expected a duration such as \"12h\" or \"30m\"", t.HealthcheckTTL)
+	}
+	return ttl, nil
+}
+
+// ArgRewrite replaces every argument equal to From with the arguments in To
+type ArgRewrite struct {
+	From string     `toml:"from" json:"from"`
+	To   StringList `toml:"to" json:"to"`
+}
+
+// RewriteArgs applies the tool's arg_rewrite rules to args, in order. Each
+// argument is compared to the From of every rule as a whole; the first
+// matching rule replaces it with its To arguments, which may be empty to drop
+// the argument.
+func (t ToolConfig) RewriteArgs(args []string) []string {
+	if len(t.ArgRewrite) == 0 {
+		return args
+	}
+
+	rewritten := make([]string, 0, len(args))
+	for _, arg := range args {
+		replaced := false
+		for _, rule := range t.ArgRewrite {
+			if arg == rule.From {
+				rewritten = append(rewritten, rule.To...)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			rewritten = append(rewritten, arg)
+		}
+	}
+	return rewritten
+}
+
+// Tool returns the settings for the named tool, or the zero value if the tool
+// has no [tools.<name>] table. A name with an extension also matches the table
+// for its base name.
+func (c *Config) Tool(name string) ToolConfig {
+	if tool, ok := c.Tools[name]; ok {
+		return tool
+	}
+	return c.Tools[strings.TrimSuffix(name, filepath.Ext(name))]
+}
+
+// StringList is a list of strings that can be written in TOML either as a
+// single string or as an array of strings.
+type StringList []string
+
+// UnmarshalTOML implements toml.Unmarshaler
+func (l *StringList) UnmarshalTOML(data any) error {
+	switch value := data.(type) {
+	case string:
+		*l = StringList{value}
+	case []any:
+		list := make(StringList, 0, len(value))
+		for _, item := range value {
+			str, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected a string, got %T", item)
+			}
+			list = append(list, str)
+		}
+		*l = list
+	default:
+		return fmt.Errorf("expected a string or a list of strings, got %T", data)
+	}
+	return nil
+}
+
+// ToolAllowed reports whether a tool may run according to allow_tools and
+// deny_tools. The names are the ways the tool is known (e.g. the requested
+// name and the resolved file name); each is matched with and without its
+// extension against the glob patterns in the lists. A tool is denied if any
+// name matches deny_tools, and when allow_tools is set at least one name
+// must match it.
+func (c *Config) ToolAllowed(names ...string) bool {
+	for _, name := range names {
+		if matchesAny(c.DenyTools, name) {
+			return false
+		}
+	}
+
+	if len(c.AllowTools) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if matchesAny(c.AllowTools, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether name, with or without its extension, matches any
+// of the glob patterns.
+func matchesAny(patterns []string, name string) bool {
+	baseName := strings.TrimSuffix(name, filepath.Ext(name))
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, baseName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultNamespaceSeparator separates the namespace from the tool name in
+// commands like "git:status" when namespace_separator is not set.
+const DefaultNamespaceSeparator = ":"
+
+// Namespace splits a namespaced command such as "git:status" into its
+// namespace segments and tool name. ok is false if the command isn't
+// namespaced.
+func (c *Config) Namespace(command string) (namespace []string, name string, ok bool) {
+	separator := c.NamespaceSeparator
+	if separator == "" {
+		separator = DefaultNamespaceSeparator
+	}
+
+	parts := strings.Split(command, separator)
+	if len(parts) < 2 {
+		return nil, "", false
+	}
+	// Each segment must be a plain name so that the command can't escape the
+	// tool path
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return nil, "", false
+		}
+	}
+	return parts[:len(parts)-1], parts[len(parts)-1], true
+}
+
+// ParseUmask returns the umask option as a number. ok is false when the
+// option is not set.
+func (c *Config) ParseUmask() (mask int, ok bool, err error) {
+	if c.Umask == "" {
+		return 0, false, nil
+	}
+	value, err := strconv.ParseUint(c.Umask, 8, 32)
+	if err != nil || value > 0777 {
+		return 0, false, fmt.Errorf("invalid umask '%s': expected an octal value such as \"022\"", c.Umask)
+	}
+	return int(value), true, nil
+}
+
+// memorySuffixes are the multipliers of the unit suffixes accepted by
+// max_memory
+var memorySuffixes = map[string]int64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// ParseMaxMemory returns the max_memory option in bytes. The value is a
+// number of bytes, optionally followed by a K, M, G or T suffix (powers of
+// 1024, e.g. "512M" or "2GiB"). ok is false when the option is not set.
+func (c *Config) ParseMaxMemory() (limit int64, ok bool, err error) {
+	if c.MaxMemory == "" {
+		return 0, false, nil
+	}
+	invalid := fmt.Errorf("invalid max_memory '%s': expected a number of bytes such as \"1073741824\" or \"512M\"", c.MaxMemory)
+
+	value := strings.ToUpper(strings.TrimSpace(c.MaxMemory))
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "B"), "I")
+	digits := strings.TrimRight(value, "KMGT")
+	multiplier, known := memorySuffixes[value[len(digits):]]
+	if !known {
+		return 0, false, invalid
+	}
+	number, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || number <= 0 || number > math.MaxInt64/multiplier {
+		return 0, false, invalid
+	}
+	return number * multiplier, true, nil
+}
+
+// Validate checks the option values that can't be verified by decoding alone.
+func (c *Config) Validate() error {
+	if _, _, err := c.ParseUmask(); err != nil {
+		return err
+	}
+	if _, _, err := c.ParseMaxMemory(); err != nil {
+		return err
+	}
+	switch c.EnvSetupOnError {
+	case "", EnvSetupOnErrorAbort, EnvSetupOnErrorWarn:
+	default:
+		return fmt.Errorf("invalid env_setup_on_error '%s': expected \"%s\" or \"%s\"", c.EnvSetupOnError, EnvSetupOnErrorAbort, EnvSetupOnErrorWarn)
+	}
+	switch c.ToolPathsEnvMode {
+	case "", ToolPathsEnvAppend, ToolPathsEnvReplace:
+	default:
+		return fmt.Errorf("invalid tool_paths_env_mode '%s': expected \"%s\" or \"%s\"", c.ToolPathsEnvMode, ToolPathsEnvAppend, ToolPathsEnvReplace)
+	}
+	for name, tool := range c.Tools {
+		if tool.MaxArgs != nil && *tool.MaxArgs < 0 {
+			return fmt.Errorf("invalid max_args %d for tool '%s': must not be negative", *tool.MaxArgs, name)
+		}
+		if _, err := tool.ParseHealthcheckTTL(); err != nil {
+			return fmt.Errorf("tool '%s': %w", name, err)
+		}
+		if tool.Nice < MinNice || tool.Nice > MaxNice {
+			return fmt.Errorf("invalid nice %d for tool '%s': must be between %d and %d", tool.Nice, name, MinNice, MaxNice)
+		}
+		for _, variable := range tool.RequiresEnv {
+			if variable == "" || strings.Contains(variable, "=") {
+				return fmt.Errorf("invalid requires_env entry '%s' for tool '%s': expected a variable name", variable, name)
+			}
+		}
+	}
+	for name, steps := range c.Sequences {
+		if len(steps) == 0 {
+			return fmt.Errorf("sequence '%s' has no steps", name)
+		}
+		for _, step := range steps {
+			if step == "" {
+				return fmt.Errorf("sequence '%s' has an empty step", name)
+			}
+			if _, ok := c.Sequences[step]; ok {
+				return fmt.Errorf("sequence '%s' can't run sequence '%s': steps must be tools", name, step)
+			}
+		}
+	}
+	for _, entry := range c.ToolPathTables {
+		if entry.Path == "" {
+			return fmt.Errorf("every [[tool_path]] entry needs a path")
+		}
+	}
+	seenCommands := make(map[string]bool)
+	for _, command := range c.Commands {
+		if command.Name == "" || command.Name == "." || command.Name == ".." || strings.ContainsAny(command.Name, `/\`) {
+			return fmt.Errorf("invalid [[command]] name '%s': expected a tool name", command.Name)
+		}
+		if command.Run == "" {
+			return fmt.Errorf("[[command]] '%s' needs a run snippet", command.Name)
+		}
+		if seenCommands[command.Name] {
+			return fmt.Errorf("[[command]] '%s' is defined more than once", command.Name)
+		}
+		if _, ok := c.Sequences[command.Name]; ok {
+			return fmt.Errorf("[[command]] '%s' has the same name as a sequence", command.Name)
+		}
+		seenCommands[command.Name] = true
+	}
+	if c.EnvSetup != "" && c.EnvSetupCmd != "" {
+		return fmt.Errorf("env_setup and env_setup_cmd cannot both be set")
+	}
+	if c.EnvSetupOutputFile != "" && c.EnvSetup == "" && c.EnvSetupCmd == "" {
+		return fmt.Errorf("env_setup_output_file requires env_setup or env_setup_cmd")
+	}
+	return nil
 }
 
 // Load loads the TOML configuration from an io.Reader
@@ -25,6 +462,21 @@ func Load(r io.Reader) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse .uber file: %w", err)
 	}
 
+	// Expand environment variables in the fields that hold paths
+	config.EnvSetup = config.expandEnv(config.EnvSetup)
+	config.ReportingCmds = config.expandEnvList(config.ReportingCmds)
+	config.setReportingCmd()
+	config.AfterSuccessCmd = config.expandEnvList(config.AfterSuccessCmd)
+	config.AfterFailureCmd = config.expandEnvList(config.AfterFailureCmd)
+	config.UberBinPath = config.expandEnv(config.UberBinPath)
+	config.EnvSetupOutputFile = config.expandEnv(config.EnvSetupOutputFile)
+	config.ToolPaths = config.expandEnvList(config.ToolPaths)
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid .uber file: %w", err)
+	}
+	config.addToolPathTables()
+
 	return &config, nil
 }
 
@@ -33,12 +485,240 @@ func LoadFromFile(projectRoot string) (*Config, error) {
 	uberFile := filepath.Join(projectRoot, ".uber")
 
 	// Open the TOML file
-	file, err := os.Open(uberFile)
+	file, err := openConfigFile(uberFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read .uber file: %w", err)
 	}
 	defer file.Close()
 
 	// Load the configuration
-	return Load(file)
+	config, err := Load(file)
+	if err != nil {
+		return nil, err
+	}
+	config.setCommandSources(uberFile)
+
+	// Overlay the optional .uber.local file, which holds personal overrides
+	// that are not committed to version control
+	localPath := filepath.Join(projectRoot, ".uber.local")
+	localFile, err := openConfigFile(localPath)
+	if err == nil {
+		defer localFile.Close()
+
+		if err := config.overlay(localFile); err != nil {
+			return nil, fmt.Errorf("failed to parse .uber.local file: %w", err)
+		}
+		config.setCommandSources(localPath)
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid .uber.local file: %w", err)
+		}
+		config.addToolPathTables()
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read .uber.local file: %w", err)
+	}
+
+	// Tool paths can also come from the environment, e.g. in CI
+	config.applyToolPathsEnv(os.Getenv(ToolPathsEnvVar))
+
+	return config, nil
+}
+
+// setReportingCmd sets the deprecated ReportingCmd to the first reporting
+// command.
+func (c *Config) setReportingCmd() {
+	c.ReportingCmd = ""
+	if len(c.ReportingCmds) > 0 {
+		c.ReportingCmd = c.ReportingCmds[0]
+	}
+}
+
+// setCommandSources records path as the source of the inline commands that
+// don't have one yet, i.e. those decoded from path.
+func (c *Config) setCommandSources(path string) {
+	for i := range c.Commands {
+		if c.Commands[i].Source == "" {
+			c.Commands[i].Source = path
+		}
+	}
+}
+
+// applyToolPathsEnv adds the tool paths listed in value, separated like PATH,
+// after the configured ones, or replaces them if tool_paths_env_mode is
+// "replace". Empty entries are ignored.
+func (c *Config) applyToolPathsEnv(value string) {
+	var paths []string
+	for _, path := range filepath.SplitList(value) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	if c.ToolPathsEnvMode == ToolPathsEnvReplace {
+		c.ToolPaths = paths
+		c.RequiredToolPaths = nil
+		return
+	}
+	c.ToolPaths = append(c.ToolPaths, paths...)
+}
+
+// Write encodes the configuration as TOML to w, using the same keys Load
+// reads. Unset fields are omitted. Note that a configuration returned by Load
+// holds the values after environment variable expansion.
+func (c *Config) Write(w io.Writer) error {
+	if err := toml.NewEncoder(w).Encode(c.encoded()); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return nil
+}
+
+// WriteJSON encodes the configuration as indented JSON to w, using the same
+// key names as the TOML file.
+func (c *Config) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c.encoded()); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return nil
+}
+
+// encoded returns a copy of the configuration holding what Write and
+// WriteJSON encode, with the deprecated ReportingCmd merged into
+// ReportingCmds. If some tool paths are required, every tool path is written
+// as a [[tool_path]] entry so that Load reads back the same search order
+// and required paths.
+func (c *Config) encoded() *Config {
+	encoded := *c
+	encoded.ReportingCmds = c.ReportingCommands()
+	if len(c.RequiredToolPaths) > 0 {
+		tables := make([]ToolPath, 0, len(c.ToolPaths)+len(c.ToolPathTables))
+		for _, path := range c.ToolPaths {
+			tables = append(tables, ToolPath{Path: path, Required: slices.Contains(c.RequiredToolPaths, path)})
+		}
+		encoded.ToolPathTables = append(tables, c.ToolPathTables...)
+		encoded.ToolPaths = nil
+	}
+	return &encoded
+}
+
+// Save writes the configuration to the .uber file in the project root,
+// replacing any existing file.
+func (c *Config) Save(projectRoot string) error {
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(projectRoot, ".uber"), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write .uber file: %w", err)
+	}
+	return nil
+}
+
+// openConfigFile opens a configuration file, returning a clear error if the
+// path is a directory rather than a file.
+func openConfigFile(path string) (*os.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("expected %s to be a file but found a directory", path)
+	}
+
+	return file, nil
+}
+
+// overlay decodes the TOML from r on top of the existing configuration.
+// Keys present in r override the current values, except for tool_paths
+// which are appended after the existing tool paths, and [[command]] entries
+// which replace the existing command of the same name or are added.
+func (c *Config) overlay(r io.Reader) error {
+	baseToolPaths := c.ToolPaths
+	baseCommands := c.Commands
+	c.ToolPaths = nil
+	c.Commands = nil
+
+	md, err := toml.NewDecoder(r).Decode(c)
+	if err != nil {
+		c.ToolPaths = baseToolPaths
+		c.Commands = baseCommands
+		return err
+	}
+
+	for _, command := range c.Commands {
+		if i := slices.IndexFunc(baseCommands, func(base InlineCommand) bool { return base.Name == command.Name }); i >= 0 {
+			baseCommands[i] = command
+		} else {
+			baseCommands = append(baseCommands, command)
+		}
+	}
+	c.Commands = baseCommands
+
+	// Only expand the values that came from the overlay, the others were
+	// already expanded
+	if md.IsDefined("env_setup") {
+		c.EnvSetup = c.expandEnv(c.EnvSetup)
+	}
+	if md.IsDefined("reporting_cmd") {
+		c.ReportingCmds = c.expandEnvList(c.ReportingCmds)
+		c.setReportingCmd()
+	}
+	if md.IsDefined("after_success_cmd") {
+		c.AfterSuccessCmd = c.expandEnvList(c.AfterSuccessCmd)
+	}
+	if md.IsDefined("after_failure_cmd") {
+		c.AfterFailureCmd = c.expandEnvList(c.AfterFailureCmd)
+	}
+	if md.IsDefined("uber_bin_path") {
+		c.UberBinPath = c.expandEnv(c.UberBinPath)
+	}
+	if md.IsDefined("env_setup_output_file") {
+		c.EnvSetupOutputFile = c.expandEnv(c.EnvSetupOutputFile)
+	}
+
+	c.ToolPaths = append(baseToolPaths, c.expandEnvList(c.ToolPaths)...)
+	return nil
+}
+
+// addToolPathTables appends the [[tool_path]] entries to ToolPaths after the
+// plain tool paths, recording the required ones in RequiredToolPaths.
+func (c *Config) addToolPathTables() {
+	for _, entry := range c.ToolPathTables {
+		path := c.expandEnv(entry.Path)
+		c.ToolPaths = append(c.ToolPaths, path)
+		if entry.Required {
+			c.RequiredToolPaths = append(c.RequiredToolPaths, path)
+		}
+	}
+	c.ToolPathTables = nil
+}
+
+// expandEnv replaces $VAR and ${VAR} in s with the values of environment
+// variables. Unset variables expand to an empty string and add a warning.
+func (c *Config) expandEnv(s string) string {
+	return os.Expand(s, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("environment variable '%s' used in '%s' is not set", name, s))
+		}
+		return value
+	})
+}
+
+// expandEnvList applies expandEnv to every value in list.
+func (c *Config) expandEnvList(list []string) []string {
+	for i, s := range list {
+		list[i] = c.expandEnv(s)
+	}
+	return list
 }
@@ -1,11 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -25,6 +27,15 @@ env_setup = "/path/to/setup.sh"`,
 			},
 			wantErr: false,
 		},
+		{
+			name:        "leading UTF-8 byte order mark",
+			tomlContent: "\ufefftool_paths = [\"bin\"]\nenv_setup = \"setup.sh\"",
+			want: &Config{
+				ToolPaths: []string{"bin"},
+				EnvSetup:  "setup.sh",
+			},
+			wantErr: false,
+		},
 		{
 			name:        "valid_tool_paths_with_mixed_relative_and_absolute",
 			tomlContent: `tool_paths = ["/usr/local/bin", "bin", "tools", "/opt/tools", "./scripts", "../external-tools"]`,
@@ -90,6 +101,43 @@ env_setup = "/path/to/setup.sh"`,
 			},
 			wantErr: false,
 		},
+		{
+			name:        "reporting_cmd as string",
+			tomlContent: `reporting_cmd = "scripts/report.sh"`,
+			want: &Config{
+				ReportingCmds: StringList{"scripts/report.sh"},
+				ReportingCmd:  "scripts/report.sh",
+			},
+			wantErr: false,
+		},
+		{
+			name:        "reporting_cmd as list",
+			tomlContent: `reporting_cmd = ["scripts/metrics.sh", "scripts/audit.sh"]`,
+			want: &Config{
+				ReportingCmds: StringList{"scripts/metrics.sh", "scripts/audit.sh"},
+				ReportingCmd:  "scripts/metrics.sh",
+			},
+			wantErr: false,
+		},
+		{
+			name:        "reporting_cmd with invalid type",
+			tomlContent: `reporting_cmd = 42`,
+			want:        nil,
+			wantErr:     true,
+		},
+		{
+			name: "tools table",
+			tomlContent: `
+[tools.deploy]
+cwd = "deploy"
+`,
+			want: &Config{
+				Tools: map[string]ToolConfig{
+					"deploy": {Cwd: "deploy"},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name:        "malformed_toml",
 			tomlContent: `tool_paths = [`,
@@ -168,3 +216,834 @@ func TestLoadFromFileNotFound(t *testing.T) {
 		t.Errorf("Expected error message to start with '%s', got '%s'", expectedErrorPrefix, err.Error())
 	}
 }
+
+func TestLoadFromFileWithLocalOverlay(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "uber-test-local-overlay")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	baseContent := `
+tool_paths = ["bin", "tools"]
+env_setup = "scripts/env.sh"
+reporting_cmd = "scripts/report.sh"
+`
+	localContent := `
+tool_paths = ["my-tools"]
+env_setup = "scripts/my-env.sh"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(baseContent), 0644); err != nil {
+		t.Fatalf("Failed to create .uber file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber.local"), []byte(localContent), 0644); err != nil {
+		t.Fatalf("Failed to create .uber.local file: %v", err)
+	}
+
+	got, err := LoadFromFile(tempDir)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	want := &Config{
+		ToolPaths:     []string{"bin", "tools", "my-tools"},
+		EnvSetup:      "scripts/my-env.sh",
+		ReportingCmds: StringList{"scripts/report.sh"},
+		ReportingCmd:  "scripts/report.sh",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFromFile() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadInlineCommands(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "uber-test-inline-commands")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	baseContent := `
+[[command]]
+name = "hello"
+run = "echo hello \"$@\""
+
+[[command]]
+name = "clean"
+run = "rm -rf build"
+`
+	localContent := `
+[[command]]
+name = "clean"
+run = "rm -rf build dist"
+
+[[command]]
+name = "serve"
+run = "python3 -m http.server"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(baseContent), 0644); err != nil {
+		t.Fatalf("Failed to create .uber file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber.local"), []byte(localContent), 0644); err != nil {
+		t.Fatalf("Failed to create .uber.local file: %v", err)
+	}
+
+	cfg, err := LoadFromFile(tempDir)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	uberFile, localFile := filepath.Join(tempDir, ".uber"), filepath.Join(tempDir, ".uber.local")
+	want := []InlineCommand{
+		{Name: "hello", Run: `echo hello "$@"`, Source: uberFile},
+		{Name: "clean", Run: "rm -rf build dist", Source: localFile},
+		{Name: "serve", Run: "python3 -m http.server", Source: localFile},
+	}
+	if !reflect.DeepEqual(cfg.Commands, want) {
+		t.Errorf("Expected commands %+v, got %+v", want, cfg.Commands)
+	}
+	if command, ok := cfg.Command("clean"); !ok || command.Run != "rm -rf build dist" {
+		t.Errorf("Expected .uber.local to override clean, got %+v, %v", command, ok)
+	}
+	if _, ok := cfg.Command("build"); ok {
+		t.Errorf("Expected no inline command named build")
+	}
+
+	invalid := map[string]string{
+		"[[command]]\nrun = \"true\"\n":                 "invalid [[command]] name",
+		"[[command]]\nname = \"a/b\"\nrun = \"true\"\n": "invalid [[command]] name",
+		"[[command]]\nname = \"a\"\n":                   "needs a run snippet",
+		"[[command]]\nname = \"a\"\nrun = \"true\"\n[[command]]\nname = \"a\"\nrun = \"false\"\n": "defined more than once",
+		"[sequences]\nci = [\"lint\"]\n[[command]]\nname = \"ci\"\nrun = \"true\"\n":              "same name as a sequence",
+	}
+	for data, want := range invalid {
+		if _, err := Load(strings.NewReader(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error containing %q for %q, got: %v", want, data, err)
+		}
+	}
+}
+
+func TestLoadFromFileToolPathTables(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "uber-test-tool-path-tables")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	baseContent := `
+tool_paths = ["bin"]
+
+[[tool_path]]
+path = "tools"
+required = true
+
+[[tool_path]]
+path = "extra"
+`
+	localContent := `
+[[tool_path]]
+path = "my-tools"
+required = true
+`
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(baseContent), 0644); err != nil {
+		t.Fatalf("Failed to create .uber file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber.local"), []byte(localContent), 0644); err != nil {
+		t.Fatalf("Failed to create .uber.local file: %v", err)
+	}
+
+	got, err := LoadFromFile(tempDir)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	want := &Config{
+		ToolPaths:         []string{"bin", "tools", "extra", "my-tools"},
+		RequiredToolPaths: []string{"tools", "my-tools"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFromFile() = %+v, want %+v", got, want)
+	}
+
+	if _, err := Load(strings.NewReader("[[tool_path]]\nrequired = true\n")); err == nil || !strings.Contains(err.Error(), "needs a path") {
+		t.Errorf("Expected an error for a [[tool_path]] without a path, got: %v", err)
+	}
+}
+
+func TestConfigWriteRequiredToolPaths(t *testing.T) {
+	original, err := Load(strings.NewReader("tool_paths = [\"bin\"]\n\n[[tool_path]]\npath = \"tools\"\nrequired = true\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Required paths stay required and keep their place in the search order
+	var buf strings.Builder
+	if err := original.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	loaded, err := Load(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Load failed: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(loaded.RequiredToolPaths, []string{"tools"}) || !reflect.DeepEqual(loaded, original) {
+		t.Errorf("Round trip mismatch:\ngot  %+v\nwant %+v\nTOML:\n%s", loaded, original, buf.String())
+	}
+
+	buf.Reset()
+	if err := original.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded Config
+	if err := json.Unmarshal([]byte(buf.String()), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	if want := []ToolPath{{Path: "bin"}, {Path: "tools", Required: true}}; !reflect.DeepEqual(decoded.ToolPathTables, want) {
+		t.Errorf("Expected the JSON to hold %+v, got %+v", want, decoded.ToolPathTables)
+	}
+}
+
+func TestLoadFromFileWithMalformedLocalOverlay(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "uber-test-local-overlay-malformed")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(`tool_paths = ["bin"]`), 0644); err != nil {
+		t.Fatalf("Failed to create .uber file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber.local"), []byte(`tool_paths = [`), 0644); err != nil {
+		t.Fatalf("Failed to create .uber.local file: %v", err)
+	}
+
+	_, err = LoadFromFile(tempDir)
+	if err == nil {
+		t.Fatal("Expected error for malformed .uber.local file, but got nil")
+	}
+	if !strings.Contains(err.Error(), ".uber.local") {
+		t.Errorf("Expected error to mention .uber.local, got '%s'", err.Error())
+	}
+}
+
+func TestConfigTool(t *testing.T) {
+	cfg := &Config{
+		Tools: map[string]ToolConfig{
+			"deploy": {Cwd: "deploy"},
+		},
+	}
+
+	if got := cfg.Tool("deploy"); got.Cwd != "deploy" {
+		t.Errorf("Tool(deploy).Cwd = '%s', want 'deploy'", got.Cwd)
+	}
+	if got := cfg.Tool("deploy.sh"); got.Cwd != "deploy" {
+		t.Errorf("Tool(deploy.sh).Cwd = '%s', want 'deploy'", got.Cwd)
+	}
+	if got := cfg.Tool("build"); !reflect.DeepEqual(got, ToolConfig{}) {
+		t.Errorf("Tool(build) = %+v, want zero value", got)
+	}
+	if got := (&Config{}).Tool("deploy"); !reflect.DeepEqual(got, ToolConfig{}) {
+		t.Errorf("Tool(deploy) without tools table = %+v, want zero value", got)
+	}
+}
+
+func TestLoadMaxArgs(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`
+[tools.clean]
+max_args = 0
+
+[tools.deploy]
+max_args = 2
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := cfg.Tool("clean").MaxArgs; got == nil || *got != 0 {
+		t.Errorf("Expected max_args 0 to be kept for clean, got %v", got)
+	}
+	if got := cfg.Tool("deploy").MaxArgs; got == nil || *got != 2 {
+		t.Errorf("Expected max_args 2 for deploy, got %v", got)
+	}
+	if got := cfg.Tool("build").MaxArgs; got != nil {
+		t.Errorf("Expected no max_args for build, got %d", *got)
+	}
+
+	if _, err := Load(strings.NewReader("[tools.deploy]\nmax_args = -1\n")); err == nil || !strings.Contains(err.Error(), "max_args") {
+		t.Errorf("Expected an error for a negative max_args, got: %v", err)
+	}
+}
+
+func TestLoadHealthcheck(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`
+[tools.deploy]
+healthcheck = ["--selftest"]
+healthcheck_ttl = "30m"
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	tool := cfg.Tool("deploy")
+	if !reflect.DeepEqual(tool.Healthcheck, []string{"--selftest"}) {
+		t.Errorf("Expected healthcheck [--selftest], got %v", tool.Healthcheck)
+	}
+	if ttl, err := tool.ParseHealthcheckTTL(); err != nil || ttl != 30*time.Minute {
+		t.Errorf("Expected a TTL of 30m, got %v (%v)", ttl, err)
+	}
+	if ttl, _ := cfg.Tool("build").ParseHealthcheckTTL(); ttl != DefaultHealthcheckTTL {
+		t.Errorf("Expected the default TTL, got %v", ttl)
+	}
+
+	if _, err := Load(strings.NewReader("[tools.deploy]\nhealthcheck_ttl = \"soon\"\n")); err == nil || !strings.Contains(err.Error(), "healthcheck_ttl") {
+		t.Errorf("Expected an error for an invalid healthcheck_ttl, got: %v", err)
+	}
+}
+
+func TestLoadRequiresEnv(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`
+[tools.deploy]
+requires_env = ["AWS_REGION", "DEPLOY_KEY"]
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got, want := cfg.Tool("deploy").RequiresEnv, []string{"AWS_REGION", "DEPLOY_KEY"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected requires_env %v, got %v", want, got)
+	}
+
+	for _, data := range []string{"[tools.deploy]\nrequires_env = [\"\"]\n", "[tools.deploy]\nrequires_env = [\"A=1\"]\n"} {
+		if _, err := Load(strings.NewReader(data)); err == nil || !strings.Contains(err.Error(), "requires_env") {
+			t.Errorf("Expected an error for %q, got: %v", data, err)
+		}
+	}
+}
+
+func TestLoadNice(t *testing.T) {
+	cfg, err := Load(strings.NewReader("[tools.build]\nnice = 10\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := cfg.Tool("build").Nice; got != 10 {
+		t.Errorf("Expected nice 10, got %d", got)
+	}
+
+	for _, data := range []string{"[tools.build]\nnice = 20\n", "[tools.build]\nnice = -21\n"} {
+		if _, err := Load(strings.NewReader(data)); err == nil || !strings.Contains(err.Error(), "invalid nice") {
+			t.Errorf("Expected an error for %q, got: %v", data, err)
+		}
+	}
+}
+
+func TestLoadSequences(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`
+[sequences]
+release = ["build", "test", "publish"]
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got, want := cfg.Sequences["release"], []string{"build", "test", "publish"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected release steps %v, got %v", want, got)
+	}
+
+	invalid := map[string]string{
+		"[sequences]\nrelease = []\n":                              "no steps",
+		"[sequences]\nrelease = [\"build\", \"\"]\n":               "empty step",
+		"[sequences]\nci = [\"release\"]\nrelease = [\"build\"]\n": "steps must be tools",
+	}
+	for data, want := range invalid {
+		if _, err := Load(strings.NewReader(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error containing %q for %q, got: %v", want, data, err)
+		}
+	}
+}
+
+func TestToolConfigRewriteArgs(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`
+[tools.deploy]
+arg_rewrite = [
+  { from = "--prod", to = ["--env", "production"] },
+  { from = "--staging", to = "--env=staging" },
+  { from = "--legacy", to = [] },
+]
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	got := cfg.Tool("deploy").RewriteArgs([]string{"--prod", "--staging", "--legacy", "--prod-like", "x"})
+	want := []string{"--env", "production", "--env=staging", "--prod-like", "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RewriteArgs() = %q, want %q", got, want)
+	}
+
+	args := []string{"--prod"}
+	if got := cfg.Tool("build").RewriteArgs(args); !reflect.DeepEqual(got, args) {
+		t.Errorf("Expected args to be unchanged without arg_rewrite, got %q", got)
+	}
+}
+
+func TestConfigToolAllowed(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		names []string
+		want  bool
+	}{
+		{
+			name:  "no lists",
+			cfg:   Config{},
+			names: []string{"deploy"},
+			want:  true,
+		},
+		{
+			name:  "denied by exact name",
+			cfg:   Config{DenyTools: []string{"deploy"}},
+			names: []string{"deploy"},
+			want:  false,
+		},
+		{
+			name:  "denied by name without extension",
+			cfg:   Config{DenyTools: []string{"deploy"}},
+			names: []string{"deploy", "deploy.sh"},
+			want:  false,
+		},
+		{
+			name:  "denied by glob",
+			cfg:   Config{DenyTools: []string{"prod-*"}},
+			names: []string{"prod-migrate"},
+			want:  false,
+		},
+		{
+			name:  "allowed by glob",
+			cfg:   Config{AllowTools: []string{"build*", "test"}},
+			names: []string{"build-all"},
+			want:  true,
+		},
+		{
+			name:  "not in allow list",
+			cfg:   Config{AllowTools: []string{"build*", "test"}},
+			names: []string{"deploy"},
+			want:  false,
+		},
+		{
+			name:  "deny wins over allow",
+			cfg:   Config{AllowTools: []string{"*"}, DenyTools: []string{"deploy"}},
+			names: []string{"deploy"},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ToolAllowed(tt.names...); got != tt.want {
+				t.Errorf("ToolAllowed(%v) = %v, want %v", tt.names, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigParseUmask(t *testing.T) {
+	tests := []struct {
+		umask   string
+		want    int
+		wantOK  bool
+		wantErr bool
+	}{
+		{umask: "", want: 0, wantOK: false},
+		{umask: "022", want: 0o22, wantOK: true},
+		{umask: "0002", want: 0o2, wantOK: true},
+		{umask: "777", want: 0o777, wantOK: true},
+		{umask: "089", wantErr: true},
+		{umask: "1000", wantErr: true},
+		{umask: "rw-r--r--", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.umask, func(t *testing.T) {
+			cfg := &Config{Umask: tt.umask}
+			got, ok, err := cfg.ParseUmask()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUmask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseUmask() = %o, %v, want %o, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestConfigParseMaxMemory(t *testing.T) {
+	tests := []struct {
+		maxMemory string
+		want      int64
+		wantOK    bool
+		wantErr   bool
+	}{
+		{maxMemory: "", want: 0, wantOK: false},
+		{maxMemory: "1048576", want: 1 << 20, wantOK: true},
+		{maxMemory: "512M", want: 512 << 20, wantOK: true},
+		{maxMemory: "512m", want: 512 << 20, wantOK: true},
+		{maxMemory: "2GiB", want: 2 << 30, wantOK: true},
+		{maxMemory: "64KB", want: 64 << 10, wantOK: true},
+		{maxMemory: "0", wantErr: true},
+		{maxMemory: "-1G", wantErr: true},
+		{maxMemory: "1.5G", wantErr: true},
+		{maxMemory: "10X", wantErr: true},
+		{maxMemory: "G", wantErr: true},
+		{maxMemory: "99999999999T", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.maxMemory, func(t *testing.T) {
+			cfg := &Config{MaxMemory: tt.maxMemory}
+			got, ok, err := cfg.ParseMaxMemory()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMaxMemory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseMaxMemory() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidUmask(t *testing.T) {
+	_, err := Load(strings.NewReader(`umask = "999"`))
+	if err == nil {
+		t.Fatal("Expected error for an invalid umask, got nil")
+	}
+	if !strings.Contains(err.Error(), "invalid umask") {
+		t.Errorf("Expected error to mention the invalid umask, got: %v", err)
+	}
+}
+
+func TestLoadInvalidEnvSetupOnError(t *testing.T) {
+	_, err := Load(strings.NewReader(`env_setup_on_error = "ignore"`))
+	if err == nil {
+		t.Fatal("Expected error for an invalid env_setup_on_error, got nil")
+	}
+	if !strings.Contains(err.Error(), "env_setup_on_error") {
+		t.Errorf("Expected error to mention env_setup_on_error, got: %v", err)
+	}
+}
+
+func TestLoadEnvSetupAndEnvSetupCmd(t *testing.T) {
+	_, err := Load(strings.NewReader("env_setup = \"setup.sh\"\nenv_setup_cmd = \"direnv export bash\""))
+	if err == nil {
+		t.Fatal("Expected error when both env_setup and env_setup_cmd are set, got nil")
+	}
+	if !strings.Contains(err.Error(), "env_setup_cmd") {
+		t.Errorf("Expected error to mention env_setup_cmd, got: %v", err)
+	}
+}
+
+func TestLoadEnvSetupOutputFileWithoutEnvSetup(t *testing.T) {
+	_, err := Load(strings.NewReader(`env_setup_output_file = ".envrc.generated"`))
+	if err == nil {
+		t.Fatal("Expected error for env_setup_output_file without env_setup, got nil")
+	}
+	if !strings.Contains(err.Error(), "env_setup_output_file") {
+		t.Errorf("Expected error to mention env_setup_output_file, got: %v", err)
+	}
+}
+
+func TestLoadExpandsEnv(t *testing.T) {
+	t.Setenv("UBER_TEST_TOOLS", "/opt/tools")
+	t.Setenv("UBER_TEST_TOOLCHAIN", "/opt/toolchain")
+
+	tomlContent := `
+tool_paths = ["$UBER_TEST_TOOLS/bin", "bin"]
+env_setup = "${UBER_TEST_TOOLCHAIN}/setup"
+reporting_cmd = ["$UBER_TEST_TOOLS/report.sh", "${UBER_TEST_UNSET}/audit.sh"]
+`
+	cfg, err := Load(strings.NewReader(tomlContent))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if want := []string{"/opt/tools/bin", "bin"}; !reflect.DeepEqual(cfg.ToolPaths, want) {
+		t.Errorf("ToolPaths = %v, want %v", cfg.ToolPaths, want)
+	}
+	if cfg.EnvSetup != "/opt/toolchain/setup" {
+		t.Errorf("EnvSetup = '%s', want '/opt/toolchain/setup'", cfg.EnvSetup)
+	}
+	if want := (StringList{"/opt/tools/report.sh", "/audit.sh"}); !reflect.DeepEqual(cfg.ReportingCmds, want) {
+		t.Errorf("ReportingCmds = %v, want %v", cfg.ReportingCmds, want)
+	}
+
+	// The unset variable expands to empty with a warning
+	if len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], "UBER_TEST_UNSET") {
+		t.Errorf("Expected one warning about UBER_TEST_UNSET, got %v", cfg.Warnings)
+	}
+}
+
+func TestLoadFromFileExpandsLocalOverlayEnv(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("UBER_TEST_HOME", "/home/me")
+
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(`tool_paths = ["bin"]
+env_setup = "setup.sh"`), 0644); err != nil {
+		t.Fatalf("Failed to write .uber: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber.local"), []byte(`tool_paths = ["$UBER_TEST_HOME/tools"]`), 0644); err != nil {
+		t.Fatalf("Failed to write .uber.local: %v", err)
+	}
+
+	cfg, err := LoadFromFile(tempDir)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if want := []string{"bin", "/home/me/tools"}; !reflect.DeepEqual(cfg.ToolPaths, want) {
+		t.Errorf("ToolPaths = %v, want %v", cfg.ToolPaths, want)
+	}
+	if cfg.EnvSetup != "setup.sh" {
+		t.Errorf("EnvSetup = '%s', want 'setup.sh'", cfg.EnvSetup)
+	}
+	if len(cfg.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", cfg.Warnings)
+	}
+}
+
+func TestConfigNamespace(t *testing.T) {
+	tests := []struct {
+		name          string
+		separator     string
+		command       string
+		wantNamespace []string
+		wantName      string
+		wantOK        bool
+	}{
+		{name: "not namespaced", command: "status", wantOK: false},
+		{name: "default separator", command: "git:status", wantNamespace: []string{"git"}, wantName: "status", wantOK: true},
+		{name: "nested", command: "cloud:db:migrate", wantNamespace: []string{"cloud", "db"}, wantName: "migrate", wantOK: true},
+		{name: "custom separator", separator: "/", command: "git/status", wantNamespace: []string{"git"}, wantName: "status", wantOK: true},
+		{name: "custom separator ignores colon", separator: "/", command: "git:status", wantOK: false},
+		{name: "empty segment", command: "git::status", wantOK: false},
+		{name: "parent directory", command: "..:status", wantOK: false},
+		{name: "path in segment", command: "a/b:status", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{NamespaceSeparator: tt.separator}
+			namespace, name, ok := cfg.Namespace(tt.command)
+			if ok != tt.wantOK {
+				t.Fatalf("Namespace(%s) ok = %v, want %v", tt.command, ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(namespace, tt.wantNamespace) || name != tt.wantName {
+				t.Errorf("Namespace(%s) = %v, %s, want %v, %s", tt.command, namespace, name, tt.wantNamespace, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestLoadFromFileDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tempDir, ".uber"), 0755); err != nil {
+		t.Fatalf("Failed to create .uber directory: %v", err)
+	}
+
+	_, err := LoadFromFile(tempDir)
+	if err == nil {
+		t.Fatal("Expected error when .uber is a directory, got nil")
+	}
+	if !strings.Contains(err.Error(), "to be a file but found a directory") {
+		t.Errorf("Expected a clear error about the directory, got: %v", err)
+	}
+}
+
+func TestConfigWriteRoundTrip(t *testing.T) {
+	original := &Config{
+		ToolPaths:            []string{"bin", "/opt/tools"},
+		EnvSetup:             "scripts/setup.sh",
+		ReportingCmds:        StringList{"scripts/report.sh", "scripts/audit.sh"},
+		ReportingCmd:         "scripts/report.sh",
+		Strict:               true,
+		EnvSetupMaxLineBytes: 4096,
+		Umask:                "022",
+		Tools: map[string]ToolConfig{
+			"deploy": {Cwd: "deploy"},
+			"serve":  {Pidfile: "run/serve.pid", LogFile: "logs/serve.log"},
+		},
+		ForwardSignals: []string{"SIGHUP"},
+	}
+
+	var buf strings.Builder
+	if err := original.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Unset fields are left out
+	for _, key := range []string{"git_env", "env_setup_on_error", "allow_tools", "warnings", "nice"} {
+		if strings.Contains(buf.String(), key) {
+			t.Errorf("Expected unset key '%s' to be omitted, got:\n%s", key, buf.String())
+		}
+	}
+	var minimal strings.Builder
+	if err := (&Config{ToolPaths: []string{"bin"}}).Write(&minimal); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if strings.Contains(minimal.String(), "env_setup_max_line_bytes") {
+		t.Errorf("Expected unset key 'env_setup_max_line_bytes' to be omitted, got:\n%s", minimal.String())
+	}
+
+	loaded, err := Load(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Load failed: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(loaded, original) {
+		t.Errorf("Round trip mismatch:\ngot  %+v\nwant %+v\nTOML:\n%s", loaded, original, buf.String())
+	}
+}
+
+func TestConfigWriteJSON(t *testing.T) {
+	c := &Config{
+		ToolPaths: []string{"bin"},
+		Tools:     map[string]ToolConfig{"deploy": {Cwd: "deploy"}},
+		Warnings:  []string{"not serialized"},
+	}
+
+	var buf strings.Builder
+	if err := c.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(buf.String()), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	want := map[string]any{
+		"tool_paths": []any{"bin"},
+		"tools":      map[string]any{"deploy": map[string]any{"cwd": "deploy"}},
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("Expected %v, got %v", want, decoded)
+	}
+}
+
+func TestConfigSave(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "uber-config-save")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	original := &Config{ToolPaths: []string{"bin"}, GitEnv: true}
+	if err := original.Save(tempDir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadFromFile(tempDir)
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, original) {
+		t.Errorf("LoadFromFile() = %+v, want %+v", loaded, original)
+	}
+}
+
+func TestLoadFromFileToolPathsEnv(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "uber-test-tool-paths-env")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(`tool_paths = ["bin"]`), 0644); err != nil {
+		t.Fatalf("Failed to create .uber file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber.local"), []byte(`tool_paths = ["my-tools"]`), 0644); err != nil {
+		t.Fatalf("Failed to create .uber.local file: %v", err)
+	}
+
+	sep := string(filepath.ListSeparator)
+	t.Setenv(ToolPathsEnvVar, "/ci/tools"+sep+sep+"ci-bin")
+
+	got, err := LoadFromFile(tempDir)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if want := []string{"bin", "my-tools", "/ci/tools", "ci-bin"}; !reflect.DeepEqual(got.ToolPaths, want) {
+		t.Errorf("ToolPaths = %q, want %q", got.ToolPaths, want)
+	}
+
+	// The environment can replace the configured tool paths instead
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber.local"), []byte(`tool_paths_env_mode = "replace"`), 0644); err != nil {
+		t.Fatalf("Failed to create .uber.local file: %v", err)
+	}
+	got, err = LoadFromFile(tempDir)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if want := []string{"/ci/tools", "ci-bin"}; !reflect.DeepEqual(got.ToolPaths, want) {
+		t.Errorf("ToolPaths = %q, want %q", got.ToolPaths, want)
+	}
+
+	// An empty variable leaves the configuration alone
+	t.Setenv(ToolPathsEnvVar, "")
+	got, err = LoadFromFile(tempDir)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if want := []string{"bin"}; !reflect.DeepEqual(got.ToolPaths, want) {
+		t.Errorf("ToolPaths = %q, want %q", got.ToolPaths, want)
+	}
+}
+
+func TestLoadInvalidToolPathsEnvMode(t *testing.T) {
+	_, err := Load(strings.NewReader(`tool_paths_env_mode = "prepend"`))
+	if err == nil || !strings.Contains(err.Error(), "tool_paths_env_mode") {
+		t.Errorf("Expected error to mention tool_paths_env_mode, got: %v", err)
+	}
+}
+
+func TestReportingCommands(t *testing.T) {
+	// Callers that only set the single command keep working
+	legacy := &Config{ReportingCmd: "scripts/report.sh"}
+	if got := legacy.ReportingCommands(); !reflect.DeepEqual(got, []string{"scripts/report.sh"}) {
+		t.Errorf("ReportingCommands() = %v, want [scripts/report.sh]", got)
+	}
+
+	cfg, err := Load(strings.NewReader(`reporting_cmd = ["scripts/metrics.sh", "scripts/audit.sh"]`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.ReportingCommands(); !reflect.DeepEqual(got, []string{"scripts/metrics.sh", "scripts/audit.sh"}) {
+		t.Errorf("ReportingCommands() = %v, want both commands", got)
+	}
+	if cfg.ReportingCmd != "scripts/metrics.sh" {
+		t.Errorf("Expected ReportingCmd to hold the first command, got %q", cfg.ReportingCmd)
+	}
+
+	// Setting ReportingCmd after Load replaces the first command
+	cfg.ReportingCmd = "b.sh"
+	if got := cfg.ReportingCommands(); !reflect.DeepEqual(got, []string{"b.sh", "scripts/audit.sh"}) {
+		t.Errorf("ReportingCommands() = %v, want [b.sh scripts/audit.sh]", got)
+	}
+
+	if got := (&Config{}).ReportingCommands(); got != nil {
+		t.Errorf("Expected no reporting commands, got %v", got)
+	}
+}
+
+func TestConfigWriteReportingCmd(t *testing.T) {
+	// A caller that only sets the deprecated field still gets it written
+	legacy := &Config{ToolPaths: []string{"bin"}, ReportingCmd: "scripts/report.sh"}
+	var buf strings.Builder
+	if err := legacy.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	loaded, err := Load(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Load failed: %v\n%s", err, buf.String())
+	}
+	if got := loaded.ReportingCommands(); !reflect.DeepEqual(got, []string{"scripts/report.sh"}) {
+		t.Errorf("ReportingCommands() = %v after writing:\n%s", got, buf.String())
+	}
+
+	var jsonBuf strings.Builder
+	if err := legacy.WriteJSON(&jsonBuf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if !strings.Contains(jsonBuf.String(), `"reporting_cmd"`) {
+		t.Errorf("Expected reporting_cmd in the JSON, got:\n%s", jsonBuf.String())
+	}
+}
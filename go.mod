@@ -4,5 +4,9 @@ go 1.24.4
 
 require (
 	github.com/BurntSushi/toml v1.5.0
+	github.com/creack/pty v1.1.24
 	github.com/spf13/pflag v1.0.6
+	golang.org/x/term v0.30.0
 )
+
+require golang.org/x/sys v0.31.0 // indirect
@@ -9,7 +9,7 @@ import (
 
 func main() {
 	if err := uber.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fmt.Fprint(os.Stderr, uber.PrefixMessage(fmt.Sprintf("Error: %v\n", err)))
+		os.Exit(uber.ExitCode(err))
 	}
 }
@@ -0,0 +1,293 @@
+package uber
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// positionalArgsKey is the property that holds the positional arguments when
+// a tool's arguments are validated against its args_schema
+const positionalArgsKey = "_"
+
+// argsSchema is the subset of JSON Schema supported by args_schema. Other
+// keywords are ignored.
+type argsSchema struct {
+	Type                 string                 `json:"type"`
+	Properties           map[string]*argsSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Enum                 []any                  `json:"enum"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	Items                *argsSchema            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+}
+
+// loadArgsSchema reads the JSON schema at path, relative to the project root.
+func (te *ToolExecutor) loadArgsSchema(path string) (*argsSchema, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(te.ctx.Root, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, withKind(ErrConfig, fmt.Errorf("failed to read args_schema: %w", err))
+	}
+	var schema argsSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, withKind(ErrConfig, fmt.Errorf("invalid args_schema '%s': %w", path, err))
+	}
+	// The arguments are always validated as an object, so a schema without
+	// a type still checks its properties
+	switch schema.Type {
+	case "":
+		schema.Type = "object"
+	case "object":
+	default:
+		return nil, withKind(ErrConfig, fmt.Errorf("invalid args_schema '%s': the top-level type must be \"object\", got %q", path, schema.Type))
+	}
+	return &schema, nil
+}
+
+// validateArgs checks the tool's arguments against its args_schema, if it has
+// one, and returns a usage error listing every violation.
+func (te *ToolExecutor) validateArgs(toolName string, args []string) error {
+	schemaPath := te.ctx.ToolConfig.ArgsSchema
+	if schemaPath == "" {
+		return nil
+	}
+	schema, err := te.loadArgsSchema(schemaPath)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	schema.validate(parseArgsObject(args, schema), "arguments", &problems)
+	if len(problems) > 0 {
+		return withKind(ErrUsage, fmt.Errorf("arguments of tool '%s' don't match %s:\n  %s", toolName, schemaPath, strings.Join(problems, "\n  ")))
+	}
+	return nil
+}
+
+// parseArgsObject turns command line arguments into the object validated by
+// the schema: --name=value and --name value become "name": "value", a flag
+// without a value or declared as boolean becomes "name": true, a repeated
+// flag collects its values in an array and positional arguments are
+// collected under "_".
+func parseArgsObject(args []string, schema *argsSchema) map[string]any {
+	object := map[string]any{}
+	add := func(name string, value any) {
+		switch existing := object[name].(type) {
+		case nil:
+			object[name] = value
+		case []any:
+			object[name] = append(existing, value)
+		default:
+			object[name] = []any{existing, value}
+		}
+	}
+
+	positional := []any{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			for _, rest := range args[i+1:] {
+				positional = append(positional, rest)
+			}
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if hasValue {
+			add(name, value)
+			continue
+		}
+		isBool, isNumber := false, false
+		if property := schema.Properties[name]; property != nil {
+			isBool = property.Type == "boolean"
+			isNumber = property.isNumber() || (property.Type == "array" && property.Items != nil && property.Items.isNumber())
+		}
+		// A negative number is the value of a numeric flag, not another flag
+		if !isBool && i+1 < len(args) && (!strings.HasPrefix(args[i+1], "-") || isNumber && isNumeric(args[i+1])) {
+			i++
+			add(name, args[i])
+			continue
+		}
+		add(name, true)
+	}
+	object[positionalArgsKey] = positional
+	return object
+}
+
+// isNumber reports whether the schema describes a number.
+func (s *argsSchema) isNumber() bool {
+	return s.Type == "number" || s.Type == "integer"
+}
+
+// isNumeric reports whether arg is a number, such as "-1" or "-0.5".
+func isNumeric(arg string) bool {
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil
+}
+
+// validate appends a description of every way value violates the schema to
+// problems. name describes the value in those descriptions.
+func (s *argsSchema) validate(value any, name string, problems *[]string) {
+	report := func(format string, args ...any) {
+		*problems = append(*problems, name+": "+fmt.Sprintf(format, args...))
+	}
+
+	// A flag given once is still a valid array
+	if s.Type == "array" {
+		if _, ok := value.([]any); !ok {
+			value = []any{value}
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			report("expected an object")
+			return
+		}
+		s.validateObject(object, problems)
+		return
+	case "array":
+		items := value.([]any)
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			report("expected at least %d value(s), got %d", *s.MinItems, len(items))
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			report("expected at most %d value(s), got %d", *s.MaxItems, len(items))
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", name, i), problems)
+			}
+		}
+		return
+	case "boolean":
+		if _, ok := value.(bool); !ok && value != "true" && value != "false" {
+			report("expected a boolean, got %s", describeArgValue(value))
+			return
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			report("expected a value, got %s", describeArgValue(value))
+			return
+		}
+	case "number", "integer":
+		number, ok := argNumber(value)
+		if !ok || (s.Type == "integer" && number != math.Trunc(number)) {
+			expected := "a number"
+			if s.Type == "integer" {
+				expected = "an integer"
+			}
+			report("expected %s, got %s", expected, describeArgValue(value))
+			return
+		}
+		if s.Minimum != nil && number < *s.Minimum {
+			report("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			report("must be at most %v", *s.Maximum)
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		text := fmt.Sprint(value)
+		if !slices.ContainsFunc(s.Enum, func(allowed any) bool { return fmt.Sprint(allowed) == text }) {
+			allowed := make([]string, len(s.Enum))
+			for i, value := range s.Enum {
+				allowed[i] = fmt.Sprintf("%q", fmt.Sprint(value))
+			}
+			report("must be one of %s, got %s", strings.Join(allowed, ", "), describeArgValue(value))
+		}
+	}
+	if s.Pattern != "" {
+		if text, ok := value.(string); ok {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				report("invalid pattern %q in schema: %v", s.Pattern, err)
+			} else if !re.MatchString(text) {
+				report("%q does not match the pattern %q", text, s.Pattern)
+			}
+		}
+	}
+}
+
+// validateObject checks the properties of the arguments object. Flags are
+// named as they are given on the command line.
+func (s *argsSchema) validateObject(object map[string]any, problems *[]string) {
+	for _, required := range s.Required {
+		if _, ok := object[required]; !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: required", argDisplayName(required)))
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties && name != positionalArgsKey {
+				*problems = append(*problems, fmt.Sprintf("%s: unknown flag", argDisplayName(name)))
+			}
+			continue
+		}
+		// A property declared as null accepts any value
+		if property != nil {
+			property.validate(object[name], argDisplayName(name), problems)
+		}
+	}
+}
+
+// argDisplayName returns how a property of the arguments object is named in
+// validation errors.
+func argDisplayName(name string) string {
+	if name == positionalArgsKey {
+		return "positional arguments"
+	}
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// argNumber returns value as a number if it is one or is text holding one.
+func argNumber(value any) (float64, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	return number, err == nil
+}
+
+// describeArgValue describes an argument value in validation errors.
+func describeArgValue(value any) string {
+	switch value := value.(type) {
+	case bool:
+		return "no value"
+	case []any:
+		return fmt.Sprintf("%d values", len(value))
+	default:
+		return fmt.Sprintf("%q", fmt.Sprint(value))
+	}
+}
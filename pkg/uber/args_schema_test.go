@@ -0,0 +1,231 @@
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+const deployArgsSchema = `{
+  "type": "object",
+  "properties": {
+    "env": {"type": "string", "enum": ["staging", "production"]},
+    "replicas": {"type": "integer", "minimum": 1, "maximum": 10},
+    "dry-run": {"type": "boolean"},
+    "tag": {"type": "array", "items": {"type": "string", "pattern": "^v[0-9]+$"}, "maxItems": 2},
+    "_": {"type": "array", "maxItems": 1}
+  },
+  "required": ["env"],
+  "additionalProperties": false
+}`
+
+func TestValidateArgs(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-args-schema")
+	defer cleanup()
+	if err := os.MkdirAll(filepath.Join(tempDir, "schemas"), 0755); err != nil {
+		t.Fatalf("Failed to create schemas directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "schemas", "deploy.json"), []byte(deployArgsSchema), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		// wantProblems are the problems expected in the error, none if empty
+		wantProblems []string
+	}{
+		{
+			name: "required flag only",
+			args: []string{"--env", "staging"},
+		},
+		{
+			name: "every flag",
+			args: []string{"--env=production", "--replicas", "3", "--dry-run", "--tag", "v1", "--tag=v2", "app"},
+		},
+		{
+			name: "positional after separator",
+			args: []string{"--env", "staging", "--", "--not-a-flag"},
+		},
+		{
+			name:         "missing required flag",
+			args:         []string{"--replicas", "3"},
+			wantProblems: []string{"--env: required"},
+		},
+		{
+			name:         "value not in enum",
+			args:         []string{"--env", "dev"},
+			wantProblems: []string{`--env: must be one of "staging", "production", got "dev"`},
+		},
+		{
+			name:         "flag without value",
+			args:         []string{"--env"},
+			wantProblems: []string{"--env: expected a value, got no value"},
+		},
+		{
+			name: "bad numbers",
+			args: []string{"--env", "staging", "--replicas", "many"},
+			wantProblems: []string{
+				`--replicas: expected an integer, got "many"`,
+			},
+		},
+		{
+			name:         "negative number",
+			args:         []string{"--env", "staging", "--replicas", "-1"},
+			wantProblems: []string{"--replicas: must be at least 1"},
+		},
+		{
+			name:         "number out of range",
+			args:         []string{"--env", "staging", "--replicas=20"},
+			wantProblems: []string{"--replicas: must be at most 10"},
+		},
+		{
+			name: "bad array items",
+			args: []string{"--env", "staging", "--tag", "v1", "--tag", "latest", "--tag", "v3"},
+			wantProblems: []string{
+				"--tag: expected at most 2 value(s), got 3",
+				`--tag[1]: "latest" does not match the pattern "^v[0-9]+$"`,
+			},
+		},
+		{
+			name: "unknown flag and extra positional arguments",
+			args: []string{"--env", "staging", "-f", "one", "two", "three"},
+			wantProblems: []string{
+				"positional arguments: expected at most 1 value(s), got 2",
+				"-f: unknown flag",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewToolExecutor(&RunContext{
+				Root:       tempDir,
+				ToolConfig: config.ToolConfig{ArgsSchema: "schemas/deploy.json"},
+			})
+			err := executor.validateArgs("deploy", tt.args)
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Errorf("Expected the arguments to pass, got: %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrUsage) {
+				t.Fatalf("Expected ErrUsage, got: %v", err)
+			}
+			want := "arguments of tool 'deploy' don't match schemas/deploy.json:\n  " + strings.Join(tt.wantProblems, "\n  ")
+			if err.Error() != want {
+				t.Errorf("Error = %q, want %q", err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestValidateArgsSchemaErrors(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-args-schema-errors")
+	defer cleanup()
+	if err := os.WriteFile(filepath.Join(tempDir, "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "array.json"), []byte(`{"type": "array"}`), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	for _, schemaPath := range []string{"missing.json", "broken.json", "array.json"} {
+		executor := NewToolExecutor(&RunContext{
+			Root:       tempDir,
+			ToolConfig: config.ToolConfig{ArgsSchema: schemaPath},
+		})
+		if err := executor.validateArgs("deploy", nil); !errors.Is(err, ErrConfig) {
+			t.Errorf("Expected ErrConfig for %s, got: %v", schemaPath, err)
+		}
+	}
+}
+
+func TestValidateArgsSchemaWithoutType(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-args-schema-untyped")
+	defer cleanup()
+	schema := `{"properties": {"env": {"type": "string"}}, "required": ["env"], "additionalProperties": false}`
+	if err := os.WriteFile(filepath.Join(tempDir, "deploy.json"), []byte(schema), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	// Without a top-level type the arguments are still checked as an object
+	executor := NewToolExecutor(&RunContext{
+		Root:       tempDir,
+		ToolConfig: config.ToolConfig{ArgsSchema: "deploy.json"},
+	})
+	err := executor.validateArgs("deploy", []string{"--force"})
+	if !errors.Is(err, ErrUsage) || !strings.Contains(err.Error(), "--env: required") || !strings.Contains(err.Error(), "--force: unknown flag") {
+		t.Errorf("Expected the schema to be enforced, got: %v", err)
+	}
+	if err := executor.validateArgs("deploy", []string{"--env", "staging"}); err != nil {
+		t.Errorf("Expected the arguments to pass, got: %v", err)
+	}
+}
+
+func TestValidateArgsSchemaNullProperty(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-args-schema-null")
+	defer cleanup()
+	schema := `{"type": "object", "properties": {"x": null}, "additionalProperties": false}`
+	if err := os.WriteFile(filepath.Join(tempDir, "deploy.json"), []byte(schema), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	// A property declared as null is known and accepts any value
+	executor := NewToolExecutor(&RunContext{
+		Root:       tempDir,
+		ToolConfig: config.ToolConfig{ArgsSchema: "deploy.json"},
+	})
+	if err := executor.validateArgs("deploy", []string{"--x", "1"}); err != nil {
+		t.Errorf("Expected the arguments to pass, got: %v", err)
+	}
+	if err := executor.validateArgs("deploy", []string{"--y"}); !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected an unknown flag to fail, got: %v", err)
+	}
+}
+
+func TestFindAndExecuteToolArgsSchema(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-args-schema-run")
+	defer cleanup()
+	if err := os.WriteFile(filepath.Join(tempDir, "deploy.json"), []byte(deployArgsSchema), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	logFile := filepath.Join(tempDir, "log.txt")
+	if err := os.WriteFile(filepath.Join(tempDir, "deploy"), []byte(fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\n", logFile)), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	run := func(args ...string) error {
+		executor := NewToolExecutor(&RunContext{
+			Root: tempDir,
+			Config: &config.Config{
+				ToolPaths: []string{tempDir},
+				Tools: map[string]config.ToolConfig{
+					"deploy": {ArgsSchema: "deploy.json"},
+				},
+			},
+		})
+		return executor.FindAndExecuteTool(context.Background(), "deploy", args)
+	}
+
+	if err := run("--env", "production"); err != nil {
+		t.Fatalf("Expected valid arguments to run the tool, got: %v", err)
+	}
+	if err := run("--env", "dev"); !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for invalid arguments, got: %v", err)
+	}
+
+	output, _ := os.ReadFile(logFile)
+	if string(output) != "--env production\n" {
+		t.Errorf("Expected only the valid run to execute the tool, log = %q", string(output))
+	}
+}
@@ -0,0 +1,110 @@
+package uber
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+// benchmarkStats summarizes the execution times of the runs of a benchmark
+type benchmarkStats struct {
+	Min  time.Duration
+	Max  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P95  time.Duration
+}
+
+// Benchmark runs the tool runs times with no input and its output discarded,
+// then writes statistics of the execution phase of the runs to w. The env
+// setup script and healthcheck run once, and the reporting command doesn't
+// run. Interactive tools are refused since they would wait for input.
+func (te *ToolExecutor) Benchmark(ctx context.Context, w io.Writer, toolName string, args []string, runs int) error {
+	if _, ok := te.ctx.Config.Sequences[toolName]; ok {
+		return withKind(ErrUsage, fmt.Errorf("--benchmark can only be used with a tool, and '%s' is a sequence", toolName))
+	}
+
+	args, err := te.prepareTool(toolName, args)
+	if err != nil {
+		return err
+	}
+	if te.ctx.ToolConfig.Interactive {
+		return withKind(ErrUsage, fmt.Errorf("tool '%s' is interactive and needs stdin, so it can't be benchmarked", toolName))
+	}
+
+	cleanup, err := te.createTmpDir()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	env, err := te.setupEnvironment(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := te.checkRequiredEnv(toolName, env); err != nil {
+		return err
+	}
+	if err := te.checkHealth(ctx, toolName, env); err != nil {
+		return err
+	}
+
+	durations := make([]time.Duration, 0, runs)
+	for i := 1; i <= runs; i++ {
+		execStart := time.Now()
+		err := te.executeTool(ctx, te.ctx.ExecutablePath, args, env)
+		elapsed := time.Since(execStart)
+		te.ctx.TimeExecToolMs = elapsed.Milliseconds()
+		te.ctx.ToolExitCode = ExitCode(err)
+		if err != nil {
+			return fmt.Errorf("run %d of %d of tool '%s' failed, run it without --benchmark to see its output: %w", i, runs, toolName, err)
+		}
+		if te.ctx.Verbose {
+			ColorPrint(ColorCyan, fmt.Sprintf("Run %d of %d: %s\n", i, runs, elapsed.Round(time.Microsecond)))
+		}
+		durations = append(durations, elapsed)
+	}
+
+	stats := summarizeDurations(durations)
+	fmt.Fprintf(w, "Benchmark of '%s' (%d runs, env setup %dms):\n", toolName, runs, te.ctx.TimeEnvSetupMs)
+	for _, row := range []struct {
+		label    string
+		duration time.Duration
+	}{
+		{"min", stats.Min},
+		{"max", stats.Max},
+		{"mean", stats.Mean},
+		{"p50", stats.P50},
+		{"p95", stats.P95},
+	} {
+		fmt.Fprintf(w, "  %-4s  %s\n", row.label, row.duration.Round(time.Microsecond))
+	}
+	return nil
+}
+
+// summarizeDurations computes the statistics of a non-empty list of
+// durations. Percentiles use the nearest-rank method.
+func summarizeDurations(durations []time.Duration) benchmarkStats {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	percentile := func(p float64) time.Duration {
+		rank := int(math.Ceil(p * float64(len(sorted))))
+		return sorted[max(rank, 1)-1]
+	}
+	return benchmarkStats{
+		Min:  sorted[0],
+		Max:  sorted[len(sorted)-1],
+		Mean: total / time.Duration(len(sorted)),
+		P50:  percentile(0.50),
+		P95:  percentile(0.95),
+	}
+}
@@ -0,0 +1,115 @@
+package uber
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestBenchmark(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-benchmark")
+	defer cleanup()
+
+	logFile := filepath.Join(tempDir, "log.txt")
+	scripts := map[string]string{
+		"setup.sh": fmt.Sprintf("#!/bin/sh\necho setup >> %s\n", logFile),
+		"build":    fmt.Sprintf("#!/bin/sh\necho \"build $1\" >> %s\necho output\n", logFile),
+		"flaky":    fmt.Sprintf("#!/bin/sh\necho flaky >> %s\n[ $(wc -l < %s) -lt 3 ]\n", logFile, logFile),
+		"prompt":   "#!/bin/sh\nread answer\n",
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	newExecutor := func() *ToolExecutor {
+		return NewToolExecutor(&RunContext{
+			Root:      tempDir,
+			Benchmark: 3,
+			Config: &config.Config{
+				ToolPaths: []string{tempDir},
+				EnvSetup:  filepath.Join(tempDir, "setup.sh"),
+				Tools:     map[string]config.ToolConfig{"prompt": {Interactive: true}},
+				Sequences: map[string][]string{"all": {"build"}},
+			},
+		})
+	}
+
+	t.Run("runs the tool repeatedly", func(t *testing.T) {
+		os.Remove(logFile)
+		var output bytes.Buffer
+		if err := newExecutor().Benchmark(context.Background(), &output, "build", []string{"x"}, 3); err != nil {
+			t.Fatalf("Benchmark failed: %v", err)
+		}
+
+		log, _ := os.ReadFile(logFile)
+		if string(log) != "setup\nbuild x\nbuild x\nbuild x\n" {
+			t.Errorf("Expected env setup once and three runs, log = %q", string(log))
+		}
+		for _, want := range []string{"Benchmark of 'build' (3 runs", "min", "max", "mean", "p50", "p95"} {
+			if !strings.Contains(output.String(), want) {
+				t.Errorf("Expected %q in the report, got:\n%s", want, output.String())
+			}
+		}
+		// The tool's own output is discarded
+		if strings.Contains(output.String(), "output") {
+			t.Errorf("Expected the tool's output to be discarded, got:\n%s", output.String())
+		}
+	})
+
+	t.Run("stops at a failed run", func(t *testing.T) {
+		os.Remove(logFile)
+		err := newExecutor().Benchmark(context.Background(), &bytes.Buffer{}, "flaky", nil, 3)
+		if err == nil || !strings.Contains(err.Error(), "run 2 of 3 of tool 'flaky' failed") {
+			t.Errorf("Expected the second run to fail, got: %v", err)
+		}
+	})
+
+	t.Run("refuses interactive tools", func(t *testing.T) {
+		err := newExecutor().Benchmark(context.Background(), &bytes.Buffer{}, "prompt", nil, 3)
+		if !errors.Is(err, ErrUsage) || !strings.Contains(err.Error(), "interactive") {
+			t.Errorf("Expected a usage error for an interactive tool, got: %v", err)
+		}
+	})
+
+	t.Run("refuses sequences", func(t *testing.T) {
+		err := newExecutor().Benchmark(context.Background(), &bytes.Buffer{}, "all", nil, 3)
+		if !errors.Is(err, ErrUsage) {
+			t.Errorf("Expected a usage error for a sequence, got: %v", err)
+		}
+	})
+}
+
+func TestSummarizeDurations(t *testing.T) {
+	var durations []time.Duration
+	// 20 runs of 20ms down to 1ms, in no particular order
+	for i := 20; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	got := summarizeDurations(durations)
+	want := benchmarkStats{
+		Min:  time.Millisecond,
+		Max:  20 * time.Millisecond,
+		Mean: 10500 * time.Microsecond,
+		P50:  10 * time.Millisecond,
+		P95:  19 * time.Millisecond,
+	}
+	if got != want {
+		t.Errorf("summarizeDurations() = %+v, want %+v", got, want)
+	}
+
+	single := summarizeDurations([]time.Duration{5 * time.Millisecond})
+	if single.Min != single.P95 || single.P50 != 5*time.Millisecond {
+		t.Errorf("Expected every statistic of a single run to be that run, got %+v", single)
+	}
+}
@@ -0,0 +1,28 @@
+package uber
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// binaryPath returns the absolute path of the uber binary that was started
+// as argv0. A bare name is looked up in PATH and symlinks are resolved, so
+// that uber reached through a symlinked name still reports the real binary.
+func binaryPath(argv0 string) (string, error) {
+	path := argv0
+	if !strings.ContainsRune(argv0, filepath.Separator) {
+		if found, err := exec.LookPath(argv0); err == nil {
+			path = found
+		}
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path, nil
+}
@@ -0,0 +1,69 @@
+package uber
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBinaryPath(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-bin-path")
+	defer cleanup()
+	tempDir, err := filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve temp directory: %v", err)
+	}
+
+	realBinary := filepath.Join(tempDir, "real", "uber")
+	if err := os.MkdirAll(filepath.Dir(realBinary), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(realBinary, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create binary: %v", err)
+	}
+	wrapper := filepath.Join(tempDir, "uber-wrapper")
+	if err := os.Symlink(realBinary, wrapper); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	// A symlinked name resolves to the real binary
+	got, err := binaryPath(wrapper)
+	if err != nil {
+		t.Fatalf("binaryPath failed: %v", err)
+	}
+	if got != realBinary {
+		t.Errorf("binaryPath(%s) = %s, want %s", wrapper, got, realBinary)
+	}
+
+	// A bare name is looked up in PATH first
+	t.Setenv("PATH", tempDir)
+	got, err = binaryPath("uber-wrapper")
+	if err != nil {
+		t.Fatalf("binaryPath failed: %v", err)
+	}
+	if got != realBinary {
+		t.Errorf("binaryPath(uber-wrapper) = %s, want %s", got, realBinary)
+	}
+}
+
+func TestParseArgsUberBinPath(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-uber-bin-path")
+	defer cleanup()
+	tempDir, err := filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve temp directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(`uber_bin_path = "tools/uber"`), 0644); err != nil {
+		t.Fatalf("Failed to create .uber file: %v", err)
+	}
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if want := filepath.Join(tempDir, "tools", "uber"); ctx.UberBinPath != want {
+		t.Errorf("Expected UberBinPath %s from uber_bin_path, got %s", want, ctx.UberBinPath)
+	}
+}
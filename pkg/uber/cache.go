@@ -0,0 +1,55 @@
+package uber
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// cacheDirName is the directory, relative to the project root, where uber
+// keeps data that can be safely deleted at any time.
+const cacheDirName = ".uber-cache"
+
+// cachePath returns the path of the named file in the project's cache directory.
+func (te *ToolExecutor) cachePath(name string) string {
+	return filepath.Join(te.ctx.Root, cacheDirName, name)
+}
+
+// readCacheFile decodes the JSON cache file at path into v.
+func readCacheFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeCacheFile encodes v as JSON into the cache file at path. The file is
+// written to a temporary file first and renamed into place so concurrent
+// readers never see a partial file.
+func writeCacheFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,215 @@
+//go:build linux
+
+package uber
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
+// memoryCgroup is a cgroup v2 group that caps the memory used by the tool
+// and all of its children.
+type memoryCgroup struct {
+	path string
+	dir  *os.File
+	// leaf is set when uber moved itself out of its cgroup to enable the
+	// memory controller there, and is moved back on remove
+	leaf string
+}
+
+// newMemoryCgroup creates a cgroup limited to limit bytes for the tool. The
+// memory controller must be delegated to uber's cgroup, or to the one
+// enclosing it, for this to work without root.
+func newMemoryCgroup(limit int64) (*memoryCgroup, error) {
+	current, err := currentCgroup()
+	if err != nil {
+		return nil, err
+	}
+
+	cg := &memoryCgroup{}
+	parent, err := cg.memoryParent(current)
+	if err != nil {
+		return nil, err
+	}
+
+	cg.path = filepath.Join(parent, fmt.Sprintf("uber-%d", os.Getpid()))
+	if err := os.Mkdir(cg.path, 0755); err != nil {
+		cg.restore()
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(cg.path, "memory.max"), []byte(strconv.FormatInt(limit, 10)), 0644); err != nil {
+		cg.remove()
+		return nil, fmt.Errorf("failed to set the memory limit of cgroup %s: %w", cg.path, err)
+	}
+	// Don't let the tool swap its way around the limit. Swap accounting may
+	// be disabled, in which case there is nothing to limit.
+	os.WriteFile(filepath.Join(cg.path, "memory.swap.max"), []byte("0"), 0644)
+
+	cg.dir, err = os.Open(cg.path)
+	if err != nil {
+		cg.remove()
+		return nil, fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	return cg, nil
+}
+
+// memoryParent returns a cgroup with the memory controller enabled for its
+// children, under which the tool's cgroup can be created. cgroup v2 only
+// enables controllers for the children of a group without processes, and
+// current holds at least uber, so:
+//
+//  1. If uber is the only process in current, it moves to a leaf child so
+//     that the controller can be enabled in current.
+//  2. Otherwise the tool's cgroup becomes a sibling of current, which works
+//     when the enclosing group is delegated, e.g. a systemd app.slice.
+func (cg *memoryCgroup) memoryParent(current string) (string, error) {
+	firstErr := enableMemoryController(current)
+	if firstErr == nil {
+		return current, nil
+	}
+
+	if onlyProcess(current) {
+		leaf := filepath.Join(current, fmt.Sprintf("uber-%d-main", os.Getpid()))
+		if err := os.Mkdir(leaf, 0755); err == nil {
+			if err := moveSelf(leaf); err == nil {
+				cg.leaf = leaf
+				if err := enableMemoryController(current); err == nil {
+					return current, nil
+				}
+				cg.restore()
+			} else {
+				os.Remove(leaf)
+			}
+		}
+	}
+
+	if current != cgroupRoot {
+		if err := enableMemoryController(filepath.Dir(current)); err == nil {
+			return filepath.Dir(current), nil
+		}
+	}
+	return "", fmt.Errorf("the memory controller is not enabled for cgroup %s and enabling it failed: %w", current, firstErr)
+}
+
+// enableMemoryController enables the memory controller for the children of
+// the cgroup at path, if it isn't already.
+func enableMemoryController(path string) error {
+	controllers, err := os.ReadFile(filepath.Join(path, "cgroup.subtree_control"))
+	if err != nil {
+		return fmt.Errorf("failed to read the controllers of cgroup %s: %w", path, err)
+	}
+	if slices.Contains(strings.Fields(string(controllers)), "memory") {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(path, "cgroup.subtree_control"), []byte("+memory"), 0644)
+}
+
+// onlyProcess reports whether uber is the only process in the cgroup at
+// path.
+func onlyProcess(path string) bool {
+	data, err := os.ReadFile(filepath.Join(path, "cgroup.procs"))
+	if err != nil {
+		return false
+	}
+	return slices.Equal(strings.Fields(string(data)), []string{strconv.Itoa(os.Getpid())})
+}
+
+// moveSelf moves uber, with all of its threads, to the cgroup at path.
+func moveSelf(path string) error {
+	return os.WriteFile(filepath.Join(path, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644)
+}
+
+// restore moves uber back to the cgroup it ran in if it moved to a leaf,
+// disabling the memory controller it enabled there so that the group can
+// hold processes again.
+func (cg *memoryCgroup) restore() {
+	if cg.leaf == "" {
+		return
+	}
+	original := filepath.Dir(cg.leaf)
+	os.WriteFile(filepath.Join(original, "cgroup.subtree_control"), []byte("-memory"), 0644)
+	if moveSelf(original) == nil {
+		os.Remove(cg.leaf)
+	}
+	cg.leaf = ""
+}
+
+// currentCgroup returns the directory of the cgroup v2 group uber runs in.
+func currentCgroup() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not available at %s", cgroupRoot)
+	}
+
+	file, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("failed to read the current cgroup: %w", err)
+	}
+	defer file.Close()
+
+	// The cgroup v2 entry has the form "0::/path"
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return filepath.Join(cgroupRoot, path), nil
+		}
+	}
+	return "", fmt.Errorf("uber is not running in a cgroup v2 group")
+}
+
+// apply makes cmd start inside the cgroup.
+func (cg *memoryCgroup) apply(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(cg.dir.Fd())
+}
+
+// oomKilled reports whether a process in the cgroup was killed for exceeding
+// the memory limit.
+func (cg *memoryCgroup) oomKilled() bool {
+	data, err := os.ReadFile(filepath.Join(cg.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if count, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			return count != "0"
+		}
+	}
+	return false
+}
+
+// peak returns the highest memory usage recorded for the cgroup, if the
+// kernel reports it.
+func (cg *memoryCgroup) peak() (int64, bool) {
+	data, err := os.ReadFile(filepath.Join(cg.path, "memory.peak"))
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return value, err == nil
+}
+
+// remove deletes the cgroup. It must not contain any processes anymore.
+func (cg *memoryCgroup) remove() error {
+	if cg.dir != nil {
+		cg.dir.Close()
+	}
+	err := os.Remove(cg.path)
+	cg.restore()
+	if err != nil {
+		return fmt.Errorf("failed to remove cgroup %s: %w", cg.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+//go:build linux
+
+package uber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestExecuteToolMaxMemory(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-max-memory")
+	defer cleanup()
+
+	markerFile := filepath.Join(tempDir, "ran")
+	toolContent := "#!/bin/sh\ncat /proc/self/cgroup > " + markerFile + "\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "limited"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			MaxMemory: "256M",
+		},
+	})
+	err := executor.FindAndExecuteTool(context.Background(), "limited", []string{})
+
+	// Without a delegated cgroup v2 memory controller the tool must not run
+	// unlimited
+	probe, probeErr := newMemoryCgroup(256 << 20)
+	if probeErr != nil {
+		if err == nil || !strings.Contains(err.Error(), "failed to apply max_memory") {
+			t.Errorf("Expected a clear error when cgroups are unavailable, got: %v", err)
+		}
+		if _, statErr := os.Stat(markerFile); statErr == nil {
+			t.Errorf("Expected the tool not to run without its memory limit")
+		}
+		return
+	}
+	probe.remove()
+
+	if err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	output, err := os.ReadFile(markerFile)
+	if err != nil {
+		t.Fatalf("Failed to read marker file: %v", err)
+	}
+	if !strings.Contains(string(output), "uber-") {
+		t.Errorf("Expected the tool to run in the uber cgroup, got:\n%s", output)
+	}
+
+	// The cgroup is removed afterwards
+	current, _ := currentCgroup()
+	for _, dir := range []string{current, filepath.Dir(current)} {
+		if matches, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("uber-%d*", os.Getpid()))); len(matches) > 0 {
+			t.Errorf("Expected the cgroup to be removed, found %v", matches)
+		}
+	}
+}
+
+func TestNewMemoryCgroup(t *testing.T) {
+	before, err := currentCgroup()
+	if err != nil {
+		t.Skipf("cgroup v2 is not available: %v", err)
+	}
+	cg, err := newMemoryCgroup(64 << 20)
+	if err != nil {
+		t.Skipf("the memory controller is not delegated to this cgroup: %v", err)
+	}
+
+	if limit, _ := os.ReadFile(filepath.Join(cg.path, "memory.max")); strings.TrimSpace(string(limit)) != "67108864" {
+		t.Errorf("Expected memory.max 67108864, got %q", limit)
+	}
+
+	// A process started with the cgroup runs inside it
+	cmd := exec.Command("cat", "/proc/self/cgroup")
+	cg.apply(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to run a process in the cgroup: %v", err)
+	}
+	if want := "0::/" + strings.TrimPrefix(cg.path, cgroupRoot+"/"); !strings.Contains(string(output), want) {
+		t.Errorf("Expected the process in %s, got:\n%s", want, output)
+	}
+
+	// Removing it puts uber back where it was
+	if err := cg.remove(); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if _, err := os.Stat(cg.path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", cg.path)
+	}
+	if after, _ := currentCgroup(); after != before {
+		t.Errorf("Expected uber back in %s, got %s", before, after)
+	}
+}
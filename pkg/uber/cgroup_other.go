@@ -0,0 +1,25 @@
+//go:build !linux
+
+package uber
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// memoryCgroup is only available on Linux.
+type memoryCgroup struct{}
+
+// newMemoryCgroup reports that memory limits are not supported on this
+// platform.
+func newMemoryCgroup(limit int64) (*memoryCgroup, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (cg *memoryCgroup) apply(cmd *exec.Cmd) {}
+
+func (cg *memoryCgroup) oomKilled() bool { return false }
+
+func (cg *memoryCgroup) peak() (int64, bool) { return 0, false }
+
+func (cg *memoryCgroup) remove() error { return nil }
@@ -2,7 +2,9 @@ package uber
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
 // ANSI color codes
@@ -14,6 +16,74 @@ const (
 	ColorReset  = "\033[0m"
 )
 
+// Values for --color
+const (
+	ColorModeAuto   = "auto"
+	ColorModeAlways = "always"
+	ColorModeNever  = "never"
+)
+
+// colorMode is the color mode set by SetColorMode
+var colorMode = ColorModeAuto
+
+// SetColorMode sets whether colored output is used: "always", "never", or
+// "auto" to decide based on NO_COLOR, UBER_FORCE_COLOR and whether the output
+// is a terminal.
+func SetColorMode(mode string) error {
+	switch mode {
+	case ColorModeAuto, ColorModeAlways, ColorModeNever:
+		colorMode = mode
+		return nil
+	}
+	return fmt.Errorf("invalid color mode '%s': expected \"%s\", \"%s\" or \"%s\"", mode, ColorModeAuto, ColorModeAlways, ColorModeNever)
+}
+
+// messagePrefix is prepended to every line of uber's messages, set by
+// SetMessagePrefix
+var messagePrefix string
+
+// SetMessagePrefix sets the prefix, such as "[uber] ", prepended to every
+// line of the messages printed by uber so that they can be told apart from
+// the output of the tool.
+func SetMessagePrefix(prefix string) {
+	messagePrefix = prefix
+}
+
+// PrefixMessage returns message with the message prefix prepended to every
+// line.
+func PrefixMessage(message string) string {
+	if messagePrefix == "" {
+		return message
+	}
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(message, "\n") {
+		if line != "" {
+			b.WriteString(messagePrefix)
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// useColor reports whether output to a stream that is a terminal if isTTY is
+// true should be colored. An explicit color mode wins over the NO_COLOR and
+// UBER_FORCE_COLOR environment variables, which win over terminal detection.
+func useColor(isTTY bool) bool {
+	switch colorMode {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if os.Getenv("UBER_FORCE_COLOR") != "" {
+		return true
+	}
+	return isTTY
+}
+
 // IsTTY checks if stdout is connected to a terminal
 func IsTTY() bool {
 	fileInfo, _ := os.Stdout.Stat()
@@ -26,20 +96,39 @@ func IsTTYStderr() bool {
 	return (fileInfo.Mode() & os.ModeCharDevice) != 0
 }
 
+// IsTTYStdin checks if stdin is connected to a terminal
+func IsTTYStdin() bool {
+	fileInfo, _ := os.Stdin.Stat()
+	return (fileInfo.Mode() & os.ModeCharDevice) != 0
+}
+
 // ColorPrint prints colored text only if running in a TTY
 func ColorPrint(color, message string) {
-	if IsTTY() {
-		fmt.Print(color + message + ColorReset)
-	} else {
-		fmt.Print(message)
-	}
+	colorPrint(os.Stdout, IsTTY(), color, PrefixMessage(message))
 }
 
 // ColorPrintError prints colored error text only if running in a TTY
 func ColorPrintError(message string) {
-	if IsTTYStderr() {
-		fmt.Fprint(os.Stderr, ColorRed+message+ColorReset)
+	colorPrint(os.Stderr, IsTTYStderr(), ColorRed, PrefixMessage(message))
+}
+
+// ColorPrintWarning prints colored warning text to stderr only if running in a TTY
+func ColorPrintWarning(message string) {
+	colorPrint(os.Stderr, IsTTYStderr(), ColorYellow, PrefixMessage(message))
+}
+
+// ColorPrintStatus prints colored progress text to stderr only if running in
+// a TTY, keeping it out of the output of the tool
+func ColorPrintStatus(color, message string) {
+	colorPrint(os.Stderr, IsTTYStderr(), color, PrefixMessage(message))
+}
+
+// colorPrint writes message to w, colored if w is a terminal when isTTY is
+// set and the color mode allows it.
+func colorPrint(w io.Writer, isTTY bool, color, message string) {
+	if useColor(isTTY) {
+		fmt.Fprint(w, color+message+ColorReset)
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(w, message)
 	}
 }
@@ -0,0 +1,83 @@
+package uber
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// completeCommand is a hidden command used by shell completion scripts. It
+// prints the tools whose name starts with the given prefix, one per line.
+const completeCommand = "__complete"
+
+// runComplete handles the hidden completion command. It never runs env_setup
+// or any tool, and prints nothing if the project can't be loaded.
+func runComplete(binPath string, args []string, w io.Writer) {
+	var prefix string
+	if len(args) > 0 {
+		prefix = args[0]
+	}
+
+	root, err := findProjectRoot(rootMarker(""))
+	if err != nil {
+		return
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return
+	}
+	cfg, err := config.LoadFromFile(root)
+	if err != nil {
+		return
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:        root,
+		UberBinPath: binPath,
+		Config:      cfg,
+	})
+	for _, name := range executor.CompletionCandidates(prefix) {
+		fmt.Fprintln(w, name)
+	}
+}
+
+// CompletionCandidates returns the names of the available tools starting with
+// prefix, in tool_paths order and without duplicates. Tools are named by their
+// base name unless several tools in the same path share it, in which case
+// their full file names are used, matching how they can be invoked.
+func (te *ToolExecutor) CompletionCandidates(prefix string) []string {
+	tools, err := te.GetAllAvailableTools()
+	if err != nil {
+		return nil
+	}
+
+	// Count base names per path to detect ambiguous ones
+	baseCounts := make(map[string]int)
+	for _, tool := range tools {
+		baseCounts[tool.Path+"\x00"+toolBaseName(tool.Name)]++
+	}
+
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, tool := range tools {
+		name := toolBaseName(tool.Name)
+		if baseCounts[tool.Path+"\x00"+name] > 1 || tool.Path == inlineCommandsPath {
+			name = tool.Name
+		}
+		if !strings.HasPrefix(name, prefix) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		candidates = append(candidates, name)
+	}
+
+	return candidates
+}
+
+// toolBaseName returns the tool name without its extension.
+func toolBaseName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,77 @@
+package uber
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestCompletionCandidates(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-completion")
+	defer cleanup()
+
+	files := map[string][]string{
+		"bin":   {"deploy", "build.sh", "test.sh", "test.py"},
+		"tools": {"deploy.sh", "debug"},
+	}
+	for dir, names := range files {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(tempDir, dir, name), []byte("#!/bin/sh\ntrue\n"), 0755); err != nil {
+				t.Fatalf("Failed to create tool: %v", err)
+			}
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{"bin", "tools"},
+		},
+	})
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "de", want: []string{"deploy", "debug"}},
+		{prefix: "b", want: []string{"build"}},
+		{prefix: "test", want: []string{"test.py", "test.sh"}},
+		{prefix: "zzz", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got := executor.CompletionCandidates(tt.prefix)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompletionCandidates(%s) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCompleteSilentOutsideProject(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-completion-no-project")
+	defer cleanup()
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	var buf bytes.Buffer
+	runComplete("/dummy/bin/path", []string{"de"}, &buf)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output outside a project, got '%s'", buf.String())
+	}
+}
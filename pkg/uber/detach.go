@@ -0,0 +1,114 @@
+package uber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// stopCommand is the command that stops a tool started with --detach, unless
+// a tool with the same name exists.
+const stopCommand = "stop"
+
+// toolStatePath returns the configured path of a per-tool state file relative
+// to the project root, or a file named after the tool in the cache directory.
+func (te *ToolExecutor) toolStatePath(configured, toolName, ext string) string {
+	if configured == "" {
+		return te.cachePath(toolBaseName(toolName) + ext)
+	}
+	if filepath.IsAbs(configured) {
+		return configured
+	}
+	return filepath.Join(te.ctx.Root, configured)
+}
+
+// startDetached starts cmd in its own session with its output appended to the
+// log file, records its PID in the pidfile and returns without waiting.
+func (te *ToolExecutor) startDetached(cmd *exec.Cmd) error {
+	for _, path := range []string{te.ctx.PidFile, te.ctx.LogFile} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+	}
+
+	logFile, err := os.OpenFile(te.ctx.LogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	// The tool must not hold on to uber's terminal
+	cmd.Stdin = nil
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	setDetachAttrs(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pid := cmd.Process.Pid
+
+	if err := os.WriteFile(te.ctx.PidFile, []byte(strconv.Itoa(pid)+"\n"), 0644); err != nil {
+		signalProcessGroup(pid)
+		return fmt.Errorf("failed to write pidfile: %w", err)
+	}
+	cmd.Process.Release()
+
+	fmt.Printf("Started in the background (pid %d)\n", pid)
+	fmt.Printf("Pidfile: %s\n", te.ctx.PidFile)
+	fmt.Printf("Log file: %s\n", te.ctx.LogFile)
+	return nil
+}
+
+// StopTool stops a tool started with --detach by signaling the process group
+// recorded in its pidfile.
+func (te *ToolExecutor) StopTool(toolName string) error {
+	pidFile := te.toolStatePath(te.ctx.Config.Tool(toolName).Pidfile, toolName, ".pid")
+
+	pid, err := readPidFile(pidFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return withKind(ErrNotFound, fmt.Errorf("no pidfile for '%s' at %s; is it running?", toolName, pidFile))
+		}
+		return err
+	}
+
+	if err := signalProcessGroup(pid); err != nil {
+		if !processAlive(pid) {
+			os.Remove(pidFile)
+			return fmt.Errorf("tool '%s' is not running (removed stale pidfile %s)", toolName, pidFile)
+		}
+		return fmt.Errorf("failed to stop '%s' (pid %d): %w", toolName, pid, err)
+	}
+
+	os.Remove(pidFile)
+	fmt.Printf("Stopped %s (pid %d)\n", toolName, pid)
+	return nil
+}
+
+// readPidFile returns the process ID stored in a pidfile.
+func readPidFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pidfile %s", path)
+	}
+	return pid, nil
+}
+
+// runningPid returns the process ID in the pidfile if that process is still
+// running.
+func runningPid(path string) (int, bool) {
+	pid, err := readPidFile(path)
+	if err != nil || !processAlive(pid) {
+		return 0, false
+	}
+	return pid, true
+}
@@ -0,0 +1,26 @@
+//go:build !unix
+
+package uber
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setDetachAttrs is a no-op on platforms without sessions.
+func setDetachAttrs(cmd *exec.Cmd) {}
+
+// signalProcessGroup kills the process, process groups are Unix only.
+func signalProcessGroup(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
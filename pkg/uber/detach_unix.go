@@ -0,0 +1,25 @@
+//go:build unix
+
+package uber
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setDetachAttrs starts cmd in a new session, so that it has its own process
+// group and doesn't receive signals sent to uber's terminal.
+func setDetachAttrs(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// signalProcessGroup asks the process group led by pid to terminate.
+func signalProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
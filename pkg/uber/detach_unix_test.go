@@ -0,0 +1,99 @@
+//go:build unix
+
+package uber
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestDetachAndStop(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-detach")
+	defer cleanup()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "serve"), []byte("#!/bin/sh\necho started \"$@\"\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Detach: true,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+
+	start := time.Now()
+	if err := executor.FindAndExecuteTool(context.Background(), "serve", []string{"--port", "8080"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected --detach to return immediately, took %v", elapsed)
+	}
+
+	pidFile := filepath.Join(tempDir, cacheDirName, "serve.pid")
+	pid, err := readPidFile(pidFile)
+	if err != nil {
+		t.Fatalf("Failed to read pidfile: %v", err)
+	}
+	if !processAlive(pid) {
+		t.Fatalf("Expected process %d to be running", pid)
+	}
+
+	// The output goes to the log file
+	logFile := filepath.Join(tempDir, cacheDirName, "serve.log")
+	var output []byte
+	for i := 0; i < 50; i++ {
+		output, _ = os.ReadFile(logFile)
+		if len(output) > 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !strings.Contains(string(output), "started --port 8080") {
+		t.Errorf("Expected tool output in the log file, got '%s'", string(output))
+	}
+
+	// A second start is refused while the first one runs
+	if err := executor.FindAndExecuteTool(context.Background(), "serve", []string{}); !errors.Is(err, ErrUsage) || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("Expected an already running error, got: %v", err)
+	}
+
+	if err := executor.StopTool("serve"); err != nil {
+		t.Fatalf("StopTool failed: %v", err)
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("Expected pidfile to be removed, got: %v", err)
+	}
+
+	if err := executor.StopTool("serve"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when the tool isn't running, got: %v", err)
+	}
+}
+
+func TestDetachConfiguredPaths(t *testing.T) {
+	executor := NewToolExecutor(&RunContext{
+		Root: "/project",
+		Config: &config.Config{
+			Tools: map[string]config.ToolConfig{
+				"serve": {Pidfile: "run/serve.pid", LogFile: "/var/log/serve.log"},
+			},
+		},
+	})
+
+	tool := executor.ctx.Config.Tool("serve")
+	if got := executor.toolStatePath(tool.Pidfile, "serve", ".pid"); got != "/project/run/serve.pid" {
+		t.Errorf("Pidfile = %s, want /project/run/serve.pid", got)
+	}
+	if got := executor.toolStatePath(tool.LogFile, "serve", ".log"); got != "/var/log/serve.log" {
+		t.Errorf("LogFile = %s, want /var/log/serve.log", got)
+	}
+	if got := executor.toolStatePath("", "serve.sh", ".pid"); got != filepath.Join("/project", cacheDirName, "serve.pid") {
+		t.Errorf("Default pidfile = %s", got)
+	}
+}
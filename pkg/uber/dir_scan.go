@@ -0,0 +1,57 @@
+package uber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// readDirFunc reads a directory. Tests replace it to simulate a slow
+// filesystem.
+var readDirFunc = os.ReadDir
+
+// errDirScanTimeout is returned for a directory that took longer than
+// --dir-scan-timeout to read.
+var errDirScanTimeout = errors.New("directory scan timed out")
+
+// readDir reads the directory at path, giving up after --dir-scan-timeout if
+// one was given. The read runs in a goroutine that is abandoned when it
+// times out, so a directory that timed out once isn't read again during this
+// run: its first read is most likely still stuck.
+func (te *ToolExecutor) readDir(path string) ([]os.DirEntry, error) {
+	timeout := te.ctx.DirScanTimeout
+	if timeout <= 0 {
+		return readDirFunc(path)
+	}
+	if te.timedOutDirs[path] {
+		return nil, dirScanTimeoutError(path, timeout)
+	}
+
+	type result struct {
+		entries []os.DirEntry
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		entries, err := readDirFunc(path)
+		done <- result{entries, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.entries, r.err
+	case <-timer.C:
+		if te.timedOutDirs == nil {
+			te.timedOutDirs = make(map[string]bool)
+		}
+		te.timedOutDirs[path] = true
+		return nil, dirScanTimeoutError(path, timeout)
+	}
+}
+
+func dirScanTimeoutError(path string, timeout time.Duration) error {
+	return fmt.Errorf("%w after %s: %s", errDirScanTimeout, timeout, path)
+}
@@ -0,0 +1,100 @@
+package uber
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// slowReadDir makes reads of slowDir hang until the test ends, as on a stuck
+// network mount, and returns a counter of the reads of slowDir.
+func slowReadDir(t *testing.T, slowDir string) *atomic.Int32 {
+	t.Helper()
+	var reads atomic.Int32
+	release := make(chan struct{})
+	t.Cleanup(func() {
+		close(release)
+		readDirFunc = os.ReadDir
+	})
+	readDirFunc = func(path string) ([]os.DirEntry, error) {
+		if path == slowDir {
+			reads.Add(1)
+			<-release
+		}
+		return os.ReadDir(path)
+	}
+	return &reads
+}
+
+func TestReadDirTimeout(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-dir-scan")
+	defer cleanup()
+	slowDir := filepath.Join(tempDir, "slow")
+	if err := os.Mkdir(slowDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	reads := slowReadDir(t, slowDir)
+
+	executor := NewToolExecutor(&RunContext{Root: tempDir, DirScanTimeout: 20 * time.Millisecond})
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		if _, err := executor.readDir(slowDir); !errors.Is(err, errDirScanTimeout) {
+			t.Fatalf("Expected errDirScanTimeout, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the read to give up after the timeout, took %s", elapsed)
+		}
+	}
+	if got := reads.Load(); got != 1 {
+		t.Errorf("Expected a timed out directory to be read once, got %d reads", got)
+	}
+
+	// Other directories are read as usual
+	if _, err := executor.readDir(tempDir); err != nil {
+		t.Errorf("Expected a fast directory to be read, got: %v", err)
+	}
+}
+
+func TestDirScanTimeoutSkipsToolPath(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-dir-scan-skip")
+	defer cleanup()
+
+	slowDir := filepath.Join(tempDir, "slow")
+	fastDir := filepath.Join(tempDir, "fast")
+	for _, dir := range []string{slowDir, fastDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "build"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+	slowReadDir(t, slowDir)
+
+	executor := NewToolExecutor(&RunContext{
+		Root:           tempDir,
+		DirScanTimeout: 20 * time.Millisecond,
+		Config:         &config.Config{ToolPaths: []string{slowDir, fastDir}},
+	})
+
+	tools, err := executor.GetAllAvailableTools()
+	if err != nil {
+		t.Fatalf("GetAllAvailableTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Path != fastDir {
+		t.Errorf("Expected only the tool in the fast path, got %v", tools)
+	}
+
+	if err := executor.FindAndExecuteTool(context.Background(), "build", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	if executor.ctx.FoundToolPath != fastDir {
+		t.Errorf("Expected the tool to be found in the fast path, got %s", executor.ctx.FoundToolPath)
+	}
+}
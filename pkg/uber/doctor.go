@@ -0,0 +1,194 @@
+package uber
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Statuses of a doctor check.
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// doctorCheck is the result of one diagnostic performed by --doctor.
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// doctorReport is the JSON document printed by --doctor --json.
+type doctorReport struct {
+	OK     bool          `json:"ok"`
+	Checks []doctorCheck `json:"checks"`
+}
+
+// Doctor checks the project configuration and prints the results to w, as
+// JSON when asJSON is set. It returns an error if any check failed.
+func (te *ToolExecutor) Doctor(w io.Writer, asJSON bool) error {
+	checks := te.doctorChecks()
+
+	report := doctorReport{OK: true, Checks: checks}
+	failed := 0
+	for _, check := range checks {
+		if check.Status == checkFail {
+			report.OK = false
+			failed++
+		}
+	}
+
+	if asJSON {
+		if err := writeJSON(w, report); err != nil {
+			return fmt.Errorf("failed to write doctor report: %w", err)
+		}
+	} else {
+		for _, check := range checks {
+			color := ColorGreen
+			switch check.Status {
+			case checkWarn:
+				color = ColorYellow
+			case checkFail:
+				color = ColorRed
+			}
+			message := fmt.Sprintf("[%s] %s: %s\n", check.Status, check.Name, check.Message)
+			if useColor(IsTTY()) {
+				message = color + message + ColorReset
+			}
+			fmt.Fprint(w, message)
+		}
+	}
+
+	if failed > 0 {
+		return withKind(ErrConfig, fmt.Errorf("%d doctor check(s) failed", failed))
+	}
+	return nil
+}
+
+// doctorChecks runs all diagnostics against the loaded configuration.
+func (te *ToolExecutor) doctorChecks() []doctorCheck {
+	// The other checks need the configuration
+	if te.ctx.configErr != nil {
+		return []doctorCheck{{Name: "config", Status: checkFail, Message: te.ctx.configErr.Error()}}
+	}
+
+	checks := []doctorCheck{{
+		Name:    "config",
+		Status:  checkPass,
+		Message: fmt.Sprintf("loaded %s", filepath.Join(te.ctx.Root, ".uber")),
+	}}
+
+	// Tool paths that don't exist are skipped when searching, so they are only
+	// a warning unless they are required
+	if len(te.ctx.Config.ToolPaths) == 0 {
+		checks = append(checks, doctorCheck{Name: "tool_paths", Status: checkWarn, Message: "no tool paths configured"})
+	}
+	for _, toolPath := range te.ctx.Config.ToolPaths {
+		fullPath := te.resolveToolFullPath(toolPath, "")
+		check := doctorCheck{Name: "tool_paths", Status: checkPass, Message: fmt.Sprintf("%s exists", fullPath)}
+		if _, err := os.Stat(fullPath); err != nil {
+			check.Status = checkWarn
+			check.Message = fmt.Sprintf("%s does not exist", fullPath)
+			if slices.Contains(te.ctx.Config.RequiredToolPaths, toolPath) {
+				check.Status = checkFail
+				check.Message = fmt.Sprintf("required tool path %s does not exist", fullPath)
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	if te.ctx.Config.EnvSetup != "" {
+		checks = append(checks, te.checkScript("env_setup", te.ctx.Config.EnvSetup))
+	}
+	if te.ctx.Config.EnvSetupCmd != "" {
+		checks = append(checks, te.checkShellCommand("env_setup_cmd", te.ctx.Config.EnvSetupCmd))
+	}
+	for _, reportingCmd := range te.ctx.Config.ReportingCommands() {
+		checks = append(checks, te.checkScript("reporting_cmd", reportingCmd))
+	}
+	for _, reportingCmd := range te.ctx.Config.AfterSuccessCmd {
+		checks = append(checks, te.checkScript("after_success_cmd", reportingCmd))
+	}
+	for _, reportingCmd := range te.ctx.Config.AfterFailureCmd {
+		checks = append(checks, te.checkScript("after_failure_cmd", reportingCmd))
+	}
+
+	if minVersion := te.ctx.Config.MinUberVersion; minVersion != "" {
+		check := doctorCheck{Name: "min_uber_version", Status: checkPass, Message: fmt.Sprintf("uber %s satisfies %s", Version, minVersion)}
+		if Version == "dev" {
+			check.Status = checkWarn
+			check.Message = fmt.Sprintf("cannot check %s against a development build", minVersion)
+		} else if err := checkMinVersion(Version, minVersion); err != nil {
+			check.Status = checkFail
+			check.Message = err.Error()
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// checkScript checks that a script configured under the given option exists
+// and is executable.
+func (te *ToolExecutor) checkScript(name, scriptPath string) doctorCheck {
+	if !filepath.IsAbs(scriptPath) {
+		scriptPath = filepath.Join(te.ctx.Root, scriptPath)
+	}
+
+	if _, err := os.Stat(scriptPath); err != nil {
+		return doctorCheck{Name: name, Status: checkFail, Message: fmt.Sprintf("%s not found", scriptPath)}
+	}
+	if !te.isExecutable(scriptPath) {
+		return doctorCheck{Name: name, Status: checkFail, Message: fmt.Sprintf("%s is not executable", scriptPath)}
+	}
+	return doctorCheck{Name: name, Status: checkPass, Message: fmt.Sprintf("%s is executable", scriptPath)}
+}
+
+// shellBuiltins are the commands a shell runs itself, which aren't looked up
+// in PATH.
+var shellBuiltins = []string{".", ":", "[", "cd", "echo", "eval", "exec", "export", "false", "printf", "source", "test", "true"}
+
+// checkShellCommand checks that the shell and the program run by a command
+// line configured under the given option can be found.
+func (te *ToolExecutor) checkShellCommand(name, command string) doctorCheck {
+	if _, err := exec.LookPath(te.shell()); err != nil {
+		return doctorCheck{Name: name, Status: checkFail, Message: fmt.Sprintf("shell %s not found", te.shell())}
+	}
+
+	words, err := splitShellWords(command)
+	if err != nil {
+		return doctorCheck{Name: name, Status: checkFail, Message: fmt.Sprintf("invalid command: %v", err)}
+	}
+	// Skip variable assignments before the program
+	for len(words) > 0 && isEnvAssignment(words[0]) {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return doctorCheck{Name: name, Status: checkFail, Message: "the command doesn't run a program"}
+	}
+
+	program := words[0]
+	if slices.Contains(shellBuiltins, program) {
+		return doctorCheck{Name: name, Status: checkPass, Message: fmt.Sprintf("%s is a shell builtin", program)}
+	}
+	path, err := exec.LookPath(program)
+	if err != nil {
+		return doctorCheck{Name: name, Status: checkFail, Message: fmt.Sprintf("%s not found", program)}
+	}
+	return doctorCheck{Name: name, Status: checkPass, Message: fmt.Sprintf("%s resolves to %s", program, path)}
+}
+
+// isEnvAssignment reports whether word is a shell variable assignment such as
+// "FOO=bar".
+func isEnvAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	return ok && name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
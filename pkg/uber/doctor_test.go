@@ -0,0 +1,151 @@
+package uber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestDoctorJSON(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-doctor")
+	defer cleanup()
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "bin"), 0755); err != nil {
+		t.Fatalf("Failed to create bin directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "setup.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create setup script: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "report.sh"), []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatalf("Failed to create reporting script: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths:     []string{"bin", "missing"},
+			EnvSetup:      "setup.sh",
+			ReportingCmds: config.StringList{"report.sh"},
+		},
+	})
+
+	var buf bytes.Buffer
+	err := executor.Doctor(&buf, true)
+	if !errors.Is(err, ErrConfig) {
+		t.Errorf("Expected ErrConfig for a failing check, got: %v", err)
+	}
+
+	var report doctorReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse doctor output %q: %v", buf.String(), err)
+	}
+	if report.OK {
+		t.Error("Expected ok to be false")
+	}
+
+	want := []struct{ name, status string }{
+		{"config", checkPass},
+		{"tool_paths", checkPass},
+		{"tool_paths", checkWarn},
+		{"env_setup", checkPass},
+		{"reporting_cmd", checkFail},
+	}
+	if len(report.Checks) != len(want) {
+		t.Fatalf("Expected %d checks, got %+v", len(want), report.Checks)
+	}
+	for i, w := range want {
+		got := report.Checks[i]
+		if got.Name != w.name || got.Status != w.status {
+			t.Errorf("Check %d = %s/%s, want %s/%s", i, got.Name, got.Status, w.name, w.status)
+		}
+		if got.Message == "" {
+			t.Errorf("Check %d has an empty message", i)
+		}
+	}
+}
+
+func TestDoctorHumanOutput(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-doctor-human")
+	defer cleanup()
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"."}},
+	})
+
+	var buf bytes.Buffer
+	if err := executor.Doctor(&buf, false); err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "[pass] config:") || !strings.Contains(buf.String(), "[pass] tool_paths:") {
+		t.Errorf("Unexpected doctor output: %q", buf.String())
+	}
+}
+
+func TestDoctorBrokenConfig(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-doctor-broken")
+	defer cleanup()
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte("tool_paths = ["), 0644); err != nil {
+		t.Fatalf("Failed to write .uber file: %v", err)
+	}
+
+	// --doctor still gets the project so that it can report the problem
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--doctor", "--json"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewToolExecutor(ctx).Doctor(&buf, true); !errors.Is(err, ErrConfig) {
+		t.Errorf("Expected ErrConfig, got: %v", err)
+	}
+	var report doctorReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse doctor output %q: %v", buf.String(), err)
+	}
+	if report.OK || len(report.Checks) != 1 || report.Checks[0].Name != "config" || report.Checks[0].Status != checkFail || !strings.Contains(report.Checks[0].Message, "failed to load configuration") {
+		t.Errorf("Expected a failed config check, got %+v", report)
+	}
+}
+
+func TestDoctorEnvSetupCmd(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-doctor-env-setup-cmd")
+	defer cleanup()
+
+	tests := []struct {
+		command    string
+		wantStatus string
+	}{
+		{command: "sh ./env.sh --quiet", wantStatus: checkPass},
+		{command: "FOO=bar sh ./env.sh", wantStatus: checkPass},
+		{command: ". ./env.sh", wantStatus: checkPass},
+		{command: "uber-no-such-program --env", wantStatus: checkFail},
+		{command: "echo 'unterminated", wantStatus: checkFail},
+	}
+	for _, tt := range tests {
+		executor := NewToolExecutor(&RunContext{
+			Root:   tempDir,
+			Config: &config.Config{EnvSetupCmd: tt.command},
+		})
+		var found bool
+		for _, check := range executor.doctorChecks() {
+			if check.Name != "env_setup_cmd" {
+				continue
+			}
+			found = true
+			if check.Status != tt.wantStatus {
+				t.Errorf("Expected %s for %q, got %s: %s", tt.wantStatus, tt.command, check.Status, check.Message)
+			}
+		}
+		if !found {
+			t.Errorf("Expected an env_setup_cmd check for %q", tt.command)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package uber
+
+import (
+	"fmt"
+	"io"
+)
+
+// writeParsedArgs prints how ParseArgs split the command line, for
+// --dump-args. Values are quoted so that whitespace and empty arguments are
+// visible.
+func (ctx *RunContext) writeParsedArgs(w io.Writer) {
+	fmt.Fprintf(w, "command: %q\n", ctx.Command)
+	fmt.Fprintf(w, "remaining_args: %q\n", ctx.RemainingArgs)
+	fmt.Fprintf(w, "global_command_args: %q\n", ctx.GlobalCommandArgs)
+}
@@ -0,0 +1,28 @@
+package uber
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestParseArgsDumpArgs(t *testing.T) {
+	// No project is needed, so --root may even point at a missing directory
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--dump-args", "-v", "--root", "/nonexistent", "start", "a b", ""}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if !ctx.DumpArgs {
+		t.Fatalf("Expected DumpArgs to be set")
+	}
+
+	var buf bytes.Buffer
+	ctx.writeParsedArgs(&buf)
+	want := `command: "start"
+remaining_args: ["a b" ""]
+global_command_args: "--dump-args -v --root /nonexistent"
+`
+	if buf.String() != want {
+		t.Errorf("Output = %q, want %q", buf.String(), want)
+	}
+}
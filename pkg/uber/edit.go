@@ -0,0 +1,59 @@
+package uber
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// editCommand is the command that opens the project's .uber file in an
+// editor, unless a tool with the same name exists.
+const editCommand = "edit"
+
+// defaultEditor returns the editor used when EDITOR isn't set.
+func defaultEditor() string {
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// EditConfig opens the project's .uber file in $EDITOR and waits for the
+// editor to exit. The file is loaded again afterwards and a warning is
+// printed if it no longer loads.
+func (te *ToolExecutor) EditConfig() error {
+	path := filepath.Join(te.ctx.Root, ".uber")
+
+	// EDITOR may hold arguments, e.g. "code --wait"
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = defaultEditor()
+	}
+	argv, err := splitShellWords(editor)
+	if err != nil {
+		return withKind(ErrConfig, fmt.Errorf("invalid EDITOR '%s': %w", editor, err))
+	}
+	if len(argv) == 0 {
+		argv = []string{defaultEditor()}
+	}
+
+	cmd := exec.Command(argv[0], append(argv[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if te.ctx.Verbose {
+		ColorPrint(ColorGreen, fmt.Sprintf("Opening %s with %s\n", path, editor))
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+
+	if _, err := config.LoadFromFile(te.ctx.Root); err != nil {
+		ColorPrintWarning(fmt.Sprintf("Warning: %s no longer loads: %v\n", path, err))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package uber
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestEditConfig(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-edit")
+	defer cleanup()
+
+	// The editor records the file it was given and runs the edit in $EDIT
+	logFile := filepath.Join(tempDir, "editor.log")
+	editor := filepath.Join(tempDir, "editor")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\neval \"$EDIT\"\n", logFile)
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create editor: %v", err)
+	}
+	uberFile := filepath.Join(tempDir, ".uber")
+
+	tests := []struct {
+		name        string
+		editor      string
+		edit        string
+		wantArgs    string
+		wantWarning bool
+	}{
+		{
+			name:     "valid edit",
+			editor:   editor,
+			edit:     `printf '\nstrict = true\n' >> "$1"`,
+			wantArgs: uberFile,
+		},
+		{
+			name:        "edit that breaks the file",
+			editor:      editor,
+			edit:        `printf '\ntool_paths = [\n' >> "$1"`,
+			wantArgs:    uberFile,
+			wantWarning: true,
+		},
+		{
+			name:     "editor with arguments",
+			editor:   editor + " --wait",
+			wantArgs: "--wait " + uberFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", tt.editor)
+			t.Setenv("EDIT", tt.edit)
+			original, _ := os.ReadFile(uberFile)
+			defer os.WriteFile(uberFile, original, 0644)
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			executor := NewToolExecutor(&RunContext{Root: tempDir, Config: &config.Config{}})
+			err := executor.EditConfig()
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			stderr.ReadFrom(r)
+
+			if err != nil {
+				t.Fatalf("EditConfig failed: %v", err)
+			}
+			if args, _ := os.ReadFile(logFile); strings.TrimSpace(string(args)) != tt.wantArgs {
+				t.Errorf("Expected the editor to get %q, got %q", tt.wantArgs, strings.TrimSpace(string(args)))
+			}
+			if gotWarning := strings.Contains(stderr.String(), "no longer loads"); gotWarning != tt.wantWarning {
+				t.Errorf("Expected warning %v, got stderr %q", tt.wantWarning, stderr.String())
+			}
+		})
+	}
+}
+
+func TestParseArgsEditBrokenConfig(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-edit-broken")
+	defer cleanup()
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte("tool_paths = ["), 0644); err != nil {
+		t.Fatalf("Failed to write .uber file: %v", err)
+	}
+
+	// "uber edit" still gets the project so that the file can be fixed
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "edit"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if want, _ := filepath.EvalSymlinks(tempDir); ctx.Root != want {
+		t.Errorf("Expected root %s, got %s", want, ctx.Root)
+	}
+
+	// Other commands fail as usual
+	if _, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "build"}, io.Discard); err == nil {
+		t.Errorf("Expected an error for a broken .uber file")
+	}
+	if _, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "edit", "extra"}, io.Discard); err == nil {
+		t.Errorf("Expected an error for a broken .uber file when edit has arguments")
+	}
+}
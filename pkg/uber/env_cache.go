@@ -0,0 +1,119 @@
+package uber
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// envCacheFile is the name of the env setup cache inside the cache directory.
+const envCacheFile = "env.json"
+
+// envCacheEntry is the on-disk form of the env setup cache.
+type envCacheEntry struct {
+	// Inputs maps each input file to the fingerprint it had when the
+	// script last ran.
+	Inputs map[string]string `json:"inputs"`
+	// Vars holds the variables printed by the script.
+	Vars map[string]string `json:"vars"`
+}
+
+// envCache caches the output of the env setup script, keyed on the contents
+// of the files listed in env_cache_inputs and the script itself.
+type envCache struct {
+	te           *ToolExecutor
+	path         string
+	fingerprints map[string]string
+}
+
+// newEnvCache fingerprints the current state of the env setup inputs. source
+// identifies the env setup itself, which has the given fingerprint.
+func (te *ToolExecutor) newEnvCache(source, fingerprint string) *envCache {
+	fingerprints := map[string]string{
+		source: fingerprint,
+	}
+	for _, input := range te.ctx.Config.EnvCacheInputs {
+		inputPath := input
+		if !filepath.IsAbs(inputPath) {
+			inputPath = filepath.Join(te.ctx.Root, inputPath)
+		}
+		fingerprints[inputPath] = fingerprintFile(inputPath)
+	}
+
+	return &envCache{
+		te:           te,
+		path:         te.cachePath(envCacheFile),
+		fingerprints: fingerprints,
+	}
+}
+
+// lookup returns the cached variables if every input is unchanged since they
+// were stored, or nil if the script needs to run again.
+func (c *envCache) lookup() map[string]string {
+	var entry envCacheEntry
+	if err := readCacheFile(c.path, &entry); err != nil {
+		if c.te.ctx.Verbose {
+			ColorPrint(ColorCyan, "No cached env setup output found\n")
+		}
+		return nil
+	}
+
+	if changed := c.changedInputs(entry.Inputs); len(changed) > 0 {
+		if c.te.ctx.Verbose {
+			for _, input := range changed {
+				ColorPrint(ColorCyan, fmt.Sprintf("Env setup cache invalidated: '%s' changed\n", input))
+			}
+		}
+		return nil
+	}
+
+	if c.te.ctx.Verbose {
+		ColorPrint(ColorCyan, "Using cached env setup output\n")
+	}
+	if entry.Vars == nil {
+		return map[string]string{}
+	}
+	return entry.Vars
+}
+
+// store saves the variables printed by the script along with the current
+// input fingerprints.
+func (c *envCache) store(vars map[string]string) error {
+	return writeCacheFile(c.path, envCacheEntry{
+		Inputs: c.fingerprints,
+		Vars:   vars,
+	})
+}
+
+// changedInputs returns the sorted list of inputs whose fingerprint differs
+// from the cached one.
+func (c *envCache) changedInputs(cached map[string]string) []string {
+	var changed []string
+	for input, fingerprint := range c.fingerprints {
+		if cached[input] != fingerprint {
+			changed = append(changed, input)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// fingerprintFile returns a hash of the file's contents, or "missing" if the
+// file can't be read.
+func fingerprintFile(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return "missing"
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "missing"
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
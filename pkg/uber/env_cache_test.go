@@ -0,0 +1,135 @@
+package uber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// setupEnvCacheProject creates a project with an env setup script that
+// records each run in a counter file, and a tool that does nothing.
+func setupEnvCacheProject(t *testing.T) (string, string, func()) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-cache")
+
+	counterFile := filepath.Join(tempDir, "runs.txt")
+	setupScript := fmt.Sprintf(`#!/bin/sh
+echo run >> %s
+echo 'CACHED_VAR=value'
+`, counterFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "setup.sh"), []byte(setupScript), 0755); err != nil {
+		t.Fatalf("Failed to create setup script: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "noop"), []byte("#!/bin/sh\ntrue\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module example\n"), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+
+	return tempDir, counterFile, cleanup
+}
+
+// countRuns returns how many times the env setup script has run.
+func countRuns(t *testing.T, counterFile string) int {
+	data, err := os.ReadFile(counterFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0
+		}
+		t.Fatalf("Failed to read counter file: %v", err)
+	}
+	return strings.Count(string(data), "run")
+}
+
+func TestEnvSetupCacheInputs(t *testing.T) {
+	tempDir, counterFile, cleanup := setupEnvCacheProject(t)
+	defer cleanup()
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths:      []string{tempDir},
+			EnvSetup:       "setup.sh",
+			EnvCacheInputs: []string{"go.mod"},
+		},
+	})
+
+	run := func() []string {
+		env, err := executor.executeEnvSetup(context.Background())
+		if err != nil {
+			t.Fatalf("executeEnvSetup failed: %v", err)
+		}
+		return env
+	}
+
+	env := run()
+	run()
+	if got := countRuns(t, counterFile); got != 1 {
+		t.Errorf("Expected env setup to run once with unchanged inputs, ran %d times", got)
+	}
+
+	found := false
+	for _, v := range env {
+		if v == "CACHED_VAR=value" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected CACHED_VAR=value in environment")
+	}
+
+	// Changing an input invalidates the cache
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module changed\n"), 0644); err != nil {
+		t.Fatalf("Failed to update input file: %v", err)
+	}
+	run()
+	if got := countRuns(t, counterFile); got != 2 {
+		t.Errorf("Expected env setup to run again after input changed, ran %d times", got)
+	}
+
+	// Cached variables are still applied
+	env = run()
+	if got := countRuns(t, counterFile); got != 2 {
+		t.Errorf("Expected cached env setup output to be reused, ran %d times", got)
+	}
+	found = false
+	for _, v := range env {
+		if v == "CACHED_VAR=value" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected CACHED_VAR=value in environment from cache")
+	}
+}
+
+func TestEnvSetupWithoutCacheInputs(t *testing.T) {
+	tempDir, counterFile, cleanup := setupEnvCacheProject(t)
+	defer cleanup()
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			EnvSetup:  "setup.sh",
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := executor.FindAndExecuteTool(context.Background(), "noop", []string{}); err != nil {
+			t.Fatalf("FindAndExecuteTool failed: %v", err)
+		}
+	}
+
+	if got := countRuns(t, counterFile); got != 2 {
+		t.Errorf("Expected env setup to run on every invocation, ran %d times", got)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, cacheDirName)); !os.IsNotExist(err) {
+		t.Errorf("Expected no cache directory without env_cache_inputs")
+	}
+}
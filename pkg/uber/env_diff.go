@@ -0,0 +1,74 @@
+package uber
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// EnvDiff runs the env setup for toolName, as running the tool would, and
+// writes the variables it adds as "+KEY=value" and those it changes as
+// "~KEY: old -> new" to w. The baseline is the environment the tool gets
+// without env setup, i.e. uber's environment with the UBER_ variables, so
+// that only the effect of the setup shows. The tool isn't run.
+func (te *ToolExecutor) EnvDiff(ctx context.Context, w io.Writer, toolName string, args []string) error {
+	if te.ctx.Config.EnvSetup == "" && te.ctx.Config.EnvSetupCmd == "" {
+		return withKind(ErrConfig, fmt.Errorf("--env-diff needs env_setup or env_setup_cmd in the .uber file"))
+	}
+	if _, ok := te.ctx.Config.Sequences[toolName]; ok {
+		return withKind(ErrUsage, fmt.Errorf("--env-diff can only be used with a tool, and '%s' is a sequence", toolName))
+	}
+
+	if _, err := te.prepareTool(toolName, args); err != nil {
+		return err
+	}
+	cleanup, err := te.createTmpDir()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	before := parseEnv(te.prepareEnvironment())
+	env, err := te.setupEnvironment(ctx)
+	if err != nil {
+		return err
+	}
+	after := parseEnv(env)
+
+	keys := make([]string, 0, len(after))
+	for key := range after {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changes := 0
+	for _, key := range keys {
+		old, existed := before[key]
+		switch {
+		case !existed:
+			fmt.Fprintf(w, "+%s=%s\n", key, after[key])
+		case old != after[key]:
+			fmt.Fprintf(w, "~%s: %s -> %s\n", key, old, after[key])
+		default:
+			continue
+		}
+		changes++
+	}
+	if changes == 0 {
+		ColorPrint(ColorCyan, "The env setup didn't add or change any variable\n")
+	}
+	return nil
+}
+
+// parseEnv turns a list of KEY=value entries into a map. Later entries win.
+func parseEnv(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, entry := range env {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			m[key] = value
+		}
+	}
+	return m
+}
@@ -0,0 +1,80 @@
+package uber
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestEnvDiff(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-diff")
+	defer cleanup()
+
+	markerFile := filepath.Join(tempDir, "ran.txt")
+	scripts := map[string]string{
+		"build":    fmt.Sprintf("#!/bin/sh\ntouch %s\n", markerFile),
+		"setup.sh": "#!/bin/sh\necho ADDED=1\necho CHANGED=new\necho UNCHANGED=same\n",
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	t.Setenv("CHANGED", "old")
+	t.Setenv("UNCHANGED", "same")
+
+	executor := NewToolExecutor(&RunContext{
+		Root:        tempDir,
+		Command:     "build",
+		UberBinPath: "/dummy/bin/path",
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			EnvSetup:  filepath.Join(tempDir, "setup.sh"),
+		},
+	})
+	var out bytes.Buffer
+	if err := executor.EnvDiff(context.Background(), &out, "build", nil); err != nil {
+		t.Fatalf("EnvDiff failed: %v", err)
+	}
+	if want := "+ADDED=1\n~CHANGED: old -> new\n"; out.String() != want {
+		t.Errorf("Expected diff %q, got %q", want, out.String())
+	}
+	if _, err := os.Stat(markerFile); err == nil {
+		t.Errorf("Expected the tool not to run")
+	}
+
+	// Without env setup there is nothing to diff
+	executor.ctx.Config.EnvSetup = ""
+	if err := executor.EnvDiff(context.Background(), io.Discard, "build", nil); !errors.Is(err, ErrConfig) {
+		t.Errorf("Expected ErrConfig without env_setup, got: %v", err)
+	}
+}
+
+func TestParseArgsEnvDiff(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-env-diff-args")
+	defer cleanup()
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--env-diff", "build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if !ctx.EnvDiff || ctx.Command != "build" {
+		t.Errorf("Expected EnvDiff for build, got %v for %q", ctx.EnvDiff, ctx.Command)
+	}
+
+	for _, args := range [][]string{
+		{"--root", tempDir, "--env-diff"},
+		{"--root", tempDir, "--env-diff", "--bare", "build"},
+	} {
+		if _, err := ParseArgs("/dummy/bin/path", args, io.Discard); !errors.Is(err, ErrUsage) {
+			t.Errorf("Expected ErrUsage for %v, got: %v", args, err)
+		}
+	}
+}
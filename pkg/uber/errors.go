@@ -0,0 +1,97 @@
+package uber
+
+import (
+	"errors"
+	"io/fs"
+	"os/exec"
+	"syscall"
+)
+
+// Exit codes used by the uber binary. These are stable so that scripts can
+// distinguish the different ways an invocation can fail. When the tool itself
+// runs and exits non-zero, its exit code is passed through unchanged.
+const (
+	ExitOK            = 0
+	ExitFailure       = 1
+	ExitUsage         = 2
+	ExitConfig        = 3
+	ExitToolNotFound  = 4
+	ExitNotExecutable = 126
+	ExitNotFound      = 127
+)
+
+// Sentinel errors used to classify failures. Use errors.Is to test for them.
+var (
+	// ErrUsage indicates the command line was invalid.
+	ErrUsage = errors.New("usage error")
+	// ErrConfig indicates the project root or .uber configuration is invalid.
+	ErrConfig = errors.New("configuration error")
+	// ErrToolNotFound indicates the requested tool is not in any tool path.
+	ErrToolNotFound = errors.New("tool not found")
+	// ErrNotExecutable indicates a file exists but cannot be executed.
+	ErrNotExecutable = errors.New("not executable")
+	// ErrNotFound indicates a file uber needed to execute does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrNotPermitted indicates the configuration doesn't allow running a tool.
+	ErrNotPermitted = errors.New("not permitted")
+)
+
+// kindError tags an error with one of the sentinel errors above without
+// changing its message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// withKind tags err with the given sentinel kind so that errors.Is(err, kind)
+// reports true.
+func withKind(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// ExitCode maps an error returned by Run to the process exit code uber should
+// use. A nil error maps to ExitOK.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	// Pass through the exit code of a child process
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if code := exitErr.ExitCode(); code >= 0 {
+			return code
+		}
+		// The child was terminated by a signal, follow the shell convention
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return ExitFailure
+	}
+
+	switch {
+	case errors.Is(err, ErrUsage):
+		return ExitUsage
+	case errors.Is(err, ErrConfig):
+		return ExitConfig
+	case errors.Is(err, ErrToolNotFound):
+		return ExitToolNotFound
+	case errors.Is(err, ErrNotExecutable), errors.Is(err, ErrNotPermitted), errors.Is(err, fs.ErrPermission), errors.Is(err, syscall.ENOEXEC):
+		return ExitNotExecutable
+	case errors.Is(err, ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return ExitNotFound
+	}
+
+	return ExitFailure
+}
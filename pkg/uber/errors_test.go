@@ -0,0 +1,140 @@
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "nil error",
+			err:  nil,
+			want: ExitOK,
+		},
+		{
+			name: "generic error",
+			err:  errors.New("something went wrong"),
+			want: ExitFailure,
+		},
+		{
+			name: "usage error",
+			err:  withKind(ErrUsage, errors.New("missing command")),
+			want: ExitUsage,
+		},
+		{
+			name: "wrapped config error",
+			err:  fmt.Errorf("error: %w", withKind(ErrConfig, errors.New("bad config"))),
+			want: ExitConfig,
+		},
+		{
+			name: "tool not found",
+			err:  withKind(ErrToolNotFound, errors.New("tool 'x' not found")),
+			want: ExitToolNotFound,
+		},
+		{
+			name: "not executable",
+			err:  withKind(ErrNotExecutable, errors.New("script is not executable")),
+			want: ExitNotExecutable,
+		},
+		{
+			name: "not found",
+			err:  withKind(ErrNotFound, errors.New("script not found")),
+			want: ExitNotFound,
+		},
+		{
+			name: "permission denied from exec",
+			err:  &os.PathError{Op: "fork/exec", Path: "/tmp/tool", Err: os.ErrPermission},
+			want: ExitNotExecutable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeChildPassthrough(t *testing.T) {
+	err := exec.Command("/bin/sh", "-c", "exit 42").Run()
+	if err == nil {
+		t.Fatal("Expected command to fail")
+	}
+
+	if got := ExitCode(fmt.Errorf("error: %w", err)); got != 42 {
+		t.Errorf("ExitCode() = %d, want 42", got)
+	}
+}
+
+func TestWithKindPreservesMessage(t *testing.T) {
+	err := withKind(ErrConfig, errors.New("failed to load configuration"))
+	if err.Error() != "failed to load configuration" {
+		t.Errorf("Expected message to be unchanged, got '%s'", err.Error())
+	}
+	if !errors.Is(err, ErrConfig) {
+		t.Errorf("Expected errors.Is(err, ErrConfig) to be true")
+	}
+}
+
+func TestFindAndExecuteToolErrorKinds(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-error-kinds")
+	defer cleanup()
+
+	// Create a non-executable file with the exact tool name
+	if err := os.WriteFile(filepath.Join(tempDir, "plain"), []byte("not executable"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	executor := &ToolExecutor{
+		ctx: &RunContext{
+			Root:   tempDir,
+			Config: &config.Config{ToolPaths: []string{tempDir}},
+		},
+	}
+
+	err := executor.FindAndExecuteTool(context.Background(), "missing", []string{})
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound, got: %v", err)
+	}
+
+	err = executor.FindAndExecuteTool(context.Background(), "plain", []string{})
+	if !errors.Is(err, ErrNotExecutable) {
+		t.Errorf("Expected ErrNotExecutable, got: %v", err)
+	}
+}
+
+func TestParseArgsErrorKinds(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-parse-error-kinds")
+	defer cleanup()
+
+	_, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir}, io.Discard)
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for missing command, got: %v", err)
+	}
+
+	emptyDir, err := os.MkdirTemp("", "uber-test-no-uber")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(emptyDir)
+
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", emptyDir, "tool"}, io.Discard)
+	if !errors.Is(err, ErrConfig) {
+		t.Errorf("Expected ErrConfig for invalid root, got: %v", err)
+	}
+}
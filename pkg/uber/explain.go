@@ -0,0 +1,243 @@
+package uber
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Decisions recorded for a tool path in an explanation
+const (
+	explainMissing  = "missing"
+	explainNoMatch  = "no match"
+	explainChosen   = "chosen"
+	explainShadowed = "shadowed"
+)
+
+// explanation is how a tool name resolves, as printed by --explain. It is
+// also the document printed by --explain --json.
+type explanation struct {
+	Tool string `json:"tool"`
+	// Inline is the snippet of the [[command]] that would run, which takes
+	// precedence over the tool paths
+	Inline string        `json:"inline,omitempty"`
+	Paths  []explainPath `json:"paths"`
+	// Resolved is the executable that would run, empty if there is none
+	Resolved  string `json:"resolved,omitempty"`
+	Permitted bool   `json:"permitted"`
+	Error     string `json:"error,omitempty"`
+}
+
+// explainPath is the search of a single tool path
+type explainPath struct {
+	ToolPath string `json:"tool_path"`
+	FullPath string `json:"full_path"`
+	// Kind is "directory" or "file" for a tool path that exists
+	Kind     string          `json:"kind,omitempty"`
+	Lookups  []explainLookup `json:"lookups,omitempty"`
+	Decision string          `json:"decision"`
+}
+
+// explainLookup is the search for a tool name in one directory. A namespaced
+// command is also looked up in a subdirectory of the tool path.
+type explainLookup struct {
+	Dir        string             `json:"dir"`
+	Name       string             `json:"name"`
+	Candidates []explainCandidate `json:"candidates"`
+	Chosen     string             `json:"chosen,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// explainCandidate is a file that matches the tool name
+type explainCandidate struct {
+	Name       string `json:"name"`
+	Priority   int    `json:"priority"`
+	Executable bool   `json:"executable"`
+}
+
+// Explain prints how toolName is resolved: every tool path in order, the
+// candidate files found in each and why one was chosen or rejected, as JSON
+// when asJSON is set. Nothing is executed. It returns the same error as
+// running the tool would if the tool can't be resolved.
+func (te *ToolExecutor) Explain(w io.Writer, toolName string, asJSON bool) error {
+	result, err := te.explain(toolName)
+	if asJSON {
+		if jsonErr := writeJSON(w, result); jsonErr != nil {
+			return fmt.Errorf("failed to write explanation: %w", jsonErr)
+		}
+	} else {
+		result.write(w)
+	}
+	return err
+}
+
+// explain resolves toolName the way findTool does while recording every
+// step, and returns the error running the tool would fail with.
+func (te *ToolExecutor) explain(toolName string) (*explanation, error) {
+	result := &explanation{Tool: toolName, Paths: []explainPath{}}
+	command, isInline := te.ctx.Config.Command(toolName)
+	if isInline {
+		result.Inline = command.Run
+		result.Resolved = te.inlineCommandSource(command)
+	}
+	for _, toolPath := range te.ctx.Config.ToolPaths {
+		path := explainPath{ToolPath: toolPath, FullPath: te.resolveToolFullPath(toolPath, "")}
+		path.Decision = te.explainPath(&path, toolName, result.Resolved != "")
+		if path.Decision == explainChosen {
+			lookup := path.Lookups[len(path.Lookups)-1]
+			result.Resolved = te.toolExecutablePath(lookup.Dir, lookup.Chosen)
+		}
+		result.Paths = append(result.Paths, path)
+	}
+
+	var err error
+	switch {
+	case result.Resolved == "":
+		err = te.toolNotFoundError(toolName)
+	case isInline && !te.ctx.Config.ToolAllowed(toolName), !isInline && !te.ctx.Config.ToolAllowed(toolName, filepath.Base(result.Resolved)):
+		err = withKind(ErrNotPermitted, fmt.Errorf("tool '%s' is not permitted to run by the allow_tools/deny_tools configuration", toolName))
+	default:
+		result.Permitted = true
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, err
+}
+
+// explainPath searches a single tool path for toolName and returns the
+// decision for it. shadowed is set when an earlier path already matched.
+func (te *ToolExecutor) explainPath(path *explainPath, toolName string, shadowed bool) string {
+	info, err := os.Stat(path.FullPath)
+	if err != nil {
+		return explainMissing
+	}
+	path.Kind = "file"
+	if info.IsDir() {
+		path.Kind = "directory"
+	}
+
+	lookup := te.explainLookup(path.ToolPath, toolName)
+	path.Lookups = append(path.Lookups, lookup)
+	if lookup.Chosen == "" {
+		// Namespaced commands are also looked up in a subdirectory
+		nestedPath, nestedName, isNamespaced := te.namespacedToolPath(path.ToolPath, toolName)
+		if !isNamespaced {
+			return explainNoMatch
+		}
+		lookup = te.explainLookup(nestedPath, nestedName)
+		path.Lookups = append(path.Lookups, lookup)
+		if lookup.Chosen == "" {
+			return explainNoMatch
+		}
+	}
+
+	if shadowed {
+		return explainShadowed
+	}
+	return explainChosen
+}
+
+// explainLookup records the candidates for toolName in dir and the one that
+// would be chosen, if any.
+func (te *ToolExecutor) explainLookup(dir, toolName string) explainLookup {
+	lookup := explainLookup{Dir: dir, Name: toolName, Candidates: []explainCandidate{}}
+	candidates, err := te.toolCandidates(dir, toolName)
+	if err != nil {
+		lookup.Error = err.Error()
+		return lookup
+	}
+	if len(candidates) == 0 {
+		return lookup
+	}
+
+	for _, candidate := range candidates {
+		lookup.Candidates = append(lookup.Candidates, explainCandidate{
+			Name:       candidate.Name,
+			Priority:   candidate.Priority,
+			Executable: candidate.Executable,
+		})
+	}
+
+	resolvedName, err := chooseToolMatch(dir, toolName, candidates)
+	if err != nil {
+		lookup.Error = err.Error()
+		return lookup
+	}
+	lookup.Chosen = resolvedName
+	return lookup
+}
+
+// write prints the explanation in the human readable format.
+func (e *explanation) write(w io.Writer) {
+	fmt.Fprintf(w, "Resolving '%s':\n", e.Tool)
+	if e.Inline != "" {
+		fmt.Fprintf(w, "\nDefined by a [[command]] in %s, which takes precedence over the tool paths:\n   %s\n", e.Resolved, e.Inline)
+	}
+
+	for i, path := range e.Paths {
+		fmt.Fprintf(w, "\n%d. %s (%s)\n", i+1, path.ToolPath, path.FullPath)
+		switch path.Kind {
+		case "":
+			fmt.Fprintf(w, "   does not exist, skipped\n")
+			continue
+		case "directory":
+			fmt.Fprintf(w, "   directory exists\n")
+		default:
+			fmt.Fprintf(w, "   single executable entry\n")
+		}
+
+		for j, lookup := range path.Lookups {
+			if j > 0 {
+				fmt.Fprintf(w, "   looking for '%s' in namespace directory %s\n", lookup.Name, lookup.Dir)
+			}
+			lookup.write(w)
+		}
+
+		chosen := path.Lookups[len(path.Lookups)-1].Chosen
+		switch path.Decision {
+		case explainShadowed:
+			fmt.Fprintf(w, "   would choose %s, but it is shadowed by the earlier match\n", chosen)
+		case explainChosen:
+			fmt.Fprintf(w, "   chose %s\n", chosen)
+		}
+	}
+
+	fmt.Fprintln(w)
+	if e.Resolved == "" {
+		fmt.Fprintf(w, "'%s' does not resolve to any tool\n", e.Tool)
+		return
+	}
+	fmt.Fprintf(w, "'%s' resolves to %s\n", e.Tool, e.Resolved)
+	if !e.Permitted {
+		fmt.Fprintf(w, "but it is refused by allow_tools/deny_tools\n")
+	}
+}
+
+// write prints the candidates of the lookup and why none was chosen.
+func (l *explainLookup) write(w io.Writer) {
+	if len(l.Candidates) == 0 {
+		if l.Error != "" {
+			fmt.Fprintf(w, "   %s, skipped\n", l.Error)
+		} else {
+			fmt.Fprintf(w, "   no files match '%s'\n", l.Name)
+		}
+		return
+	}
+
+	for _, candidate := range l.Candidates {
+		match := "name with extension"
+		if candidate.Priority == 0 {
+			match = "exact name"
+		}
+		status := "executable"
+		if !candidate.Executable {
+			status = "not executable, rejected"
+		}
+		fmt.Fprintf(w, "   candidate %s (priority %d, %s, %s)\n", candidate.Name, candidate.Priority, match, status)
+	}
+	if l.Error != "" {
+		fmt.Fprintf(w, "   no candidate chosen: %s\n", l.Error)
+	}
+}
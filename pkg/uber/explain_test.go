@@ -0,0 +1,141 @@
+package uber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestExplain(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-explain")
+	defer cleanup()
+
+	for _, dir := range []string{"first", "second"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	files := []struct {
+		path string
+		mode os.FileMode
+	}{
+		{"first/build", 0644},
+		{"first/build.sh", 0755},
+		{"second/build", 0755},
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, file.path), []byte("#!/bin/sh\n"), file.mode); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"missing", "first", "second"}},
+	})
+
+	var buf bytes.Buffer
+	if err := executor.Explain(&buf, "build", false); err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	output := buf.String()
+
+	for _, want := range []string{
+		"1. missing",
+		"does not exist, skipped",
+		"candidate build (priority 0, exact name, not executable, rejected)",
+		"candidate build.sh (priority 1, name with extension, executable)",
+		"chose build.sh",
+		"would choose build, but it is shadowed by the earlier match",
+		"'build' resolves to " + filepath.Join(tempDir, "first", "build.sh"),
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	// The explanation agrees with what would actually run
+	_, executablePath, err := executor.findTool("build")
+	if err != nil {
+		t.Fatalf("findTool failed: %v", err)
+	}
+	if executablePath != filepath.Join(tempDir, "first", "build.sh") {
+		t.Errorf("findTool resolved %s, explanation disagrees", executablePath)
+	}
+
+	buf.Reset()
+	err = executor.Explain(&buf, "deploy", false)
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound, got: %v", err)
+	}
+	if !strings.Contains(buf.String(), "no files match 'deploy'") {
+		t.Errorf("Expected output to report no matches, got:\n%s", buf.String())
+	}
+}
+
+func TestExplainJSON(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-explain-json")
+	defer cleanup()
+
+	for _, dir := range []string{"first", "second"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, dir, "build"), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"missing", "first", "second"}},
+	})
+
+	var buf bytes.Buffer
+	if err := executor.Explain(&buf, "build", true); err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+
+	var result explanation
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if result.Tool != "build" || !result.Permitted {
+		t.Errorf("Expected a permitted explanation for build, got %+v", result)
+	}
+	if want := filepath.Join(tempDir, "first", "build"); result.Resolved != want {
+		t.Errorf("Expected build to resolve to %s, got %s", want, result.Resolved)
+	}
+
+	var decisions []string
+	for _, path := range result.Paths {
+		decisions = append(decisions, path.Decision)
+	}
+	want := []string{explainMissing, explainChosen, explainShadowed}
+	if strings.Join(decisions, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected decisions %v, got %v", want, decisions)
+	}
+	if candidates := result.Paths[1].Lookups[0].Candidates; len(candidates) != 1 || candidates[0].Name != "build" || !candidates[0].Executable {
+		t.Errorf("Expected a single executable candidate in the first path, got %+v", candidates)
+	}
+
+	// A tool that doesn't resolve still produces a document with the error
+	buf.Reset()
+	err := executor.Explain(&buf, "deploy", true)
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound, got: %v", err)
+	}
+	result = explanation{}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if result.Resolved != "" || result.Error == "" {
+		t.Errorf("Expected no resolution and an error, got %+v", result)
+	}
+}
@@ -0,0 +1,44 @@
+package uber
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdinFileArg is the @file argument that reads standard input
+const stdinFileArg = "@-"
+
+// expandFileArgs replaces every argument of the form @path with the contents
+// of the file at path, relative to the working directory, as a single
+// argument. @- reads stdin, which can only be done once. The contents are
+// used as-is, including any trailing newline.
+func expandFileArgs(args []string, stdin io.Reader) ([]string, error) {
+	expanded := make([]string, len(args))
+	readStdin := false
+	for i, arg := range args {
+		path, ok := strings.CutPrefix(arg, "@")
+		if !ok || path == "" {
+			expanded[i] = arg
+			continue
+		}
+
+		var data []byte
+		var err error
+		if arg == stdinFileArg {
+			if readStdin {
+				return nil, withKind(ErrUsage, fmt.Errorf("'%s' can only be used once", stdinFileArg))
+			}
+			readStdin = true
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, withKind(ErrUsage, fmt.Errorf("failed to read argument file for '%s': %w", arg, err))
+		}
+		expanded[i] = string(data)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,94 @@
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestExpandFileArgs(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-file-args")
+	defer cleanup()
+
+	payload := filepath.Join(tempDir, "payload.json")
+	if err := os.WriteFile(payload, []byte("{\"name\": \"uber\"}\n"), 0644); err != nil {
+		t.Fatalf("Failed to create payload: %v", err)
+	}
+
+	got, err := expandFileArgs([]string{"--data", "@" + payload, "@-", "@", "user@host"}, strings.NewReader("from stdin"))
+	if err != nil {
+		t.Fatalf("expandFileArgs failed: %v", err)
+	}
+	want := []string{"--data", "{\"name\": \"uber\"}\n", "from stdin", "@", "user@host"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandFileArgs() = %q, want %q", got, want)
+	}
+
+	for _, args := range [][]string{
+		{"@" + filepath.Join(tempDir, "missing.json")},
+		{"@-", "@-"},
+	} {
+		if _, err := expandFileArgs(args, strings.NewReader("")); !errors.Is(err, ErrUsage) {
+			t.Errorf("Expected ErrUsage for %v, got: %v", args, err)
+		}
+	}
+}
+
+func TestFindAndExecuteToolFileArgs(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-file-args-tool")
+	defer cleanup()
+
+	payload := filepath.Join(tempDir, "payload.txt")
+	if err := os.WriteFile(payload, []byte("hello world"), 0644); err != nil {
+		t.Fatalf("Failed to create payload: %v", err)
+	}
+	outputFile := filepath.Join(tempDir, "output.txt")
+	toolContent := fmt.Sprintf("#!/bin/sh\necho \"$# $1\" > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "gen"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+
+	// Without file_args the argument is passed literally
+	if err := executor.FindAndExecuteTool(context.Background(), "gen", []string{"@" + payload}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	output, _ := os.ReadFile(outputFile)
+	if want := fmt.Sprintf("1 @%s\n", payload); string(output) != want {
+		t.Errorf("Expected output %q, got %q", want, string(output))
+	}
+
+	executor.ctx.Config.Tools = map[string]config.ToolConfig{"gen": {FileArgs: true}}
+	if err := executor.FindAndExecuteTool(context.Background(), "gen", []string{"@" + payload}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	output, _ = os.ReadFile(outputFile)
+	if want := "1 hello world\n"; string(output) != want {
+		t.Errorf("Expected output %q, got %q", want, string(output))
+	}
+	// UBER_ARGS reports the expanded arguments
+	if !reflect.DeepEqual(executor.ctx.RemainingArgs, []string{"hello world"}) {
+		t.Errorf("Expected the expanded arguments to be recorded, got %q", executor.ctx.RemainingArgs)
+	}
+
+	// A missing file fails before the tool runs
+	os.Remove(outputFile)
+	err := executor.FindAndExecuteTool(context.Background(), "gen", []string{"@missing.txt"})
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for a missing argument file, got: %v", err)
+	}
+	if _, err := os.Stat(outputFile); err == nil {
+		t.Errorf("Expected the tool not to run")
+	}
+}
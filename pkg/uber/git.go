@@ -0,0 +1,46 @@
+package uber
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitInfo holds the git metadata exported to tools when git_env is enabled.
+type gitInfo struct {
+	SHA    string
+	Branch string
+}
+
+// gitMetadata returns the git SHA and branch checked out in the project root.
+// They are computed once per executor; failures result in empty values.
+func (te *ToolExecutor) gitMetadata() gitInfo {
+	if te.git == nil {
+		te.git = &gitInfo{
+			SHA:    te.runGit("rev-parse", "HEAD"),
+			Branch: te.runGit("rev-parse", "--abbrev-ref", "HEAD"),
+		}
+	}
+	return *te.git
+}
+
+// runGit runs git with the given arguments in the project root and returns its
+// trimmed output, or an empty string if it fails (e.g. not a git repository).
+func (te *ToolExecutor) runGit(args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = te.ctx.Root
+
+	var output []byte
+	err := te.traceRun(tracePhaseGit, cmd, func() (err error) {
+		output, err = cmd.Output()
+		return err
+	})
+	if err != nil {
+		if te.ctx.Verbose {
+			ColorPrint(ColorYellow, fmt.Sprintf("Warning: 'git %s' failed, exporting an empty value: %v\n", strings.Join(args, " "), err))
+		}
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
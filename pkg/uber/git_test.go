@@ -0,0 +1,80 @@
+package uber
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// envValue returns the value of key in env, or false if it isn't set.
+func envValue(env []string, key string) (string, bool) {
+	// Like exec.Cmd, the last value for a key wins
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], key+"=") {
+			return strings.TrimPrefix(env[i], key+"="), true
+		}
+	}
+	return "", false
+}
+
+func TestGitEnv(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-git-env")
+	defer cleanup()
+
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tempDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{GitEnv: true},
+	})
+	env := executor.prepareEnvironment()
+
+	sha, ok := envValue(env, "UBER_GIT_SHA")
+	if !ok || len(sha) != 40 {
+		t.Errorf("Expected UBER_GIT_SHA to be a full SHA, got '%s'", sha)
+	}
+	if branch, _ := envValue(env, "UBER_GIT_BRANCH"); branch != "main" {
+		t.Errorf("Expected UBER_GIT_BRANCH to be 'main', got '%s'", branch)
+	}
+}
+
+func TestGitEnvOutsideRepository(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-git-env-no-repo")
+	defer cleanup()
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{GitEnv: true},
+	})
+	env := executor.prepareEnvironment()
+
+	if sha, ok := envValue(env, "UBER_GIT_SHA"); !ok || sha != "" {
+		t.Errorf("Expected empty UBER_GIT_SHA outside a git repository, got '%s' (set: %v)", sha, ok)
+	}
+}
+
+func TestGitEnvDisabled(t *testing.T) {
+	executor := NewToolExecutor(&RunContext{
+		Root:   "/test/project",
+		Config: &config.Config{},
+	})
+
+	if _, ok := envValue(executor.prepareEnvironment(), "UBER_GIT_SHA"); ok {
+		t.Error("Expected UBER_GIT_SHA not to be set when git_env is disabled")
+	}
+}
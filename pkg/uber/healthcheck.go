@@ -0,0 +1,109 @@
+package uber
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// healthcheckCacheFile is the name of the healthcheck cache inside the cache
+// directory.
+const healthcheckCacheFile = "healthcheck.json"
+
+// healthcheckEntry is the cached result of a tool's healthcheck
+type healthcheckEntry struct {
+	// Fingerprint is the fingerprint of the tool's executable when it was
+	// checked, so that a changed tool is checked again
+	Fingerprint string    `json:"fingerprint"`
+	Args        []string  `json:"args"`
+	CheckedAt   time.Time `json:"checked_at"`
+	Passed      bool      `json:"passed"`
+}
+
+// checkHealth runs the healthcheck of the tool about to run, if it has one,
+// unless a result for the same executable and arguments is cached and still
+// within healthcheck_ttl. A failed healthcheck, cached or not, is returned
+// as an error so that the tool doesn't run.
+func (te *ToolExecutor) checkHealth(ctx context.Context, toolName string, env []string) error {
+	args := te.ctx.ToolConfig.Healthcheck
+	if len(args) == 0 {
+		return nil
+	}
+	ttl, err := te.ctx.ToolConfig.ParseHealthcheckTTL()
+	if err != nil {
+		return withKind(ErrConfig, err)
+	}
+
+	// The cache holds one entry per executable
+	cachePath := te.cachePath(healthcheckCacheFile)
+	entries := map[string]healthcheckEntry{}
+	readCacheFile(cachePath, &entries)
+
+	executablePath := te.ctx.ExecutablePath
+	fingerprint := fingerprintFile(executablePath)
+	entry, ok := entries[executablePath]
+	if ok && entry.Fingerprint == fingerprint && slices.Equal(entry.Args, args) && time.Since(entry.CheckedAt) < ttl {
+		if te.ctx.Verbose {
+			ColorPrint(ColorCyan, fmt.Sprintf("Using cached healthcheck of tool '%s' from %s\n", toolName, entry.CheckedAt.Format(time.RFC3339)))
+		}
+		if !entry.Passed {
+			return fmt.Errorf("healthcheck of tool '%s' failed at %s; the result is reused for %s, remove %s to check again", toolName, entry.CheckedAt.Format(time.RFC3339), ttl, cachePath)
+		}
+		return nil
+	}
+
+	runErr := te.runHealthcheck(ctx, toolName, args, env)
+	// An interrupted healthcheck says nothing about the tool
+	if ctx.Err() != nil {
+		return runErr
+	}
+
+	entries[executablePath] = healthcheckEntry{
+		Fingerprint: fingerprint,
+		Args:        args,
+		CheckedAt:   time.Now(),
+		Passed:      runErr == nil,
+	}
+	if err := writeCacheFile(cachePath, entries); err != nil && te.ctx.Verbose {
+		ColorPrint(ColorYellow, fmt.Sprintf("Warning: failed to write healthcheck cache: %v\n", err))
+	}
+	return runErr
+}
+
+// runHealthcheck runs the tool with the healthcheck arguments in the tool's
+// environment and working directory. Its output is only shown if it fails.
+func (te *ToolExecutor) runHealthcheck(ctx context.Context, toolName string, args, env []string) error {
+	cmd := commandContext(ctx, te.ctx.ExecutablePath, args...)
+	if env == nil {
+		env = te.prepareEnvironment()
+	}
+	cmd.Env = env
+	if cwd := te.ctx.ToolConfig.Cwd; cwd != "" {
+		cmd.Dir = cwd
+		if !filepath.IsAbs(cmd.Dir) {
+			cmd.Dir = filepath.Join(te.ctx.Root, cmd.Dir)
+		}
+	}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if te.ctx.Verbose {
+		ColorPrint(ColorCyan, fmt.Sprintf("Running healthcheck of tool '%s': %v\n", toolName, args))
+	}
+	start := time.Now()
+	err := te.traceRun(tracePhaseHealthcheck, cmd, cmd.Run)
+	te.printPhaseSummary(tracePhaseHealthcheck, err, time.Since(start))
+	if err != nil {
+		message := fmt.Sprintf("healthcheck of tool '%s' (%s %s) failed: %v", toolName, te.ctx.ExecutablePath, strings.Join(args, " "), err)
+		if text := strings.TrimSpace(output.String()); text != "" {
+			message += "\n" + text
+		}
+		return fmt.Errorf("%s", message)
+	}
+	return nil
+}
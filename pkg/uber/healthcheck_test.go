@@ -0,0 +1,118 @@
+package uber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// writeHealthcheckTool creates a deploy tool that logs every healthcheck and
+// run, and whose healthcheck exits with the given status.
+func writeHealthcheckTool(t *testing.T, dir string, status int) string {
+	t.Helper()
+	logFile := filepath.Join(dir, "log.txt")
+	script := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = "--selftest" ]; then
+  echo check >> %s
+  echo "selftest output"
+  exit %d
+fi
+echo run >> %s
+`, logFile, status, logFile)
+	if err := os.WriteFile(filepath.Join(dir, "deploy"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	return logFile
+}
+
+func TestCheckHealth(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-healthcheck")
+	defer cleanup()
+	logFile := writeHealthcheckTool(t, tempDir, 0)
+
+	run := func(ttl string) error {
+		executor := NewToolExecutor(&RunContext{
+			Root: tempDir,
+			Config: &config.Config{
+				ToolPaths: []string{tempDir},
+				Tools: map[string]config.ToolConfig{
+					"deploy": {Healthcheck: []string{"--selftest"}, HealthcheckTTL: ttl},
+				},
+			},
+		})
+		return executor.FindAndExecuteTool(context.Background(), "deploy", []string{})
+	}
+	readLog := func() string {
+		data, _ := os.ReadFile(logFile)
+		return string(data)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := run(""); err != nil {
+			t.Fatalf("Run %d failed: %v", i+1, err)
+		}
+	}
+	if got := readLog(); got != "check\nrun\nrun\n" {
+		t.Errorf("Expected a single healthcheck for two runs, got %q", got)
+	}
+
+	// A changed tool is checked again
+	os.Remove(logFile)
+	writeHealthcheckTool(t, tempDir, 0)
+	if f, err := os.OpenFile(filepath.Join(tempDir, "deploy"), os.O_APPEND|os.O_WRONLY, 0); err == nil {
+		f.WriteString("# changed\n")
+		f.Close()
+	}
+	if err := run(""); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := readLog(); got != "check\nrun\n" {
+		t.Errorf("Expected a changed tool to be checked again, got %q", got)
+	}
+
+	// An expired result is checked again
+	os.Remove(logFile)
+	if err := run("1ns"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := readLog(); got != "check\nrun\n" {
+		t.Errorf("Expected an expired result to be checked again, got %q", got)
+	}
+}
+
+func TestCheckHealthFailure(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-healthcheck-failure")
+	defer cleanup()
+	logFile := writeHealthcheckTool(t, tempDir, 3)
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			Tools: map[string]config.ToolConfig{
+				"deploy": {Healthcheck: []string{"--selftest"}},
+			},
+		},
+	})
+
+	err := executor.FindAndExecuteTool(context.Background(), "deploy", []string{})
+	if err == nil || !strings.Contains(err.Error(), "healthcheck of tool 'deploy'") || !strings.Contains(err.Error(), "selftest output") {
+		t.Errorf("Expected the healthcheck failure with its output, got: %v", err)
+	}
+
+	// The failure is cached as well
+	err = executor.FindAndExecuteTool(context.Background(), "deploy", []string{})
+	if err == nil || !strings.Contains(err.Error(), "to check again") {
+		t.Errorf("Expected the cached healthcheck failure, got: %v", err)
+	}
+
+	data, _ := os.ReadFile(logFile)
+	if string(data) != "check\n" {
+		t.Errorf("Expected one healthcheck and no run, got %q", string(data))
+	}
+}
@@ -0,0 +1,28 @@
+package uber
+
+import (
+	"path/filepath"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// inlineCommandsPath is the tool path that --list-tools shows inline
+// commands under.
+const inlineCommandsPath = "[[command]]"
+
+// inlineCommandSource returns the file that defines command, which is
+// reported as its tool path. A command that wasn't loaded from a file is
+// attributed to the project's .uber file.
+func (te *ToolExecutor) inlineCommandSource(command config.InlineCommand) string {
+	if command.Source != "" {
+		return command.Source
+	}
+	return filepath.Join(te.ctx.Root, ".uber")
+}
+
+// inlineCommandArgs returns the arguments that make the shell run the
+// snippet of an inline command with args as "$@" and the command name as
+// "$0".
+func inlineCommandArgs(name, run string, args []string) []string {
+	return append([]string{"-c", run, name}, args...)
+}
@@ -0,0 +1,89 @@
+package uber
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestInlineCommands(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-inline-commands")
+	defer cleanup()
+	tempDir, _ = filepath.EvalSymlinks(tempDir)
+
+	// "hello" exists both inline and in the tool path
+	binDir := filepath.Join(tempDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("Failed to create bin directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "hello"), []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	outputFile := filepath.Join(tempDir, "output.txt")
+	cfg := &config.Config{
+		ToolPaths: []string{"bin"},
+		Commands: []config.InlineCommand{
+			{Name: "hello", Run: fmt.Sprintf(`echo "$0 $# $1 $UBER_PROJECT_ROOT" > %s`, outputFile)},
+		},
+	}
+	executor := NewToolExecutor(&RunContext{
+		Root:        tempDir,
+		Command:     "hello",
+		UberBinPath: "/dummy/bin/path",
+		Config:      cfg,
+	})
+
+	// The inline command wins and gets the arguments and uber's environment
+	if err := executor.FindAndExecuteTool(context.Background(), "hello", []string{"a b", "c"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	output, _ := os.ReadFile(outputFile)
+	if want := fmt.Sprintf("hello 2 a b %s", tempDir); strings.TrimSpace(string(output)) != want {
+		t.Errorf("Expected output %q, got %q", want, strings.TrimSpace(string(output)))
+	}
+	if executor.ctx.FoundToolPath != filepath.Join(tempDir, ".uber") {
+		t.Errorf("Expected the tool path to be the .uber file, got %s", executor.ctx.FoundToolPath)
+	}
+
+	// A command from .uber.local is attributed to that file
+	localFile := filepath.Join(tempDir, ".uber.local")
+	cfg.Commands[0].Source = localFile
+	if err := executor.FindAndExecuteTool(context.Background(), "hello", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	if executor.ctx.FoundToolPath != localFile {
+		t.Errorf("Expected the tool path to be %s, got %s", localFile, executor.ctx.FoundToolPath)
+	}
+
+	// It is listed first
+	tools, err := executor.GetAllAvailableTools()
+	if err != nil {
+		t.Fatalf("GetAllAvailableTools failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0] != (AvailableTool{Name: "hello", Path: inlineCommandsPath}) {
+		t.Errorf("Expected the inline command to be listed first, got %+v", tools)
+	}
+
+	// --explain shows the snippet and the shadowed file
+	var explained bytes.Buffer
+	if err := executor.Explain(&explained, "hello", false); err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if !strings.Contains(explained.String(), "Defined by a [[command]] in "+localFile) || !strings.Contains(explained.String(), "shadowed") {
+		t.Errorf("Expected the explanation to show the inline command, got:\n%s", explained.String())
+	}
+
+	// A failing snippet reports the exit code like any tool
+	cfg.Commands[0].Run = "exit 4"
+	err = executor.FindAndExecuteTool(context.Background(), "hello", []string{})
+	if code := ExitCode(err); code != 4 {
+		t.Errorf("Expected exit code 4, got %d (%v)", code, err)
+	}
+}
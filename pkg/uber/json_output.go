@@ -0,0 +1,14 @@
+package uber
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// writeJSON writes v to w as indented JSON, the format shared by every
+// --json output so that they look alike.
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package uber
+
+import "os"
+
+// lockFile is a no-op on platforms without flock. Appends of a single line
+// are still unlikely to interleave.
+func lockFile(file *os.File) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package uber
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on file, blocking until it is
+// available, and returns a function that releases it.
+func lockFile(file *os.File) (func(), error) {
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	}, nil
+}
@@ -0,0 +1,53 @@
+package uber
+
+import (
+	"errors"
+	"fmt"
+)
+
+// memoryLimitCgroup creates the cgroup that enforces max_memory for the tool.
+// It returns nil when no limit applies to this run.
+func (te *ToolExecutor) memoryLimitCgroup() (*memoryCgroup, error) {
+	limit, ok, err := te.ctx.Config.ParseMaxMemory()
+	if err != nil {
+		return nil, withKind(ErrConfig, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	// A detached tool outlives uber, which removes the cgroup when the tool
+	// exits
+	if te.ctx.Detach {
+		ColorPrintWarning("Warning: max_memory is not applied to tools started with --detach\n")
+		return nil, nil
+	}
+
+	cg, err := newMemoryCgroup(limit)
+	if errors.Is(err, errors.ErrUnsupported) {
+		ColorPrintWarning("Warning: max_memory is only supported on Linux, running without a memory limit\n")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply max_memory: %w", err)
+	}
+	if te.ctx.Verbose {
+		ColorPrint(ColorGreen, fmt.Sprintf("Memory limit: %d bytes\n", limit))
+	}
+	return cg, nil
+}
+
+// releaseMemoryLimit removes the cgroup after the tool ran and explains a
+// failure caused by the tool exceeding max_memory.
+func (te *ToolExecutor) releaseMemoryLimit(cg *memoryCgroup, runErr error) error {
+	if runErr != nil && cg.oomKilled() {
+		runErr = fmt.Errorf("tool was killed for exceeding max_memory (%s): %w", te.ctx.Config.MaxMemory, runErr)
+	}
+	if peak, ok := cg.peak(); ok && te.ctx.Verbose {
+		ColorPrint(ColorGreen, fmt.Sprintf("Peak memory: %d bytes\n", peak))
+	}
+	if err := cg.remove(); err != nil {
+		ColorPrintWarning(fmt.Sprintf("Warning: %v\n", err))
+	}
+	return runErr
+}
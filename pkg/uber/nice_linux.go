@@ -0,0 +1,40 @@
+//go:build linux
+
+package uber
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// niceCommand returns no prefix on Linux, where runNiced sets the priority
+// with setpriority instead.
+func niceCommand(nice int) ([]string, bool, error) {
+	return nil, true, nil
+}
+
+// runNiced calls run, which starts the tool, on an OS thread whose nice value
+// is adjusted by nice. Linux keeps nice values per thread and a child starts
+// with the value of the thread that forked it, so the tool and everything it
+// starts run at that priority while uber keeps its own.
+func runNiced(nice int, run func() error) error {
+	result := make(chan error, 1)
+	go func() {
+		// The thread isn't unlocked so that it exits with the goroutine
+		// instead of running other goroutines at the adjusted priority
+		runtime.LockOSThread()
+		tid := syscall.Gettid()
+		// The raw syscall returns 20 - nice so that the result is positive
+		if current, err := syscall.Getpriority(syscall.PRIO_PROCESS, tid); err == nil {
+			target := min(max(20-current+nice, config.MinNice), config.MaxNice)
+			if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, target); err != nil {
+				ColorPrintWarning(fmt.Sprintf("Warning: failed to set the nice value of the tool to %d, running it at uber's priority: %v\n", target, err))
+			}
+		}
+		result <- run()
+	}()
+	return <-result
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package uber
+
+import (
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func TestRunNicedKeepsUberPriority(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	tid := syscall.Gettid()
+	before, err := syscall.Getpriority(syscall.PRIO_PROCESS, tid)
+	if err != nil {
+		t.Fatalf("Getpriority failed: %v", err)
+	}
+
+	var during int
+	err = runNiced(5, func() error {
+		during, err = syscall.Getpriority(syscall.PRIO_PROCESS, syscall.Gettid())
+		return err
+	})
+	if err != nil {
+		t.Fatalf("runNiced failed: %v", err)
+	}
+	if before > 1 && during != max(before-5, 1) {
+		t.Errorf("Expected the run to have nice value %d, got %d", 20-max(before-5, 1), 20-during)
+	}
+
+	// The calling thread isn't affected
+	if after, _ := syscall.Getpriority(syscall.PRIO_PROCESS, tid); after != before {
+		t.Errorf("Expected the calling thread to keep nice value %d, got %d", 20-before, 20-after)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package uber
+
+// niceCommand is a no-op on platforms without nice values.
+func niceCommand(nice int) ([]string, bool, error) {
+	ColorPrintWarning("Warning: --nice and the nice option are not supported on this platform and are ignored\n")
+	return nil, false, nil
+}
+
+// runNiced calls run.
+func runNiced(nice int, run func() error) error {
+	return run()
+}
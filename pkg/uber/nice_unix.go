@@ -0,0 +1,26 @@
+//go:build unix && !linux
+
+package uber
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// niceCommand returns the command that runs a program with its nice value
+// adjusted by nice. These platforms keep a single nice value per process, so
+// uber can't lower the priority of the tool without lowering its own, while
+// nice(1) sets it in the child before it execs the program.
+func niceCommand(nice int) ([]string, bool, error) {
+	path, err := exec.LookPath("nice")
+	if err != nil {
+		return nil, false, fmt.Errorf("nice(1) is needed to change the priority of tools: %w", err)
+	}
+	return []string{path, "-n", strconv.Itoa(nice)}, true, nil
+}
+
+// runNiced calls run. The priority is set by the prefix from niceCommand.
+func runNiced(nice int, run func() error) error {
+	return run()
+}
@@ -0,0 +1,80 @@
+//go:build unix
+
+package uber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestNice(t *testing.T) {
+	if _, err := exec.LookPath("ps"); err != nil {
+		t.Skip("ps is not available")
+	}
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-nice")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "nice.txt")
+	script := fmt.Sprintf("#!/bin/sh\nps -o nice= -p $$ > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "build"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	niceOf := func(flag *int, toolNice int) int {
+		t.Helper()
+		executor := NewToolExecutor(&RunContext{
+			Root:    tempDir,
+			Command: "build",
+			Nice:    flag,
+			Config: &config.Config{
+				ToolPaths: []string{tempDir},
+				Tools:     map[string]config.ToolConfig{"build": {Nice: toolNice}},
+			},
+		})
+		if err := executor.FindAndExecuteTool(context.Background(), "build", []string{}); err != nil {
+			t.Fatalf("FindAndExecuteTool failed: %v", err)
+		}
+		output, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output file: %v", err)
+		}
+		nice, err := strconv.Atoi(strings.TrimSpace(string(output)))
+		if err != nil {
+			t.Fatalf("Unexpected ps output %q: %v", output, err)
+		}
+		return nice
+	}
+
+	base := niceOf(nil, 0)
+	if got := niceOf(nil, 5); got != min(base+5, config.MaxNice) {
+		t.Errorf("Expected the tool's nice option to give %d, got %d", min(base+5, config.MaxNice), got)
+	}
+	flag := 3
+	if got := niceOf(&flag, 5); got != min(base+3, config.MaxNice) {
+		t.Errorf("Expected --nice to override the tool's option with %d, got %d", min(base+3, config.MaxNice), got)
+	}
+	flag = 0
+	if got := niceOf(&flag, 5); got != base {
+		t.Errorf("Expected --nice 0 to keep uber's nice value %d, got %d", base, got)
+	}
+
+	// A tool that can't start is still reported as such
+	if err := os.WriteFile(filepath.Join(tempDir, "broken"), []byte("#!/nonexistent/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}, Tools: map[string]config.ToolConfig{"broken": {Nice: 5}}},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "broken", []string{}); ExitCode(err) != ExitNotFound {
+		t.Errorf("Expected exit code %d for a missing interpreter, got %d (%v)", ExitNotFound, ExitCode(err), err)
+	}
+}
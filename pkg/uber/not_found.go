@@ -0,0 +1,134 @@
+package uber
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxSuggestionDistance is the largest edit distance between a command and a
+// tool name for the tool to be suggested.
+const maxSuggestionDistance = 2
+
+// toolNotFoundError is returned when a tool isn't in any tool path. It keeps
+// the requested name and the suggested alternatives apart from the message so
+// that they can also be printed as JSON.
+type toolNotFoundError struct {
+	Tool        string
+	Suggestions []string
+	err         error
+}
+
+func (e *toolNotFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *toolNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// toolNotFound builds an ErrToolNotFound error for toolName with the given
+// message.
+func toolNotFound(toolName string, suggestions []string, err error) error {
+	return withKind(ErrToolNotFound, &toolNotFoundError{Tool: toolName, Suggestions: suggestions, err: err})
+}
+
+// toolSuggestions returns the tools the user may have meant by toolName:
+// executables named toolName with an extension, which can be run by their
+// full name, then tools whose name without extension is within
+// maxSuggestionDistance edits of toolName.
+func (te *ToolExecutor) toolSuggestions(toolName string) []string {
+	var suggestions []string
+	for _, toolPath := range te.ctx.Config.ToolPaths {
+		files, err := te.readDir(te.resolveToolFullPath(toolPath, ""))
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			fileName := file.Name()
+			if strings.HasPrefix(fileName, toolName+".") {
+				fullPath := filepath.Join(te.resolveToolFullPath(toolPath, ""), fileName)
+				if te.isExecutable(fullPath) && !slices.Contains(suggestions, fileName) {
+					suggestions = append(suggestions, fileName)
+				}
+			}
+		}
+	}
+
+	// Likely typos, e.g. "biuld" for "build"
+	tools, _ := te.GetAllAvailableTools()
+	for _, tool := range tools {
+		name := tool.Name
+		if tool.Path != inlineCommandsPath {
+			name = strings.TrimSuffix(tool.Name, filepath.Ext(tool.Name))
+		}
+		if name == toolName || slices.Contains(suggestions, name) {
+			continue
+		}
+		if distance := editDistance(toolName, name); distance <= maxSuggestionDistance && distance < len(toolName) {
+			suggestions = append(suggestions, name)
+		}
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// toolNotFoundJSON is the document printed for a tool that wasn't found
+// when --json is given.
+type toolNotFoundJSON struct {
+	Error       string   `json:"error"`
+	Tool        string   `json:"tool"`
+	Message     string   `json:"message"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// writeToolNotFoundJSON writes err to w as JSON if it is a tool that wasn't
+// found, and reports whether it did.
+func writeToolNotFoundJSON(w io.Writer, err error) bool {
+	var notFound *toolNotFoundError
+	if !errors.As(err, &notFound) {
+		return false
+	}
+	suggestions := notFound.Suggestions
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+	if jsonErr := writeJSON(w, toolNotFoundJSON{
+		Error:       "tool_not_found",
+		Tool:        notFound.Tool,
+		Message:     notFound.Error(),
+		Suggestions: suggestions,
+	}); jsonErr != nil {
+		ColorPrintWarning(fmt.Sprintf("Warning: failed to write the error as JSON: %v\n", jsonErr))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package uber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"build", "build", 0},
+		{"biuld", "build", 2},
+		{"buld", "build", 1},
+		{"", "lint", 4},
+		{"deploy", "test", 5},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestToolNotFoundJSON(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-not-found-json")
+	defer cleanup()
+
+	for _, name := range []string{"deploy.prod.sh", "build", "lint.sh"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+
+	tests := []struct {
+		name            string
+		command         string
+		wantSuggestions []string
+	}{
+		{name: "name with an extension", command: "deploy.prod", wantSuggestions: []string{"deploy.prod.sh"}},
+		{name: "typo", command: "biuld", wantSuggestions: []string{"build"}},
+		{name: "typo of a tool with an extension", command: "lnt", wantSuggestions: []string{"lint"}},
+		{name: "no candidates", command: "release", wantSuggestions: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := executor.findTool(tt.command)
+			if !errors.Is(err, ErrToolNotFound) {
+				t.Fatalf("Expected ErrToolNotFound, got: %v", err)
+			}
+
+			var out bytes.Buffer
+			if !writeToolNotFoundJSON(&out, err) {
+				t.Fatalf("Expected the error to be written as JSON")
+			}
+			var got toolNotFoundJSON
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("Invalid JSON %q: %v", out.String(), err)
+			}
+			if got.Error != "tool_not_found" || got.Tool != tt.command || got.Message != err.Error() {
+				t.Errorf("Unexpected JSON error: %+v", got)
+			}
+			if !slices.Equal(got.Suggestions, tt.wantSuggestions) {
+				t.Errorf("Expected suggestions %v, got %v", tt.wantSuggestions, got.Suggestions)
+			}
+		})
+	}
+
+	// Other errors aren't written
+	var out bytes.Buffer
+	if writeToolNotFoundJSON(&out, errors.New("boom")) || out.Len() > 0 {
+		t.Errorf("Expected other errors not to be written as JSON, got %q", out.String())
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package uber
+
+// checkOwner is a no-op on platforms without Unix file ownership.
+func checkOwner(path string) error {
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package uber
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkOwner returns an error if the file at path isn't owned by the user
+// running uber.
+func checkOwner(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if uid := os.Getuid(); int(stat.Uid) != uid {
+		return withKind(ErrNotPermitted, fmt.Errorf("%s is owned by uid %d, not the current user (uid %d), and require_owner is set", path, stat.Uid, uid))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package uber
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestFindAndExecuteToolRequireOwner(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-require-owner")
+	defer cleanup()
+
+	for _, name := range []string{"mine", "theirs"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}, RequireOwner: true},
+	})
+
+	if err := executor.FindAndExecuteTool(context.Background(), "mine", []string{}); err != nil {
+		t.Errorf("Expected a tool owned by the current user to run, got: %v", err)
+	}
+
+	// Giving a file to another user needs privileges
+	if err := os.Chown(filepath.Join(tempDir, "theirs"), os.Getuid()+1, -1); err != nil {
+		t.Skipf("Cannot change file owner: %v", err)
+	}
+
+	err := executor.FindAndExecuteTool(context.Background(), "theirs", []string{})
+	if !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("Expected ErrNotPermitted for a tool owned by another user, got: %v", err)
+	}
+
+	executor.ctx.Config.RequireOwner = false
+	if err := executor.FindAndExecuteTool(context.Background(), "theirs", []string{}); err != nil {
+		t.Errorf("Expected the tool to run without require_owner, got: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package uber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isPathCommand reports whether command refers to a file by path, such as
+// "./deploy" or "/opt/tools/lint", rather than naming a tool.
+func isPathCommand(command string) bool {
+	return filepath.IsAbs(command) || strings.ContainsAny(command, `/\`)
+}
+
+// findPathCommand locates a command given as a path when path_commands is
+// set. Relative paths are resolved against the current directory and the
+// tool paths aren't searched. It returns the directory holding the file and
+// the file's absolute path, like findTool.
+func (te *ToolExecutor) findPathCommand(command string) (string, string, error) {
+	executablePath, err := filepath.Abs(command)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve '%s': %w", command, err)
+	}
+
+	info, err := os.Stat(executablePath)
+	if os.IsNotExist(err) {
+		return "", "", toolNotFound(command, nil, fmt.Errorf("tool '%s' not found: %s does not exist", command, executablePath))
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check '%s': %w", executablePath, err)
+	}
+	if !info.Mode().IsRegular() || !te.isExecutable(executablePath) {
+		return "", "", withKind(ErrNotExecutable, fmt.Errorf("tool '%s' found at '%s' but it is not executable", command, executablePath))
+	}
+
+	if te.ctx.Verbose {
+		ColorPrint(ColorGreen, fmt.Sprintf("Running '%s' by path, without searching the tool paths\n", executablePath))
+	}
+	return filepath.Dir(executablePath), executablePath, nil
+}
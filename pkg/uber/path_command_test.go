@@ -0,0 +1,87 @@
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestPathCommands(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-path-commands")
+	defer cleanup()
+	tempDir, _ = filepath.EvalSymlinks(tempDir)
+
+	// "deploy" exists both in the tool path and next to the caller
+	envFile := filepath.Join(tempDir, "env.txt")
+	scripts := map[string]string{
+		"bin/deploy":   "#!/bin/sh\nexit 3\n",
+		"local/deploy": fmt.Sprintf("#!/bin/sh\nenv > %s\n", envFile),
+		"local/notes":  "not a tool\n",
+	}
+	for name, content := range scripts {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		mode := os.FileMode(0755)
+		if name == "local/notes" {
+			mode = 0644
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	t.Chdir(filepath.Join(tempDir, "local"))
+
+	newExecutor := func(pathCommands bool) *ToolExecutor {
+		return NewToolExecutor(&RunContext{
+			Root:        tempDir,
+			Command:     "./deploy",
+			UberBinPath: "/dummy/bin/path",
+			Config:      &config.Config{ToolPaths: []string{"bin"}, PathCommands: pathCommands},
+		})
+	}
+
+	// Without path_commands, paths are refused as before
+	_, _, err := newExecutor(false).findTool("./deploy")
+	if !errors.Is(err, ErrUsage) || !strings.Contains(err.Error(), "path_commands") {
+		t.Errorf("Expected ErrUsage mentioning path_commands, got: %v", err)
+	}
+
+	// With it, the local file runs with uber's environment
+	executor := newExecutor(true)
+	if err := executor.FindAndExecuteTool(context.Background(), "./deploy", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	output, _ := os.ReadFile(envFile)
+	env := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if value, _ := envValue(env, "UBER_PROJECT_ROOT"); value != tempDir {
+		t.Errorf("Expected UBER_PROJECT_ROOT=%s, got:\n%s", tempDir, output)
+	}
+	if want := filepath.Join(tempDir, "local", "deploy"); executor.ctx.ExecutablePath != want {
+		t.Errorf("Expected executable %s, got %s", want, executor.ctx.ExecutablePath)
+	}
+
+	// Absolute paths work too
+	if _, executablePath, err := executor.findTool(filepath.Join(tempDir, "bin", "deploy")); err != nil || executablePath != filepath.Join(tempDir, "bin", "deploy") {
+		t.Errorf("Expected the absolute path to resolve, got %s, %v", executablePath, err)
+	}
+
+	// Bare names still search the tool paths
+	if _, executablePath, err := executor.findTool("deploy"); err != nil || executablePath != filepath.Join(tempDir, "bin", "deploy") {
+		t.Errorf("Expected the bare name to resolve in the tool path, got %s, %v", executablePath, err)
+	}
+
+	if _, _, err := executor.findTool("./missing"); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound for a missing file, got: %v", err)
+	}
+	if _, _, err := executor.findTool("./notes"); !errors.Is(err, ErrNotExecutable) {
+		t.Errorf("Expected ErrNotExecutable for a file that isn't executable, got: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package uber
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PickTool lets the user choose a tool to run from the available tools. The
+// list is printed to w and selections are read from r, one line at a time:
+// either the number of a listed tool, or text that narrows the list down to
+// the tools whose names contain its characters in order. An empty line or
+// the end of input cancels the selection.
+func (te *ToolExecutor) PickTool(r io.Reader, w io.Writer) (string, error) {
+	availableTools, err := te.GetAllAvailableTools()
+	if err != nil {
+		return "", err
+	}
+
+	// Offer every name that runs a tool, once, in tool_paths order
+	var all []string
+	toolsByPath := make(map[string][]AvailableTool)
+	var paths []string
+	for _, tool := range availableTools {
+		if _, ok := toolsByPath[tool.Path]; !ok {
+			paths = append(paths, tool.Path)
+		}
+		toolsByPath[tool.Path] = append(toolsByPath[tool.Path], tool)
+	}
+	for _, path := range paths {
+		names, _ := toolCommandNames(toolsByPath[path])
+		if path == inlineCommandsPath {
+			names = nil
+			for _, tool := range toolsByPath[path] {
+				names = append(names, tool.Name)
+			}
+		}
+		for _, name := range names {
+			if !slices.Contains(all, name) {
+				all = append(all, name)
+			}
+		}
+	}
+	if len(all) == 0 {
+		return "", withKind(ErrToolNotFound, fmt.Errorf("no tools available to pick from"))
+	}
+
+	scanner := bufio.NewScanner(r)
+	candidates := all
+	for {
+		for i, name := range candidates {
+			fmt.Fprintf(w, "%3d) %s\n", i+1, name)
+		}
+		fmt.Fprint(w, "Select a tool (number or filter, empty to cancel): ")
+
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			return "", withKind(ErrUsage, fmt.Errorf("no tool selected"))
+		}
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" {
+			return "", withKind(ErrUsage, fmt.Errorf("no tool selected"))
+		}
+
+		if n, err := strconv.Atoi(input); err == nil {
+			if n >= 1 && n <= len(candidates) {
+				return candidates[n-1], nil
+			}
+			fmt.Fprintf(w, "No tool numbered %d\n", n)
+			continue
+		}
+
+		var matches []string
+		for _, name := range all {
+			if fuzzyMatch(input, name) {
+				matches = append(matches, name)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			fmt.Fprintf(w, "No tools match '%s'\n", input)
+		case 1:
+			return matches[0], nil
+		default:
+			candidates = matches
+		}
+	}
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in name in the
+// same order, ignoring case.
+func fuzzyMatch(pattern, name string) bool {
+	name = strings.ToLower(name)
+	for _, c := range strings.ToLower(pattern) {
+		i := strings.IndexRune(name, c)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(string(c)):]
+	}
+	return true
+}
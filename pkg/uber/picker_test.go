@@ -0,0 +1,91 @@
+package uber
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestPickTool(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-pick")
+	defer cleanup()
+
+	for _, file := range []string{"bin/build", "bin/deploy.sh", "bin/deploy-prod", "scripts/build.py", "scripts/test"} {
+		path := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"bin", "scripts"}},
+	})
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "number", input: "2\n", want: "deploy"},
+		{name: "unique filter", input: "tst\n", want: "test"},
+		{name: "narrowed then number", input: "dep\n2\n", want: "deploy-prod"},
+		{name: "no match then number", input: "xyz\n1\n", want: "build"},
+		{name: "out of range then filter", input: "9\nbld\n", want: "build"},
+		{name: "empty line cancels", input: "\n", wantErr: ErrUsage},
+		{name: "end of input cancels", input: "dep\n", wantErr: ErrUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := executor.PickTool(strings.NewReader(tt.input), io.Discard)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PickTool failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PickTool() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickToolListsEachNameOnce(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-pick-list")
+	defer cleanup()
+
+	for _, file := range []string{"a/build", "b/build", "b/lint"} {
+		path := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"a", "b"}},
+	})
+
+	var output strings.Builder
+	executor.PickTool(strings.NewReader(""), &output)
+	if want := "  1) build\n  2) lint\n"; !strings.HasPrefix(output.String(), want) {
+		t.Errorf("Expected the list to start with %q, got %q", want, output.String())
+	}
+}
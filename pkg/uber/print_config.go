@@ -0,0 +1,27 @@
+package uber
+
+import (
+	"io"
+	"slices"
+)
+
+// PrintConfig writes the configuration in effect to w: the .uber file merged
+// with .uber.local and UBER_TOOL_PATHS, after environment variable
+// expansion. It is written as TOML, or as JSON when asJSON is set. Tool paths
+// are shown as the absolute paths uber searches.
+func (te *ToolExecutor) PrintConfig(w io.Writer, asJSON bool) error {
+	effective := *te.ctx.Config
+	effective.ToolPaths = make([]string, len(te.ctx.Config.ToolPaths))
+	effective.RequiredToolPaths = nil
+	for i, toolPath := range te.ctx.Config.ToolPaths {
+		effective.ToolPaths[i] = te.resolveToolFullPath(toolPath, "")
+		if slices.Contains(te.ctx.Config.RequiredToolPaths, toolPath) {
+			effective.RequiredToolPaths = append(effective.RequiredToolPaths, effective.ToolPaths[i])
+		}
+	}
+
+	if asJSON {
+		return effective.WriteJSON(w)
+	}
+	return effective.Write(w)
+}
@@ -0,0 +1,84 @@
+package uber
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestPrintConfig(t *testing.T) {
+	executor := NewToolExecutor(&RunContext{
+		Root: "/project",
+		Config: &config.Config{
+			ToolPaths: []string{"bin", "/opt/tools"},
+			EnvSetup:  "scripts/setup.sh",
+		},
+	})
+
+	var buf strings.Builder
+	if err := executor.PrintConfig(&buf, false); err != nil {
+		t.Fatalf("PrintConfig failed: %v", err)
+	}
+	printed, err := config.Load(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Expected valid TOML, got %v:\n%s", err, buf.String())
+	}
+	wantPaths := []string{filepath.Join("/project", "bin"), "/opt/tools"}
+	if !reflect.DeepEqual(printed.ToolPaths, wantPaths) {
+		t.Errorf("Expected absolute tool paths %v, got %v", wantPaths, printed.ToolPaths)
+	}
+	if printed.EnvSetup != "scripts/setup.sh" {
+		t.Errorf("Expected env_setup to be printed, got '%s'", printed.EnvSetup)
+	}
+
+	// The loaded configuration is left untouched
+	if executor.ctx.Config.ToolPaths[0] != "bin" {
+		t.Errorf("Expected the configured tool paths to be unchanged, got %v", executor.ctx.Config.ToolPaths)
+	}
+
+	buf.Reset()
+	if err := executor.PrintConfig(&buf, true); err != nil {
+		t.Fatalf("PrintConfig failed: %v", err)
+	}
+	var decoded struct {
+		ToolPaths []string `json:"tool_paths"`
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(decoded.ToolPaths, wantPaths) {
+		t.Errorf("Expected absolute tool paths %v, got %v", wantPaths, decoded.ToolPaths)
+	}
+}
+
+func TestPrintConfigRequiredToolPaths(t *testing.T) {
+	executor := NewToolExecutor(&RunContext{
+		Root: "/project",
+		Config: &config.Config{
+			ToolPaths:         []string{"bin", "generated", "/opt/tools"},
+			RequiredToolPaths: []string{"generated"},
+		},
+	})
+
+	var buf strings.Builder
+	if err := executor.PrintConfig(&buf, false); err != nil {
+		t.Fatalf("PrintConfig failed: %v", err)
+	}
+	printed, err := config.Load(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Expected valid TOML, got %v:\n%s", err, buf.String())
+	}
+
+	// The search order and required paths survive a round trip
+	wantPaths := []string{filepath.Join("/project", "bin"), filepath.Join("/project", "generated"), "/opt/tools"}
+	if !reflect.DeepEqual(printed.ToolPaths, wantPaths) {
+		t.Errorf("Expected tool paths %v, got %v", wantPaths, printed.ToolPaths)
+	}
+	if want := []string{filepath.Join("/project", "generated")}; !reflect.DeepEqual(printed.RequiredToolPaths, want) {
+		t.Errorf("Expected required tool paths %v, got %v:\n%s", want, printed.RequiredToolPaths, buf.String())
+	}
+}
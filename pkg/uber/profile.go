@@ -0,0 +1,56 @@
+package uber
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// profileEntry is one line of the file written by --profile.
+type profileEntry struct {
+	Timestamp      string `json:"timestamp"`
+	Command        string `json:"command"`
+	ExecutablePath string `json:"executable_path"`
+	FindToolMs     int64  `json:"find_tool_ms"`
+	EnvSetupMs     int64  `json:"env_setup_ms"`
+	ExecutionMs    int64  `json:"execution_ms"`
+	TotalMs        int64  `json:"total_ms"`
+	ExitCode       int    `json:"exit_code"`
+}
+
+// appendProfile appends the timings of this run as a JSON line to path. The
+// file is locked while writing so that concurrent uber runs can share it.
+func (te *ToolExecutor) appendProfile(path string, start time.Time, exitCode int) error {
+	entry := profileEntry{
+		Timestamp:      start.UTC().Format(time.RFC3339Nano),
+		Command:        te.ctx.Command,
+		ExecutablePath: te.ctx.ExecutablePath,
+		FindToolMs:     te.ctx.TimeFindToolMs,
+		EnvSetupMs:     te.ctx.TimeEnvSetupMs,
+		ExecutionMs:    te.ctx.TimeExecToolMs,
+		TotalMs:        te.ctx.TimeFindToolMs + te.ctx.TimeEnvSetupMs + te.ctx.TimeExecToolMs,
+		ExitCode:       exitCode,
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open profile file: %w", err)
+	}
+	defer file.Close()
+
+	unlock, err := lockFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to lock profile file: %w", err)
+	}
+	defer unlock()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write profile file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package uber
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestAppendProfile(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-profile")
+	defer cleanup()
+
+	profilePath := filepath.Join(tempDir, "profile.jsonl")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Concurrent writers each append whole lines
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			executor := NewToolExecutor(&RunContext{
+				Root:           tempDir,
+				Command:        "build",
+				ExecutablePath: "/project/bin/build.sh",
+				TimeFindToolMs: 1,
+				TimeEnvSetupMs: 2,
+				TimeExecToolMs: 3,
+				Config:         &config.Config{},
+			})
+			if err := executor.appendProfile(profilePath, start, 4); err != nil {
+				t.Errorf("appendProfile failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	file, err := os.Open(profilePath)
+	if err != nil {
+		t.Fatalf("Failed to open profile: %v", err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+		var entry profileEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to parse profile line %q: %v", scanner.Text(), err)
+		}
+		want := profileEntry{
+			Timestamp:      "2024-05-01T12:00:00Z",
+			Command:        "build",
+			ExecutablePath: "/project/bin/build.sh",
+			FindToolMs:     1,
+			EnvSetupMs:     2,
+			ExecutionMs:    3,
+			TotalMs:        6,
+			ExitCode:       4,
+		}
+		if entry != want {
+			t.Errorf("Profile entry = %+v, want %+v", entry, want)
+		}
+	}
+	if lines != writers {
+		t.Errorf("Expected %d profile lines, got %d", writers, lines)
+	}
+}
@@ -0,0 +1,98 @@
+package uber
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// progressDelay is how long a phase runs before the progress indicator
+// appears, so that fast phases don't flicker.
+const progressDelay = time.Second
+
+// progressInterval is how often the progress indicator is redrawn
+const progressInterval = 100 * time.Millisecond
+
+// progressFrames are the frames of the spinner
+var progressFrames = []string{"|", "/", "-", "\\"}
+
+// clearLine moves the cursor to the start of the line and erases it
+const clearLine = "\r\033[K"
+
+// progress draws a spinner with the elapsed time on a terminal while a slow
+// phase runs, and clears it once the phase has finished.
+type progress struct {
+	mu    sync.Mutex
+	w     io.Writer
+	label string
+	start time.Time
+	// shown is set while the spinner is on screen
+	shown   bool
+	frame   int
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// startProgress starts drawing the progress of the phase named label to w
+// once it has run for delay.
+func startProgress(w io.Writer, label string, delay time.Duration) *progress {
+	p := &progress{
+		w:     w,
+		label: label,
+		start: time.Now(),
+		done:  make(chan struct{}),
+	}
+	p.stopped.Add(1)
+	go p.run(delay)
+	return p
+}
+
+func (p *progress) run(delay time.Duration) {
+	defer p.stopped.Done()
+
+	select {
+	case <-time.After(delay):
+	case <-p.done:
+		return
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		p.draw()
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// draw redraws the spinner.
+func (p *progress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elapsed := time.Since(p.start).Truncate(100 * time.Millisecond)
+	fmt.Fprintf(p.w, "%s%s %s (%s)", clearLine, progressFrames[p.frame%len(progressFrames)], p.label, elapsed)
+	p.frame++
+	p.shown = true
+}
+
+// stop removes the spinner once the phase has finished.
+func (p *progress) stop() {
+	close(p.done)
+	p.stopped.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+// clear erases the spinner if it is on screen. p.mu must be held.
+func (p *progress) clear() {
+	if p.shown {
+		fmt.Fprint(p.w, clearLine)
+		p.shown = false
+	}
+}
@@ -0,0 +1,32 @@
+package uber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	indicator := startProgress(&buf, "Running env setup", 0)
+	time.Sleep(3 * progressInterval)
+	indicator.stop()
+
+	output := buf.String()
+	if !strings.Contains(output, "Running env setup (") {
+		t.Errorf("Expected the spinner to be drawn, got %q", output)
+	}
+	if !strings.HasSuffix(output, clearLine) {
+		t.Errorf("Expected the spinner to be cleared when stopped, got %q", output)
+	}
+}
+
+func TestProgressNotShownForFastPhases(t *testing.T) {
+	var buf bytes.Buffer
+	indicator := startProgress(&buf, "Running env setup", time.Hour)
+	indicator.stop()
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output for a fast phase, got %q", buf.String())
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package uber
+
+import "os/exec"
+
+// ptyAvailable reports false, pseudo-terminals are only supported on Unix.
+func ptyAvailable() bool {
+	return false
+}
+
+// runWithPTY runs cmd without a pseudo-terminal.
+func runWithPTY(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
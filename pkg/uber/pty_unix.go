@@ -0,0 +1,98 @@
+//go:build unix
+
+package uber
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"golang.org/x/term"
+)
+
+// ptyAvailable reports whether uber is attached to a terminal, which is
+// required to wire a pseudo-terminal through to the user.
+func ptyAvailable() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// runWithPTY runs cmd attached to a new pseudo-terminal that is connected to
+// uber's own terminal, so that TTY-aware tools behave as if run directly.
+func runWithPTY(cmd *exec.Cmd) error {
+	// Let pty.Start connect the command to the pseudo-terminal. It starts
+	// with the size of the user's terminal so that full-screen tools draw
+	// correctly from the start.
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = nil, nil, nil
+	size, err := pty.GetsizeFull(os.Stdin)
+	if err != nil {
+		size = nil
+	}
+	ptmx, err := pty.StartWithSize(cmd, size)
+	if err != nil {
+		return err
+	}
+	defer ptmx.Close()
+
+	// Keep the pseudo-terminal the same size as the user's terminal when it
+	// is resized
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer func() {
+		signal.Stop(winch)
+		close(winch)
+	}()
+	go func() {
+		for range winch {
+			pty.InheritSize(os.Stdin, ptmx)
+		}
+	}()
+
+	// Pass keystrokes through unprocessed; the tool's terminal handles them
+	if state, err := term.MakeRaw(int(os.Stdin.Fd())); err == nil {
+		defer term.Restore(int(os.Stdin.Fd()), state)
+	}
+
+	stopStdin := copyStdin(ptmx)
+	// Returns once the tool exits and the pseudo-terminal is closed
+	io.Copy(os.Stdout, ptmx)
+
+	err = cmd.Wait()
+	stopStdin()
+	return err
+}
+
+// copyStdin copies stdin to w until the returned function is called. The
+// copy must stop when the tool exits, or it would swallow input meant for
+// whatever runs next, so it reads from a non-blocking duplicate of stdin
+// whose read can be interrupted.
+func copyStdin(w io.Writer) (stop func()) {
+	fd, err := syscall.Dup(int(os.Stdin.Fd()))
+	if err != nil {
+		go io.Copy(w, os.Stdin)
+		return func() {}
+	}
+	// The flag is shared with stdin, so it is cleared again afterwards
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		go io.Copy(w, os.Stdin)
+		return func() {}
+	}
+	stdin := os.NewFile(uintptr(fd), "stdin")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(w, stdin)
+	}()
+	return func() {
+		if err := stdin.SetReadDeadline(time.Now()); err == nil {
+			<-done
+		}
+		syscall.SetNonblock(fd, false)
+		stdin.Close()
+	}
+}
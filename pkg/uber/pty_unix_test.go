@@ -0,0 +1,225 @@
+//go:build unix
+
+package uber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+	"github.com/creack/pty"
+)
+
+func TestRunWithPTY(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	ptmx.Close()
+	tty.Close()
+
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-pty")
+	defer cleanup()
+
+	// Discard what the tool prints to the pseudo-terminal
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = devNull, devNull
+	defer func() {
+		os.Stdin, os.Stdout = oldStdin, oldStdout
+	}()
+
+	outputFile := filepath.Join(tempDir, "output.txt")
+	script := fmt.Sprintf("if [ -t 0 ] && [ -t 1 ] && [ -t 2 ]; then echo tty > %s; else echo no-tty > %s; fi", outputFile, outputFile)
+	if err := runWithPTY(exec.Command("/bin/sh", "-c", script)); err != nil {
+		t.Fatalf("runWithPTY failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(output) != "tty\n" {
+		t.Errorf("Expected the tool to see a terminal, got '%s'", string(output))
+	}
+
+	// A failing tool's exit status is returned
+	err = runWithPTY(exec.Command("/bin/sh", "-c", "exit 3"))
+	if got := ExitCode(err); got != 3 {
+		t.Errorf("ExitCode() = %d, want 3", got)
+	}
+}
+
+func TestRunWithPTYStopsReadingStdin(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	ptmx.Close()
+	tty.Close()
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = r, devNull
+	defer func() {
+		os.Stdin, os.Stdout = oldStdin, oldStdout
+	}()
+
+	if err := runWithPTY(exec.Command("/bin/sh", "-c", "exit 0")); err != nil {
+		t.Fatalf("runWithPTY failed: %v", err)
+	}
+
+	// Input written after the tool exited is left for whatever runs next
+	if _, err := w.Write([]byte("next\n")); err != nil {
+		t.Fatalf("Failed to write to stdin: %v", err)
+	}
+	// runWithPTY made stdin blocking by asking for its fd, so a read deadline
+	// would not apply
+	read := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 16)
+		n, _ := r.Read(buf)
+		read <- string(buf[:n])
+	}()
+	select {
+	case got := <-read:
+		if got != "next\n" {
+			t.Errorf("Expected to read the input after the tool exited, got %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expected to read the input after the tool exited, but it was consumed")
+	}
+}
+
+func TestRunWithPTYSize(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+	if err := pty.Setsize(tty, &pty.Winsize{Rows: 42, Cols: 123}); err != nil {
+		t.Fatalf("Failed to set the terminal size: %v", err)
+	}
+
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-pty-size")
+	defer cleanup()
+
+	// uber's terminal is the pseudo-terminal opened above
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = tty, devNull
+	defer func() {
+		os.Stdin, os.Stdout = oldStdin, oldStdout
+	}()
+
+	// The tool sees the size as soon as it starts
+	outputFile := filepath.Join(tempDir, "size.txt")
+	if err := runWithPTY(exec.Command("/bin/sh", "-c", fmt.Sprintf("stty size > %s", outputFile))); err != nil {
+		t.Fatalf("runWithPTY failed: %v", err)
+	}
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(output) != "42 123\n" {
+		t.Errorf("Expected the tool to see a 42x123 terminal, got %q", string(output))
+	}
+}
+
+func TestTerminalSizeEnv(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+	if err := pty.Setsize(tty, &pty.Winsize{Rows: 30, Cols: 90}); err != nil {
+		t.Fatalf("Failed to set the terminal size: %v", err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = tty
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	for _, enabled := range []bool{true, false} {
+		executor := NewToolExecutor(&RunContext{Config: &config.Config{TerminalSizeEnv: enabled}})
+		env := executor.prepareEnvironment()
+		columns, _ := envValue(env, "COLUMNS")
+		lines, _ := envValue(env, "LINES")
+		if enabled && (columns != "90" || lines != "30") {
+			t.Errorf("Expected COLUMNS=90 and LINES=30, got COLUMNS=%q LINES=%q", columns, lines)
+		}
+		if !enabled && (columns == "90" || lines == "30") {
+			t.Errorf("Expected the terminal size not to be exported without terminal_size_env")
+		}
+	}
+
+	// Nothing is exported when stdout isn't a terminal
+	os.Stdout = oldStdout
+	devNull, _ := os.Open(os.DevNull)
+	defer devNull.Close()
+	os.Stdout = devNull
+	if env := terminalSizeEnvironment(); env != nil {
+		t.Errorf("Expected no variables without a terminal, got %v", env)
+	}
+}
+
+func TestEnvSetupKeepsTerminalStderr(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-setup-tty")
+	defer cleanup()
+	setupScript := filepath.Join(tempDir, "setup.sh")
+	if err := os.WriteFile(setupScript, []byte("#!/bin/sh\nif [ -t 2 ]; then echo STDERR=tty; else echo STDERR=no-tty; fi\n"), 0755); err != nil {
+		t.Fatalf("Failed to create setup script: %v", err)
+	}
+
+	// With a terminal on stderr the progress indicator is enabled, and the
+	// script still writes to the terminal itself
+	oldStderr := os.Stderr
+	os.Stderr = tty
+	defer func() {
+		os.Stderr = oldStderr
+	}()
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{EnvSetup: setupScript},
+	})
+	env, err := executor.executeEnvSetup(context.Background())
+	if err != nil {
+		t.Fatalf("executeEnvSetup failed: %v", err)
+	}
+	if got, _ := envValue(env, "STDERR"); got != "tty" {
+		t.Errorf("Expected the script to see a terminal on stderr, got %q", got)
+	}
+}
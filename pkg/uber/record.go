@@ -0,0 +1,72 @@
+package uber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Recording is the JSON document written by --record and read by --replay.
+// It holds everything needed to start the tool again exactly as uber did.
+type Recording struct {
+	// ToolPath is the resolved path of the executable that was started. With
+	// a wrapper or default_shell this is the wrapper or shell.
+	ToolPath string `json:"tool_path"`
+	// Args is the full argument vector, starting with the program name
+	Args []string `json:"args"`
+	// Env is the complete environment of the tool as KEY=value entries
+	Env []string `json:"env"`
+	// Dir is the working directory of the tool, empty for uber's own
+	Dir string `json:"dir,omitempty"`
+}
+
+// writeRecording saves the invocation of cmd to path for --replay. The file
+// is only readable by the owner since the environment may contain secrets.
+func writeRecording(path string, cmd *exec.Cmd) error {
+	recording := Recording{
+		ToolPath: cmd.Path,
+		Args:     cmd.Args,
+		Env:      cmd.Env,
+		Dir:      cmd.Dir,
+	}
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// Replay runs the tool recorded in the file at path with exactly the recorded
+// arguments, environment and working directory. The project configuration
+// isn't consulted.
+func Replay(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return withKind(ErrUsage, fmt.Errorf("failed to read recording: %w", err))
+	}
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return withKind(ErrUsage, fmt.Errorf("invalid recording '%s': %w", path, err))
+	}
+	if recording.ToolPath == "" || len(recording.Args) == 0 {
+		return withKind(ErrUsage, fmt.Errorf("invalid recording '%s': missing tool_path or args", path))
+	}
+
+	cmd := commandContext(ctx, recording.ToolPath)
+	cmd.Args = recording.Args
+	cmd.Env = recording.Env
+	cmd.Dir = recording.Dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return explainStartError(recording.ToolPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package uber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-record")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "output.txt")
+	toolContent := "#!/bin/sh\necho \"$UBER_PROJECT_ROOT $*\" > " + outputFile + "\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "recorded"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	recordFile := filepath.Join(tempDir, "recording.json")
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Record: recordFile,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "recorded", []string{"a b", "c"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	original, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	data, err := os.ReadFile(recordFile)
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		t.Fatalf("Invalid recording: %v\n%s", err, data)
+	}
+	if recording.ToolPath != filepath.Join(tempDir, "recorded") {
+		t.Errorf("Expected tool_path to be the resolved tool, got '%s'", recording.ToolPath)
+	}
+	if got, _ := envValue(recording.Env, "UBER_PROJECT_ROOT"); got != tempDir {
+		t.Errorf("Expected the environment to be recorded, got UBER_PROJECT_ROOT='%s'", got)
+	}
+	if info, err := os.Stat(recordFile); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the recording to be private, got mode %v", info.Mode().Perm())
+	}
+
+	// Replaying reproduces the output without any configuration
+	os.Remove(outputFile)
+	if err := Replay(context.Background(), recordFile); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	replayed, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(replayed) != string(original) {
+		t.Errorf("Expected replay output %q, got %q", original, replayed)
+	}
+}
+
+func TestReplayInvalidRecording(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-replay-invalid")
+	defer cleanup()
+
+	recordFile := filepath.Join(tempDir, "recording.json")
+	if err := os.WriteFile(recordFile, []byte(`{"env": []}`), 0644); err != nil {
+		t.Fatalf("Failed to create recording: %v", err)
+	}
+
+	for _, path := range []string{recordFile, filepath.Join(tempDir, "missing.json")} {
+		if err := Replay(context.Background(), path); !errors.Is(err, ErrUsage) {
+			t.Errorf("Expected ErrUsage for %s, got: %v", path, err)
+		}
+	}
+}
+
+func TestParseArgsReplay(t *testing.T) {
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--replay", "recording.json"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if ctx.Replay != "recording.json" || ctx.Config != nil {
+		t.Errorf("Expected --replay without loading a configuration, got %q and %v", ctx.Replay, ctx.Config)
+	}
+
+	for _, args := range [][]string{
+		{"--replay", "recording.json", "build"},
+		{"--replay", "recording.json", "--record", "other.json"},
+	} {
+		if _, err := ParseArgs("/dummy/bin/path", args, io.Discard); !errors.Is(err, ErrUsage) {
+			t.Errorf("Expected ErrUsage for %v, got: %v", args, err)
+		}
+	}
+}
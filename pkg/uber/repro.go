@@ -0,0 +1,61 @@
+package uber
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// reproCommand returns a shell command line that reproduces the invocation of
+// cmd outside of uber: its working directory, umask, the environment
+// variables that differ from uber's own environment and the quoted arguments.
+func (te *ToolExecutor) reproCommand(cmd *exec.Cmd) string {
+	var parts []string
+
+	if cmd.Dir != "" {
+		parts = append(parts, "cd", shellQuote(cmd.Dir), "&&")
+	}
+	if mask, ok, err := te.ctx.Config.ParseUmask(); err == nil && ok {
+		parts = append(parts, "umask", fmt.Sprintf("%03o", mask), "&&")
+	}
+
+	// Only the variables uber added or changed are needed
+	current := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			current[key] = value
+		}
+	}
+	overrides := make(map[string]string)
+	for _, kv := range cmd.Env {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if currentValue, ok := current[key]; ok && currentValue == value {
+			delete(overrides, key)
+			continue
+		}
+		overrides[key] = value
+	}
+	if len(overrides) > 0 {
+		keys := make([]string, 0, len(overrides))
+		for key := range overrides {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		parts = append(parts, "env")
+		for _, key := range keys {
+			parts = append(parts, key+"="+shellQuote(overrides[key]))
+		}
+	}
+
+	for _, arg := range cmd.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+
+	return strings.Join(parts, " ")
+}
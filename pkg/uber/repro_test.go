@@ -0,0 +1,57 @@
+package uber
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestReproCommand(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-repro")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "output.txt")
+	toolContent := fmt.Sprintf("#!/bin/sh\nfor arg in \"$@\"; do echo \"[$arg]\"; done > %s\necho \"$REPRO_VAR\" >> %s\npwd >> %s\n", outputFile, outputFile, outputFile)
+	toolPath := filepath.Join(tempDir, "my tool")
+	if err := os.WriteFile(toolPath, []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	workDir := filepath.Join(tempDir, "work dir")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	t.Setenv("UNCHANGED_VAR", "same")
+	executor := NewToolExecutor(&RunContext{Root: tempDir, Config: &config.Config{}})
+
+	cmd := exec.Command(toolPath, "a b", "it's", "$HOME")
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "REPRO_VAR=x 'y'")
+
+	line := executor.reproCommand(cmd)
+	if strings.Contains(line, "UNCHANGED_VAR") {
+		t.Errorf("Expected unchanged variables to be omitted, got: %s", line)
+	}
+	if !strings.Contains(line, "env REPRO_VAR=") {
+		t.Errorf("Expected the env override in the command, got: %s", line)
+	}
+
+	// Running the line in a shell reproduces the invocation
+	if out, err := exec.Command("/bin/sh", "-c", line).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run %s: %v\n%s", line, err, out)
+	}
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	realWorkDir, _ := filepath.EvalSymlinks(workDir)
+	want := fmt.Sprintf("[a b]\n[it's]\n[$HOME]\nx 'y'\n%s\n", realWorkDir)
+	if got := string(output); got != want && got != strings.Replace(want, realWorkDir, workDir, 1) {
+		t.Errorf("Reproduced output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package uber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// RunInRoots runs ctx.Command in each of ctx.Roots in turn. Every root loads
+// its own configuration and runs its own env_setup, as if uber had been run
+// with --root for it. All roots are run even when some fail; the returned
+// error lists the failed roots and wraps the first failure so its exit code
+// is preserved.
+func (ctx *RunContext) RunInRoots(execCtx context.Context) error {
+	var failed []string
+	var firstErr error
+	for i, projectRoot := range ctx.Roots {
+		// The header goes to stderr so that the tools' output can be piped
+		if i > 0 {
+			fmt.Fprintln(os.Stderr)
+		}
+		ColorPrintStatus(ColorCyan, fmt.Sprintf("==> %s\n", projectRoot))
+
+		if err := ctx.runInRoot(execCtx, projectRoot); err != nil {
+			ColorPrintError(fmt.Sprintf("error: %v\n", err))
+			failed = append(failed, projectRoot)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("'%s' failed in %d of %d roots (%s): %w", ctx.Command, len(failed), len(ctx.Roots), strings.Join(failed, ", "), firstErr)
+	}
+	return nil
+}
+
+// runInRoot runs ctx.Command with the configuration of a single root. The
+// run is profiled and its usage recorded like a run with --root would be.
+func (ctx *RunContext) runInRoot(execCtx context.Context, projectRoot string) error {
+	if err := validateProjectRoot(projectRoot); err != nil {
+		return withKind(ErrConfig, fmt.Errorf("invalid root '%s': %w", projectRoot, err))
+	}
+
+	rootCtx := *ctx
+	rootCtx.Roots = nil
+	if err := rootCtx.loadProject(projectRoot); err != nil {
+		return err
+	}
+
+	executor := NewToolExecutor(&rootCtx)
+	start := time.Now()
+	err := executor.RunCommand(execCtx, rootCtx.Command, rootCtx.RemainingArgs)
+	if rootCtx.Profile != "" {
+		if profileErr := executor.appendProfile(rootCtx.Profile, start, ExitCode(err)); profileErr != nil {
+			ColorPrintWarning(fmt.Sprintf("Warning: failed to write profile: %v\n", profileErr))
+		}
+	}
+	if rootCtx.Config.TrackUsage {
+		if usageErr := executor.recordUsage(start, ExitCode(err)); usageErr != nil {
+			ColorPrintWarning(fmt.Sprintf("Warning: failed to record usage: %v\n", usageErr))
+		}
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package uber
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// createRootWithLint creates a project root whose lint tool records that it
+// ran in marker and exits with the given status
+func createRootWithLint(t *testing.T, parent, name, marker, status string) string {
+	projectRoot := filepath.Join(parent, name)
+	if err := os.MkdirAll(filepath.Join(projectRoot, "bin"), 0755); err != nil {
+		t.Fatalf("Failed to create tool directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectRoot, ".uber"), []byte(`tool_paths = ["bin"]`), 0644); err != nil {
+		t.Fatalf("Failed to create .uber file: %v", err)
+	}
+	script := "#!/bin/sh\necho \"$UBER_PROJECT_ROOT\" >> " + marker + "\nexit " + status + "\n"
+	if err := os.WriteFile(filepath.Join(projectRoot, "bin", "lint"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	return projectRoot
+}
+
+func TestRunInRoots(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-roots")
+	defer cleanup()
+	tempDir, _ = filepath.EvalSymlinks(tempDir)
+
+	marker := filepath.Join(tempDir, "ran")
+	repoA := createRootWithLint(t, tempDir, "repoA", marker, "3")
+	repoB := createRootWithLint(t, tempDir, "repoB", marker, "0")
+	missing := filepath.Join(tempDir, "missing")
+
+	ctx := &RunContext{
+		Roots:   []string{repoA, missing, repoB},
+		Command: "lint",
+	}
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	stdoutR, stdoutW, _ := os.Pipe()
+	stderrR, stderrW, _ := os.Pipe()
+	os.Stdout, os.Stderr = stdoutW, stderrW
+	err := ctx.RunInRoots(context.Background())
+	stdoutW.Close()
+	stderrW.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	var stdout, buf strings.Builder
+	io.Copy(&stdout, stdoutR)
+	io.Copy(&buf, stderrR)
+	output := buf.String()
+
+	if err == nil {
+		t.Fatal("Expected an error when a root fails")
+	}
+	if !strings.Contains(err.Error(), "'lint' failed in 2 of 3 roots") {
+		t.Errorf("Expected the error to count the failed roots, got: %v", err)
+	}
+	if got := ExitCode(err); got != 3 {
+		t.Errorf("Expected the exit code of the first failure, got %d", got)
+	}
+
+	// Every root runs, even after a failure
+	data, readErr := os.ReadFile(marker)
+	if readErr != nil {
+		t.Fatalf("Failed to read marker file: %v", readErr)
+	}
+	if got := strings.Fields(string(data)); !reflect.DeepEqual(got, []string{repoA, repoB}) {
+		t.Errorf("Expected lint to run in each root with its own UBER_PROJECT_ROOT, got %v", got)
+	}
+
+	// The headers go to stderr, leaving stdout to the tools
+	for _, root := range ctx.Roots {
+		if !strings.Contains(output, "==> "+root+"\n") {
+			t.Errorf("Expected a header for %s on stderr, got:\n%s", root, output)
+		}
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected nothing from uber on stdout, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunInRootsRecordsUsage(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-roots-usage")
+	defer cleanup()
+
+	marker := filepath.Join(tempDir, "ran")
+	tracked := createRootWithLint(t, tempDir, "tracked", marker, "2")
+	untracked := createRootWithLint(t, tempDir, "untracked", marker, "0")
+	if err := os.WriteFile(filepath.Join(tracked, ".uber"), []byte("tool_paths = [\"bin\"]\ntrack_usage = true\n"), 0644); err != nil {
+		t.Fatalf("Failed to update .uber file: %v", err)
+	}
+
+	ctx := &RunContext{
+		Roots:   []string{tracked, untracked},
+		Command: "lint",
+	}
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	devNull, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	os.Stdout, os.Stderr = devNull, devNull
+	ctx.RunInRoots(context.Background())
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	devNull.Close()
+
+	// Each root records its runs in its own usage log
+	data, err := os.ReadFile(NewToolExecutor(&RunContext{Root: tracked}).cachePath(usageFile))
+	if err != nil {
+		t.Fatalf("Failed to read usage log: %v", err)
+	}
+	if !strings.Contains(string(data), `"tool":"lint","exit_code":2`) || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("Expected one usage entry for lint, got:\n%s", data)
+	}
+	if _, err := os.Stat(NewToolExecutor(&RunContext{Root: untracked}).cachePath(usageFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no usage log without track_usage, got %v", err)
+	}
+}
+
+func TestParseArgsRoots(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-parse-roots")
+	defer cleanup()
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--roots", "repoA, repoB,", "lint", "--fix"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if !reflect.DeepEqual(ctx.Roots, []string{"repoA", "repoB"}) {
+		t.Errorf("Expected roots [repoA repoB], got %v", ctx.Roots)
+	}
+	if ctx.Config != nil {
+		t.Errorf("Expected no configuration to be loaded until each root runs")
+	}
+	if ctx.Command != "lint" || !reflect.DeepEqual(ctx.RemainingArgs, []string{"--fix"}) {
+		t.Errorf("Expected command lint with args [--fix], got %s %v", ctx.Command, ctx.RemainingArgs)
+	}
+
+	for _, args := range [][]string{
+		{"--roots", "repoA"},
+		{"--roots", ","},
+		{"--roots", "repoA", "--root", tempDir, "lint"},
+		{"--roots", "repoA", "--list-tools"},
+	} {
+		if _, err := ParseArgs("/dummy/bin/path", args, io.Discard); !errors.Is(err, ErrUsage) {
+			t.Errorf("Expected ErrUsage for %v, got: %v", args, err)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package uber
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/chaselatta/uber/config"
 	"github.com/spf13/pflag"
@@ -14,24 +17,91 @@ import (
 // RunContext holds all parsed command-line arguments and flags.
 type RunContext struct {
 	Root              string
+	Roots             []string
 	UberBinPath       string
 	Verbose           bool
 	ListTools         bool
+	Pick              bool
+	Long              bool
+	NoToolCache       bool
 	ShowVersion       bool
+	CheckVersion      bool
+	IsolateTmp        bool
+	NoReporting       bool
+	PTY               bool
+	Repro             bool
+	Record            string
+	Replay            string
+	Trace             bool
+	TraceFile         string
+	TraceOutput       io.Writer
+	Profile           string
+	Doctor            bool
+	PrintConfig       bool
+	DumpArgs          bool
+	Explain           string
+	JSON              bool
+	Wrapper           []string
+	ForwardSignals    []os.Signal
 	Command           string
 	RemainingArgs     []string
 	GlobalCommandArgs string
+	OriginalArgs      []string
 	Config            *config.Config
 	FoundToolPath     string
+	ExecutablePath    string
+	ToolConfig        config.ToolConfig
+	TmpDir            string
+	Detach            bool
+	KeepGoing         bool
+	Benchmark         int
+	DirScanTimeout    time.Duration
+	Bare              bool
+	EnvDiff           bool
+	StrictMatch       bool
+	Nice              *int
+	PidFile           string
+	LogFile           string
+	ToolExitCode      int
+	SequenceResults   []string
 	TimeFindToolMs    int64
 	TimeEnvSetupMs    int64
 	TimeExecToolMs    int64
+
+	// configErr is why the configuration failed to load when --doctor
+	// carried on to report it
+	configErr error
+	// wrapFlag is the value of --wrap, applied when a project is loaded
+	wrapFlag string
+	// messagePrefixFlag is the value of --message-prefix, nil if it wasn't
+	// given so that message_prefix applies
+	messagePrefixFlag *string
+}
+
+// rootEnvVar is the environment variable that sets the project root when
+// --root isn't given, instead of searching for it from the working directory.
+const rootEnvVar = "UBER_ROOT"
+
+// defaultRootMarker is the file that marks the project root unless another
+// marker is given with --root-marker or UBER_ROOT_MARKER.
+const defaultRootMarker = ".uber"
+
+// rootMarker returns the file name that marks the project root. The flag value
+// takes precedence over the UBER_ROOT_MARKER environment variable.
+func rootMarker(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if marker := os.Getenv("UBER_ROOT_MARKER"); marker != "" {
+		return marker
+	}
+	return defaultRootMarker
 }
 
 // findProjectRoot walks up the directory tree starting from the current working directory
-// to find a directory containing a .uber file, which indicates the project root.
-// Returns the absolute path to the project root, or an error if not found.
-func findProjectRoot() (string, error) {
+// to find a directory containing the marker file (normally .uber), which indicates the
+// project root. Returns the absolute path to the project root, or an error if not found.
+func findProjectRoot(marker string) (string, error) {
 	currentDir, err := os.Getwd()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current working directory: %w", err)
@@ -39,9 +109,13 @@ func findProjectRoot() (string, error) {
 
 	// Walk up the directory tree
 	for {
-		// Check if .uber file exists in current directory
-		uberFile := filepath.Join(currentDir, ".uber")
-		if _, err := os.Stat(uberFile); err == nil {
+		// Check if the marker file exists in current directory
+		markerFile := filepath.Join(currentDir, marker)
+		if info, err := os.Stat(markerFile); err == nil {
+			// Other markers may be directories, but .uber must be the config file
+			if marker == defaultRootMarker && info.IsDir() {
+				return "", fmt.Errorf("expected %s to be a file but found a directory", markerFile)
+			}
 			return currentDir, nil
 		}
 
@@ -56,26 +130,91 @@ func findProjectRoot() (string, error) {
 		currentDir = parentDir
 	}
 
-	return "", fmt.Errorf("no .uber file found in current directory or any parent directories")
+	return "", fmt.Errorf("no %s file found in current directory or any parent directories", marker)
+}
+
+// findAncestorRoot returns the project root levels levels above root: the
+// levels-th directory above root that contains a .uber file. Directories
+// without one are skipped, so levels counts enclosing projects rather than
+// directories.
+func findAncestorRoot(root string, levels int) (string, error) {
+	dir, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	for found := 0; found < levels; {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("%d project root(s) enclose %s but --root-level asks for %d", found, root, levels)
+		}
+		dir = parent
+		if info, err := os.Stat(filepath.Join(dir, ".uber")); err == nil && !info.IsDir() {
+			found++
+		}
+	}
+	return dir, nil
 }
 
 // validateProjectRoot checks if the specified directory contains a .uber file.
 // Returns an error if the directory doesn't contain a .uber file or if the path is invalid.
 func validateProjectRoot(rootPath string) error {
 	// Check if the directory exists
-	if _, err := os.Stat(rootPath); err != nil {
+	info, err := os.Stat(rootPath)
+	if err != nil {
 		return fmt.Errorf("specified root directory does not exist: %w", err)
 	}
+	if !info.IsDir() {
+		return fmt.Errorf("specified root '%s' is a file, not a directory; pass the directory containing the .uber file", rootPath)
+	}
 
 	// Check if .uber file exists in the specified directory
 	uberFile := filepath.Join(rootPath, ".uber")
-	if _, err := os.Stat(uberFile); err != nil {
+	uberInfo, err := os.Stat(uberFile)
+	if err != nil {
 		return fmt.Errorf("specified root directory does not contain a .uber file")
 	}
+	if uberInfo.IsDir() {
+		return fmt.Errorf("expected %s to be a file but found a directory", uberFile)
+	}
 
 	return nil
 }
 
+// firstUnknownFlag returns the first argument that looks like a flag but is
+// not one of uber's own flags, or an empty string if there is none.
+func firstUnknownFlag(fs *pflag.FlagSet, args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+
+		name, _, inlineValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "" {
+			continue
+		}
+		var flag *pflag.Flag
+		if strings.HasPrefix(arg, "--") {
+			flag = fs.Lookup(name)
+		} else {
+			flag = fs.ShorthandLookup(name[:1])
+			inlineValue = inlineValue || len(name) > 1
+		}
+		if flag == nil {
+			return arg
+		}
+		// The next argument is the value of a flag that takes one, even if
+		// it starts with a dash
+		if !inlineValue && flag.NoOptDefVal == "" {
+			i++
+		}
+	}
+	return ""
+}
+
 // ParseArgs parses flags and positional arguments into a RunContext struct.
 // It takes an explicit args slice (excluding the program name) for testability.
 // If --root is specified, it validates that the directory contains a .uber file.
@@ -88,7 +227,42 @@ func ParseArgs(binPath string, args []string, output io.Writer) (*RunContext, er
 	root := fs.String("root", "", "Specify the root directory (e.g., --root /path/to/dir)")
 	verbose := fs.BoolP("verbose", "v", false, "Enable verbose output (-v or --verbose)")
 	listTools := fs.Bool("list-tools", false, "List available tools")
+	toolFlag := fs.String("tool", "", "Run this tool, passing every positional argument to it (e.g. --tool deploy -- --version)")
+	roots := fs.String("roots", "", "Run the tool in each of these comma-separated project roots")
+	messagePrefixValue := fs.String("message-prefix", "", "Prefix every line of uber's own messages, e.g. \"[uber] \" (overrides message_prefix)")
+	color := fs.String("color", ColorModeAuto, "Color output: auto, always or never")
+	trace := fs.Bool("trace", false, "Log every subprocess uber starts with timestamps to stderr")
+	traceFile := fs.String("trace-file", "", "Append the --trace events to the given file instead of stderr")
+	dirScanTimeout := fs.Duration("dir-scan-timeout", 0, "Skip a tool directory that takes longer than this to read, e.g. 2s (default no limit)")
+	noToolCache := fs.Bool("no-tool-cache", false, "Rescan the tool paths instead of using the tool_cache")
+	pick := fs.Bool("pick", false, "Without a command, choose the tool to run from a list when in a terminal")
+	long := fs.Bool("long", false, "With --list-tools, show each tool's full path and whether it is the one selected")
 	showVersion := fs.Bool("version", false, "Show version information")
+	versionJSON := fs.Bool("version-json", false, "Show version information as JSON (same as --version --json)")
+	checkVersion := fs.Bool("check", false, "With --version, fail if uber is older than the project's min_uber_version")
+	isolateTmp := fs.Bool("isolate-tmp", false, "Give the tool a fresh temporary directory that is removed afterwards")
+	bare := fs.Bool("bare", false, "Debugging aid: run the tool with uber's environment unmodified, without env setup or UBER_ variables")
+	noReporting := fs.Bool("no-reporting", false, "Skip the reporting command (also set by UBER_NO_REPORTING=1)")
+	repro := fs.Bool("repro", false, "Print a shell command that reproduces the tool invocation before running it")
+	record := fs.String("record", "", "Save the resolved tool path, arguments and environment to the given file as JSON")
+	replay := fs.String("replay", "", "Run the tool saved with --record again, without loading the configuration")
+	profile := fs.String("profile", "", "Append the phase timings of this run as a JSON line to the given file")
+	keepGoing := fs.Bool("keep-going", false, "When running a sequence, run the remaining steps after a step fails")
+	benchmark := fs.Int("benchmark", 0, "Run the tool the given number of times with its output discarded and print timing statistics")
+	detach := fs.Bool("detach", false, "Start the tool in the background, writing its PID to a pidfile and its output to a log file")
+	usePTY := fs.Bool("pty", false, "Run the tool in a pseudo-terminal when uber is attached to a terminal")
+	nice := fs.Int("nice", 0, "Run the tool with its nice value adjusted by this much on Unix, as with nice -n; positive values lower its priority (overrides the tool's nice option)")
+	envDiff := fs.Bool("env-diff", false, "Run the env setup for the tool and print the variables it adds or changes, without running the tool")
+	strictMatch := fs.Bool("strict-match", false, "Only run a tool whose file name is exactly the command, without inferring extensions or suggesting alternatives")
+	explain := fs.String("explain", "", "Show how a tool name is resolved without running it")
+	doctor := fs.Bool("doctor", false, "Check the project configuration for problems")
+	printConfig := fs.Bool("print-config", false, "Print the configuration in effect after merging all sources")
+	jsonOutput := fs.Bool("json", false, "With --version, --doctor, --print-config or --explain, print the results as JSON; with a command, print a tool that isn't found as JSON")
+	rootLevel := fs.Int("root-level", 0, "Use the project enclosing the detected (or --root) project this many levels up, e.g. 1 for the parent project")
+	rootMarkerFlag := fs.String("root-marker", "", "File that marks the project root when searching for it (default .uber, or UBER_ROOT_MARKER)")
+	wrap := fs.String("wrap", "", "Run the tool under a wrapper command (e.g. --wrap \"perf stat --\")")
+	dumpArgs := fs.Bool("dump-args", false, "Print how the command line was split into command, tool arguments and global arguments, then exit")
+	fs.MarkHidden("dump-args")
 
 	if output == nil {
 		output = os.Stderr
@@ -100,104 +274,378 @@ func ParseArgs(binPath string, args []string, output io.Writer) (*RunContext, er
 
 	// Parse the known uber flags
 	if err := fs.Parse(args); err != nil {
-		return nil, err
+		return nil, withKind(ErrUsage, err)
+	}
+
+	if *versionJSON {
+		*showVersion = true
+		*jsonOutput = true
+	}
+
+	// Apply the color mode right away so that messages printed while
+	// loading the configuration honor it
+	if err := SetColorMode(*color); err != nil {
+		return nil, withKind(ErrUsage, fmt.Errorf("invalid --color flag: %w", err))
+	}
+	var messagePrefixFlag *string
+	if fs.Changed("message-prefix") {
+		messagePrefixFlag = messagePrefixValue
+		SetMessagePrefix(*messagePrefixValue)
 	}
 
 	// The remaining args are for the script and tool
 	remainingArgsForTool := fs.Args()
 
+	// A "--" separator ends the uber flags: the argument after it is the
+	// command and everything following is passed to the tool verbatim
+	afterSeparator := fs.ArgsLenAtDash() == 0
+
+	// The arguments pflag consumed as uber flags, without the separator
+	flagArgs := args[:len(args)-len(remainingArgsForTool)]
+	if afterSeparator {
+		flagArgs = flagArgs[:len(flagArgs)-1]
+	}
+
 	// Find the command. It's the first positional argument that isn't a value
 	// for a preceding flag.
 	commandIndex := -1
-	for i, arg := range remainingArgsForTool {
-		if !strings.HasPrefix(arg, "-") {
-			// If the previous arg was a flag, this is its value, not the command
-			if i > 0 && strings.HasPrefix(remainingArgsForTool[i-1], "-") {
-				continue
+	if afterSeparator {
+		if len(remainingArgsForTool) > 0 {
+			commandIndex = 0
+		}
+	} else {
+		for i, arg := range remainingArgsForTool {
+			if !strings.HasPrefix(arg, "-") {
+				// If the previous arg was a flag, this is its value, not the command
+				if i > 0 && strings.HasPrefix(remainingArgsForTool[i-1], "-") {
+					continue
+				}
+				commandIndex = i
+				break
 			}
-			commandIndex = i
-			break
 		}
 	}
 
 	var command string
 	var toolArgs []string
 
-	if commandIndex != -1 {
+	if *toolFlag != "" {
+		// The tool was named explicitly, so every positional argument is
+		// for it. Unknown flags among the uber flags would be dropped.
+		if flag := firstUnknownFlag(fs, flagArgs); flag != "" {
+			return nil, withKind(ErrUsage, fmt.Errorf("'%s' is not an uber flag; with --tool, pass the tool's arguments after '--' (e.g. 'uber --tool %s -- %s')", flag, *toolFlag, flag))
+		}
+		command = *toolFlag
+		toolArgs = remainingArgsForTool
+	} else if commandIndex != -1 {
 		command = remainingArgsForTool[commandIndex]
 		toolArgs = remainingArgsForTool[commandIndex+1:]
 	}
 
-	// Reconstruct the full string of global arguments passed to the uber command
-	var globalCommandArgs string
-	commandFound := false
-	for _, arg := range args {
-		if arg == command {
-			commandFound = true
-			break
-		}
+	// The global arguments are those before the command, located by its
+	// position rather than by searching for its name, which may also be the
+	// value of an earlier flag
+	globalArgs := args
+	if afterSeparator || *toolFlag != "" {
+		// The global arguments are exactly the uber flags
+		globalArgs = flagArgs
+	} else if commandIndex != -1 {
+		globalArgs = args[:len(flagArgs)+commandIndex]
 	}
-	if commandFound {
-		globalArgsEnd := -1
-		for i, arg := range args {
-			if arg == command {
-				globalArgsEnd = i
+	globalCommandArgs := strings.Join(globalArgs, " ")
+
+	// Joining with spaces loses the boundaries of arguments that are empty
+	// or contain whitespace
+	if *verbose {
+		for _, arg := range globalArgs {
+			if arg == "" || strings.ContainsFunc(arg, unicode.IsSpace) {
+				ColorPrint(ColorYellow, fmt.Sprintf("Warning: UBER_GLOBAL_COMMAND_ARGS can't represent the argument %q and may be inaccurate; reporting commands can read the exact arguments from UBER_ORIGINAL_ARGV\n", arg))
 				break
 			}
 		}
-		if globalArgsEnd != -1 {
-			globalCommandArgs = strings.Join(args[:globalArgsEnd], " ")
-		}
-	} else {
-		globalCommandArgs = strings.Join(args, " ")
 	}
 
 	// Validate command presence
-	if !(*listTools || *showVersion) && command == "" {
-		return nil, fmt.Errorf("missing required positional argument 'command'")
+	if *roots != "" {
+		if command == "" {
+			return nil, withKind(ErrUsage, fmt.Errorf("--roots requires a tool to run"))
+		}
+		if *root != "" || *listTools || *showVersion || *doctor || *printConfig || *explain != "" {
+			return nil, withKind(ErrUsage, fmt.Errorf("--roots can't be combined with --root, --list-tools, --version, --doctor, --print-config or --explain"))
+		}
+	}
+
+	if *replay != "" {
+		if command != "" {
+			return nil, withKind(ErrUsage, fmt.Errorf("--replay does not accept additional arguments: %s", command))
+		}
+		if *root != "" || *roots != "" || *record != "" || *listTools || *showVersion || *doctor || *printConfig || *explain != "" {
+			return nil, withKind(ErrUsage, fmt.Errorf("--replay can't be combined with --root, --roots, --record, --list-tools, --version, --doctor, --print-config or --explain"))
+		}
+	}
+
+	// Without a command, an interactive terminal may offer a tool picker
+	// instead, enabled by --pick or the interactive config option. The error
+	// is kept until the configuration is loaded to decide.
+	var missingCommandErr error
+	if !(*listTools || *showVersion || *doctor || *printConfig || *explain != "" || *replay != "" || *dumpArgs) && command == "" {
+		// Unknown flags are meant for the tool, so if we got one without a
+		// command the user most likely put a tool flag before the command
+		if flag := firstUnknownFlag(fs, args); flag != "" {
+			return nil, withKind(ErrUsage, fmt.Errorf("missing required positional argument 'command': '%s' is not an uber flag; if it is meant for a tool, place it after the tool name (e.g. 'uber <tool> %s')", flag, flag))
+		}
+		missingCommandErr = withKind(ErrUsage, fmt.Errorf("missing required positional argument 'command'"))
+		if !IsTTYStdin() {
+			return nil, missingCommandErr
+		}
+	}
+	// failed reports err, or the missing command if the picker wasn't
+	// requested explicitly
+	failed := func(err error) (*RunContext, error) {
+		if missingCommandErr != nil && !*pick {
+			return nil, missingCommandErr
+		}
+		return nil, err
 	}
 	if *listTools && command != "" {
-		return nil, fmt.Errorf("--list-tools does not accept additional arguments: %s", command)
+		return nil, withKind(ErrUsage, fmt.Errorf("--list-tools does not accept additional arguments: %s", command))
 	}
 	if *showVersion && command != "" {
-		return nil, fmt.Errorf("--version does not accept additional arguments: %s", command)
+		return nil, withKind(ErrUsage, fmt.Errorf("--version does not accept additional arguments: %s", command))
+	}
+	if *doctor && command != "" {
+		return nil, withKind(ErrUsage, fmt.Errorf("--doctor does not accept additional arguments: %s", command))
+	}
+	if *printConfig && command != "" {
+		return nil, withKind(ErrUsage, fmt.Errorf("--print-config does not accept additional arguments: %s", command))
+	}
+	if *explain != "" && command != "" {
+		return nil, withKind(ErrUsage, fmt.Errorf("--explain does not accept additional arguments: %s", command))
+	}
+	if *long && !*listTools {
+		return nil, withKind(ErrUsage, fmt.Errorf("--long can only be used with --list-tools"))
+	}
+	if *jsonOutput && !*showVersion && !*doctor && !*printConfig && *explain == "" && command == "" {
+		return nil, withKind(ErrUsage, fmt.Errorf("--json can only be used with --version, --doctor, --print-config, --explain or a command"))
+	}
+	if *checkVersion && !*showVersion {
+		return nil, withKind(ErrUsage, fmt.Errorf("--check can only be used with --version"))
+	}
+	if *rootLevel < 0 {
+		return nil, withKind(ErrUsage, fmt.Errorf("invalid --root-level %d: must not be negative", *rootLevel))
+	}
+	if *rootLevel > 0 && (*roots != "" || *replay != "") {
+		return nil, withKind(ErrUsage, fmt.Errorf("--root-level can't be combined with --roots or --replay"))
+	}
+	if *dirScanTimeout < 0 {
+		return nil, withKind(ErrUsage, fmt.Errorf("invalid --dir-scan-timeout %s: must not be negative", *dirScanTimeout))
+	}
+	if fs.Changed("benchmark") {
+		if *benchmark < 1 {
+			return nil, withKind(ErrUsage, fmt.Errorf("--benchmark requires a positive number of runs, got %d", *benchmark))
+		}
+		if *detach || *usePTY || *roots != "" || *replay != "" || *listTools || *showVersion || *doctor || *printConfig || *explain != "" {
+			return nil, withKind(ErrUsage, fmt.Errorf("--benchmark can't be combined with --detach, --pty, --roots, --replay, --list-tools, --version, --doctor, --print-config or --explain"))
+		}
+	}
+
+	if *detach && *isolateTmp {
+		return nil, withKind(ErrUsage, fmt.Errorf("--isolate-tmp can't be combined with --detach, since the directory would be removed while the tool runs"))
+	}
+
+	var niceFlag *int
+	if fs.Changed("nice") {
+		if *nice < config.MinNice || *nice > config.MaxNice {
+			return nil, withKind(ErrUsage, fmt.Errorf("invalid --nice %d: must be between %d and %d", *nice, config.MinNice, config.MaxNice))
+		}
+		niceFlag = nice
+	}
+	if *envDiff {
+		if command == "" {
+			return nil, withKind(ErrUsage, fmt.Errorf("--env-diff requires a tool, e.g. uber --env-diff build"))
+		}
+		if *bare || *detach || *usePTY || *roots != "" || *replay != "" || fs.Changed("benchmark") {
+			return nil, withKind(ErrUsage, fmt.Errorf("--env-diff can't be combined with --bare, --detach, --pty, --roots, --replay or --benchmark"))
+		}
+	}
+
+	ctx := &RunContext{
+		UberBinPath:       binPath,
+		Verbose:           *verbose,
+		ListTools:         *listTools,
+		Pick:              missingCommandErr != nil,
+		Long:              *long,
+		NoToolCache:       *noToolCache,
+		ShowVersion:       *showVersion,
+		CheckVersion:      *checkVersion,
+		IsolateTmp:        *isolateTmp,
+		NoReporting:       *noReporting,
+		PTY:               *usePTY,
+		Detach:            *detach,
+		KeepGoing:         *keepGoing,
+		Benchmark:         *benchmark,
+		DirScanTimeout:    *dirScanTimeout,
+		Bare:              *bare,
+		EnvDiff:           *envDiff,
+		StrictMatch:       *strictMatch,
+		Nice:              niceFlag,
+		Repro:             *repro,
+		Record:            *record,
+		Replay:            *replay,
+		Trace:             *trace || *traceFile != "",
+		TraceFile:         *traceFile,
+		Profile:           *profile,
+		Doctor:            *doctor,
+		PrintConfig:       *printConfig,
+		DumpArgs:          *dumpArgs,
+		Explain:           *explain,
+		JSON:              *jsonOutput,
+		Command:           command,
+		RemainingArgs:     toolArgs,
+		GlobalCommandArgs: globalCommandArgs,
+		wrapFlag:          *wrap,
+		messagePrefixFlag: messagePrefixFlag,
+	}
+
+	// Dumping the parsed arguments doesn't need a project
+	if *dumpArgs {
+		return ctx, nil
+	}
+
+	// A replayed tool runs without the project configuration
+	if *replay != "" {
+		return ctx, nil
+	}
+
+	// With --roots, each root's configuration is loaded when the tool runs
+	// in it
+	if *roots != "" {
+		for _, projectRoot := range strings.Split(*roots, ",") {
+			if projectRoot = strings.TrimSpace(projectRoot); projectRoot != "" {
+				ctx.Roots = append(ctx.Roots, projectRoot)
+			}
+		}
+		if len(ctx.Roots) == 0 {
+			return nil, withKind(ErrUsage, fmt.Errorf("--roots requires at least one root"))
+		}
+		return ctx, nil
 	}
 
 	// Validate project root
 	projectRoot := *root
 	if projectRoot != "" {
 		if err := validateProjectRoot(projectRoot); err != nil {
-			return nil, fmt.Errorf("invalid --root flag: %w", err)
+			return failed(withKind(ErrConfig, fmt.Errorf("invalid --root flag: %w", err)))
 		}
+	} else if envRoot := os.Getenv(rootEnvVar); envRoot != "" {
+		// Pins the root for automation that doesn't run inside the project
+		if err := validateProjectRoot(envRoot); err != nil {
+			return failed(withKind(ErrConfig, fmt.Errorf("invalid %s environment variable: %w", rootEnvVar, err)))
+		}
+		projectRoot = envRoot
 	} else {
-		foundRoot, err := findProjectRoot()
+		marker := rootMarker(*rootMarkerFlag)
+		foundRoot, err := findProjectRoot(marker)
 		if err != nil {
-			return nil, fmt.Errorf("failed to find project root: %w", err)
+			return failed(withKind(ErrConfig, fmt.Errorf("failed to find project root: %w", err)))
+		}
+		// A root found by another marker must still hold the configuration
+		if marker != defaultRootMarker {
+			if info, err := os.Stat(filepath.Join(foundRoot, ".uber")); err != nil || info.IsDir() {
+				return failed(withKind(ErrConfig, fmt.Errorf("project root '%s' found by marker '%s' does not contain a .uber file", foundRoot, marker)))
+			}
 		}
 		projectRoot = foundRoot
 	}
 
+	// Step out of nested projects to an enclosing one
+	if *rootLevel > 0 {
+		ancestorRoot, err := findAncestorRoot(projectRoot, *rootLevel)
+		if err != nil {
+			return failed(withKind(ErrConfig, err))
+		}
+		if *verbose {
+			ColorPrint(ColorCyan, fmt.Sprintf("Using project root %s, %d level(s) above %s\n", ancestorRoot, *rootLevel, projectRoot))
+		}
+		projectRoot = ancestorRoot
+	}
+
+	if err := ctx.loadProject(projectRoot); err != nil {
+		// "uber edit" must still open a .uber file that doesn't load so
+		// that it can be fixed, and --doctor reports it as a failed check
+		editing := command == editCommand && len(toolArgs) == 0
+		if !(editing || *doctor) || errors.Is(err, ErrUsage) {
+			return failed(err)
+		}
+		if *doctor {
+			ctx.configErr = err
+		} else {
+			ColorPrintWarning(fmt.Sprintf("Warning: %v\n", err))
+		}
+		if ctx.Root, err = filepath.EvalSymlinks(projectRoot); err != nil {
+			return nil, withKind(ErrConfig, fmt.Errorf("failed to evaluate symlinks for project root: %w", err))
+		}
+		ctx.Config = &config.Config{}
+	}
+	if missingCommandErr != nil && !*pick && !ctx.Config.Interactive {
+		return nil, missingCommandErr
+	}
+
+	return ctx, nil
+}
+
+// loadProject sets the project root of ctx and loads its configuration,
+// along with the settings derived from it.
+func (ctx *RunContext) loadProject(projectRoot string) error {
 	// Normalize the path to handle symlinks (important on macOS)
 	projectRoot, err := filepath.EvalSymlinks(projectRoot)
 	if err != nil {
-		return nil, fmt.Errorf("failed to evaluate symlinks for project root: %w", err)
+		return withKind(ErrConfig, fmt.Errorf("failed to evaluate symlinks for project root: %w", err))
 	}
 
 	// Load config
 	config, err := config.LoadFromFile(projectRoot)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load configuration: %w", err)
+		return withKind(ErrConfig, fmt.Errorf("failed to load configuration: %w", err))
+	}
+	// The --message-prefix flag takes precedence over message_prefix
+	if ctx.messagePrefixFlag == nil {
+		SetMessagePrefix(config.MessagePrefix)
+	}
+	for _, warning := range config.Warnings {
+		ColorPrintWarning(fmt.Sprintf("Warning: %s\n", warning))
 	}
 
-	return &RunContext{
-		Root:              projectRoot,
-		UberBinPath:       binPath,
-		Verbose:           *verbose,
-		ListTools:         *listTools,
-		ShowVersion:       *showVersion,
-		Command:           command,
-		RemainingArgs:     toolArgs,
-		GlobalCommandArgs: globalCommandArgs,
-		Config:            config,
-	}, nil
+	// The --wrap flag takes precedence over the exec_wrapper config option
+	var wrapper []string
+	if ctx.wrapFlag != "" {
+		wrapper, err = splitShellWords(ctx.wrapFlag)
+		if err != nil {
+			return withKind(ErrUsage, fmt.Errorf("invalid --wrap flag: %w", err))
+		}
+	} else if config.ExecWrapper != "" {
+		wrapper, err = splitShellWords(config.ExecWrapper)
+		if err != nil {
+			return withKind(ErrConfig, fmt.Errorf("invalid exec_wrapper: %w", err))
+		}
+	}
+
+	forwardSignals, err := parseSignals(config.ForwardSignals)
+	if err != nil {
+		return withKind(ErrConfig, fmt.Errorf("invalid forward_signals: %w", err))
+	}
+
+	// uber_bin_path pins UBER_BIN_PATH to the canonical uber binary
+	if config.UberBinPath != "" {
+		ctx.UberBinPath = config.UberBinPath
+		if !filepath.IsAbs(ctx.UberBinPath) {
+			ctx.UberBinPath = filepath.Join(projectRoot, ctx.UberBinPath)
+		}
+	}
+
+	ctx.Root = projectRoot
+	ctx.Config = config
+	ctx.Wrapper = wrapper
+	ctx.ForwardSignals = forwardSignals
+	return nil
 }
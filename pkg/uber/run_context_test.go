@@ -1,6 +1,7 @@
 package uber
 
 import (
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -120,6 +121,106 @@ func TestParseArgs(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "separator before command",
+			args: []string{"-v", "--root", "/tmp", "--", "start", "--version", "--root"},
+			want: &RunContext{
+				Root:              "/tmp",
+				UberBinPath:       "/dummy/bin/path",
+				Verbose:           true,
+				Command:           "start",
+				RemainingArgs:     []string{"--version", "--root"},
+				GlobalCommandArgs: "-v --root /tmp",
+				Config: &config.Config{
+					ToolPaths: []string{"/usr/local/bin", "bin", "tools", "/opt/tools", "./scripts"},
+				},
+			},
+			wantErr: false,
+			setup: func() (string, func()) {
+				return createTempDirWithUberFile(t, "uber-test-separator")
+			},
+		},
+		{
+			name: "separator after command",
+			args: []string{"--root", "/tmp", "start", "--", "--version"},
+			want: &RunContext{
+				Root:              "/tmp",
+				UberBinPath:       "/dummy/bin/path",
+				Command:           "start",
+				RemainingArgs:     []string{"--", "--version"},
+				GlobalCommandArgs: "--root /tmp",
+				Config: &config.Config{
+					ToolPaths: []string{"/usr/local/bin", "bin", "tools", "/opt/tools", "./scripts"},
+				},
+			},
+			wantErr: false,
+			setup: func() (string, func()) {
+				return createTempDirWithUberFile(t, "uber-test-separator-after")
+			},
+		},
+		{
+			name:    "separator without command",
+			args:    []string{"--root", "/tmp", "--"},
+			want:    nil,
+			wantErr: true,
+			setup: func() (string, func()) {
+				return "/tmp", func() {}
+			},
+		},
+		{
+			name: "explicit tool",
+			args: []string{"--root", "/tmp", "--tool", "-weird", "--", "--version", "start"},
+			want: &RunContext{
+				Root:              "/tmp",
+				UberBinPath:       "/dummy/bin/path",
+				Command:           "-weird",
+				RemainingArgs:     []string{"--version", "start"},
+				GlobalCommandArgs: "--root /tmp --tool -weird",
+				Config: &config.Config{
+					ToolPaths: []string{"/usr/local/bin", "bin", "tools", "/opt/tools", "./scripts"},
+				},
+			},
+			wantErr: false,
+			setup: func() (string, func()) {
+				return createTempDirWithUberFile(t, "uber-test-explicit-tool")
+			},
+		},
+		{
+			name:    "explicit tool with unknown flag",
+			args:    []string{"--root", "/tmp", "--tool", "deploy", "--force"},
+			want:    nil,
+			wantErr: true,
+			setup: func() (string, func()) {
+				return createTempDirWithUberFile(t, "uber-test-explicit-tool-flag")
+			},
+		},
+		{
+			name:    "benchmark without runs",
+			args:    []string{"--root", "/tmp", "--benchmark", "0", "build"},
+			want:    nil,
+			wantErr: true,
+			setup: func() (string, func()) {
+				return createTempDirWithUberFile(t, "uber-test-benchmark-zero")
+			},
+		},
+		{
+			name:    "benchmark with detach",
+			args:    []string{"--root", "/tmp", "--benchmark", "5", "--detach", "build"},
+			want:    nil,
+			wantErr: true,
+			setup: func() (string, func()) {
+				return createTempDirWithUberFile(t, "uber-test-benchmark-detach")
+			},
+		},
+		{
+			name:    "isolate tmp with detach",
+			args:    []string{"--root", "/tmp", "--isolate-tmp", "--detach", "serve"},
+			want:    nil,
+			wantErr: true,
+			setup: func() (string, func()) {
+				return createTempDirWithUberFile(t, "uber-test-isolate-tmp-detach")
+			},
+		},
 		{
 			name:    "missing command",
 			args:    []string{"-v", "--root", "/tmp"},
@@ -185,6 +286,127 @@ func TestParseArgs(t *testing.T) {
 	}
 }
 
+func TestParseArgsRootEnv(t *testing.T) {
+	envRoot, cleanup := createTempDirWithUberFile(t, "uber-test-root-env")
+	defer cleanup()
+	flagRoot, cleanupFlagRoot := createTempDirWithUberFile(t, "uber-test-root-env-flag")
+	defer cleanupFlagRoot()
+
+	// Start outside of any project so that only UBER_ROOT can find one
+	outside, err := os.MkdirTemp("", "uber-test-root-env-outside")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(outside)
+	t.Chdir(outside)
+
+	t.Setenv("UBER_ROOT", envRoot)
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if want, _ := filepath.EvalSymlinks(envRoot); ctx.Root != want {
+		t.Errorf("Expected root %s from UBER_ROOT, got %s", want, ctx.Root)
+	}
+
+	// --root takes precedence
+	ctx, err = ParseArgs("/dummy/bin/path", []string{"--root", flagRoot, "build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if want, _ := filepath.EvalSymlinks(flagRoot); ctx.Root != want {
+		t.Errorf("Expected root %s from --root, got %s", want, ctx.Root)
+	}
+
+	// A root without a .uber file is rejected like --root
+	t.Setenv("UBER_ROOT", outside)
+	_, err = ParseArgs("/dummy/bin/path", []string{"build"}, io.Discard)
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "UBER_ROOT") {
+		t.Errorf("Expected a configuration error naming UBER_ROOT, got: %v", err)
+	}
+}
+
+func TestParseArgsMessagePrefix(t *testing.T) {
+	defer SetMessagePrefix("")
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-message-prefix")
+	defer cleanup()
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(`message_prefix = "[config] "`), 0644); err != nil {
+		t.Fatalf("Failed to write .uber file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "from the configuration", args: []string{"--root", tempDir, "build"}, want: "[config] "},
+		{name: "flag overrides the configuration", args: []string{"--root", tempDir, "--message-prefix", "[flag] ", "build"}, want: "[flag] "},
+		{name: "empty flag disables the prefix", args: []string{"--root", tempDir, "--message-prefix=", "build"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMessagePrefix("")
+			if _, err := ParseArgs("/dummy/bin/path", tt.args, io.Discard); err != nil {
+				t.Fatalf("ParseArgs failed: %v", err)
+			}
+			if messagePrefix != tt.want {
+				t.Errorf("Expected message prefix %q, got %q", tt.want, messagePrefix)
+			}
+		})
+	}
+}
+
+func TestParseArgsGlobalCommandArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantGlobal  string
+		wantWarning bool
+	}{
+		{
+			name:       "command name is also a flag value",
+			args:       []string{"--dump-args", "-v", "--name", "start", "start", "foo"},
+			wantGlobal: "--dump-args -v --name start",
+		},
+		{
+			name:        "argument with whitespace",
+			args:        []string{"--dump-args", "-v", "--msg", "a b", "start"},
+			wantGlobal:  "--dump-args -v --msg a b",
+			wantWarning: true,
+		},
+		{
+			name:       "whitespace in the tool's arguments",
+			args:       []string{"--dump-args", "-v", "start", "a b"},
+			wantGlobal: "--dump-args -v",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			ctx, err := ParseArgs("/dummy/bin/path", tt.args, io.Discard)
+			w.Close()
+			os.Stdout = oldStdout
+			output, _ := io.ReadAll(r)
+
+			if err != nil {
+				t.Fatalf("ParseArgs failed: %v", err)
+			}
+			if ctx.Command != "start" {
+				t.Errorf("Expected command 'start', got '%s'", ctx.Command)
+			}
+			if ctx.GlobalCommandArgs != tt.wantGlobal {
+				t.Errorf("GlobalCommandArgs = %q, want %q", ctx.GlobalCommandArgs, tt.wantGlobal)
+			}
+			if gotWarning := strings.Contains(string(output), "UBER_GLOBAL_COMMAND_ARGS can't represent"); gotWarning != tt.wantWarning {
+				t.Errorf("Expected warning %v, got output %q", tt.wantWarning, string(output))
+			}
+		})
+	}
+}
+
 func TestParseArgsWithAutoRoot(t *testing.T) {
 	// Create a temporary directory structure for testing
 	tempDir, err := os.MkdirTemp("", "uber-test-parse")
@@ -336,7 +558,7 @@ func TestFindProjectRoot(t *testing.T) {
 	defer os.Chdir(originalWd)
 
 	// Find project root
-	foundRoot, err := findProjectRoot()
+	foundRoot, err := findProjectRoot(defaultRootMarker)
 	if err != nil {
 		t.Fatalf("findProjectRoot failed: %v", err)
 	}
@@ -377,7 +599,7 @@ func TestFindProjectRootNotFound(t *testing.T) {
 	defer os.Chdir(originalWd)
 
 	// Try to find project root
-	_, err = findProjectRoot()
+	_, err = findProjectRoot(defaultRootMarker)
 	if err == nil {
 		t.Error("Expected error when no .uber file is found, but got nil")
 	}
@@ -426,6 +648,30 @@ func TestValidateProjectRoot(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:     ".uber is a directory",
+			rootPath: "/tmp",
+			setup: func() (string, func()) {
+				tempDir, err := os.MkdirTemp("", "uber-test-uber-dir")
+				if err != nil {
+					t.Fatalf("Failed to create temp directory: %v", err)
+				}
+				if err := os.Mkdir(filepath.Join(tempDir, ".uber"), 0755); err != nil {
+					t.Fatalf("Failed to create .uber directory: %v", err)
+				}
+				return tempDir, func() { os.RemoveAll(tempDir) }
+			},
+			wantErr: true,
+		},
+		{
+			name:     "root points at a file",
+			rootPath: "/tmp",
+			setup: func() (string, func()) {
+				tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-root-file")
+				return filepath.Join(tempDir, ".uber"), cleanup
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -459,7 +705,346 @@ func TestValidateProjectRoot(t *testing.T) {
 					if err.Error() != "specified root directory does not contain a .uber file" {
 						t.Errorf("Expected error about missing .uber file, got: %v", err)
 					}
+				} else if tt.name == ".uber is a directory" {
+					if !strings.Contains(err.Error(), "to be a file but found a directory") {
+						t.Errorf("Expected error about .uber being a directory, got: %v", err)
+					}
+				} else if tt.name == "root points at a file" {
+					if !strings.Contains(err.Error(), "is a file, not a directory") {
+						t.Errorf("Expected error about the root being a file, got: %v", err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseArgsVersionCheck(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-version-check")
+	defer cleanup()
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--version", "--check"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if !ctx.ShowVersion || !ctx.CheckVersion {
+		t.Errorf("Expected ShowVersion and CheckVersion to be set, got %v and %v", ctx.ShowVersion, ctx.CheckVersion)
+	}
+
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--check"}, io.Discard)
+	if err == nil {
+		t.Error("Expected error when --check is used without --version, but got nil")
+	}
+}
+
+func TestParseArgsFlagWithoutCommand(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-flag-without-command")
+	defer cleanup()
+
+	_, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--porT", "8080"}, io.Discard)
+	if err == nil {
+		t.Fatal("Expected error when only tool flags are given, but got nil")
+	}
+	if !strings.Contains(err.Error(), "'--porT' is not an uber flag") {
+		t.Errorf("Expected error to point at the misplaced flag, got '%s'", err.Error())
+	}
+	if !strings.Contains(err.Error(), "uber <tool> --porT") {
+		t.Errorf("Expected error to suggest placing the flag after the tool, got '%s'", err.Error())
+	}
+
+	// Without any arguments the generic error is kept
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir}, io.Discard)
+	if err == nil || err.Error() != "missing required positional argument 'command'" {
+		t.Errorf("Expected generic missing command error, got '%v'", err)
+	}
+}
+
+func TestParseArgsWrap(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-wrap")
+	defer cleanup()
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--wrap", "strace -o '/tmp/my trace'", "build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if want := []string{"strace", "-o", "/tmp/my trace"}; !reflect.DeepEqual(ctx.Wrapper, want) {
+		t.Errorf("Wrapper = %q, want %q", ctx.Wrapper, want)
+	}
+	if ctx.Command != "build" {
+		t.Errorf("Command = '%s', want 'build'", ctx.Command)
+	}
+
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--wrap", "time 'oops", "build"}, io.Discard)
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for an unterminated quote, got: %v", err)
+	}
+
+	// The flag overrides exec_wrapper from the config
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(`exec_wrapper = "time -p"`), 0644); err != nil {
+		t.Fatalf("Failed to write .uber file: %v", err)
+	}
+	ctx, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if want := []string{"time", "-p"}; !reflect.DeepEqual(ctx.Wrapper, want) {
+		t.Errorf("Wrapper = %q, want %q", ctx.Wrapper, want)
+	}
+	ctx, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--wrap", "perf stat --", "build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if want := []string{"perf", "stat", "--"}; !reflect.DeepEqual(ctx.Wrapper, want) {
+		t.Errorf("Wrapper = %q, want %q", ctx.Wrapper, want)
+	}
+}
+
+func TestParseArgsRootMarker(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-root-marker")
+	defer cleanup()
+	tempDir, err := filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to evaluate symlinks: %v", err)
+	}
+
+	// The outer directory holds the marker, a nested one has its own .uber
+	if err := os.WriteFile(filepath.Join(tempDir, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatalf("Failed to create marker: %v", err)
+	}
+	nestedDir := filepath.Join(tempDir, "nested")
+	workDir := filepath.Join(nestedDir, "deeper")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(nestedDir, ".uber"), []byte(`tool_paths = ["bin"]`), 0644); err != nil {
+		t.Fatalf("Failed to create .uber file: %v", err)
+	}
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get original working directory: %v", err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if ctx.Root != nestedDir {
+		t.Errorf("Expected default marker to find %s, got %s", nestedDir, ctx.Root)
+	}
+
+	ctx, err = ParseArgs("/dummy/bin/path", []string{"--root-marker", "WORKSPACE", "build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if ctx.Root != tempDir {
+		t.Errorf("Expected --root-marker to find %s, got %s", tempDir, ctx.Root)
+	}
+
+	t.Setenv("UBER_ROOT_MARKER", "WORKSPACE")
+	ctx, err = ParseArgs("/dummy/bin/path", []string{"build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if ctx.Root != tempDir {
+		t.Errorf("Expected UBER_ROOT_MARKER to find %s, got %s", tempDir, ctx.Root)
+	}
+
+	// The root found by the marker must contain a .uber file
+	if err := os.Remove(filepath.Join(tempDir, ".uber")); err != nil {
+		t.Fatalf("Failed to remove .uber file: %v", err)
+	}
+	_, err = ParseArgs("/dummy/bin/path", []string{"build"}, io.Discard)
+	if !errors.Is(err, ErrConfig) {
+		t.Fatalf("Expected ErrConfig when the marked root has no .uber file, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "found by marker 'WORKSPACE' does not contain a .uber file") {
+		t.Errorf("Expected a clear error about the missing .uber file, got: %v", err)
+	}
+}
+
+func TestParseArgsDoctor(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-doctor-args")
+	defer cleanup()
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--doctor", "--json"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if !ctx.Doctor || !ctx.JSON {
+		t.Errorf("Expected Doctor and JSON to be set, got %v and %v", ctx.Doctor, ctx.JSON)
+	}
+
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--json", "--list-tools"}, io.Discard)
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for --json without --doctor, got: %v", err)
+	}
+
+	// A command may report a missing tool as JSON
+	ctx, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--json", "build"}, io.Discard)
+	if err != nil || !ctx.JSON {
+		t.Errorf("Expected --json to be accepted with a command, got: %v", err)
+	}
+
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--doctor", "build"}, io.Discard)
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for --doctor with a command, got: %v", err)
+	}
+}
+
+func TestParseArgsPrintConfig(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-print-config-args")
+	defer cleanup()
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--print-config", "--json"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if !ctx.PrintConfig || !ctx.JSON {
+		t.Errorf("Expected PrintConfig and JSON to be set, got %v and %v", ctx.PrintConfig, ctx.JSON)
+	}
+
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--print-config", "build"}, io.Discard)
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for --print-config with a command, got: %v", err)
+	}
+}
+
+func TestParseArgsListToolsLong(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-list-long-args")
+	defer cleanup()
+
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--list-tools", "--long"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if !ctx.ListTools || !ctx.Long {
+		t.Errorf("Expected ListTools and Long to be set, got %v and %v", ctx.ListTools, ctx.Long)
+	}
+
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--long", "build"}, io.Discard)
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for --long without --list-tools, got: %v", err)
+	}
+}
+
+func TestFindProjectRootUberDirectory(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "uber-test-find-uber-dir")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.Mkdir(filepath.Join(tempDir, ".uber"), 0755); err != nil {
+		t.Fatalf("Failed to create .uber directory: %v", err)
+	}
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get original working directory: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	_, err = findProjectRoot(defaultRootMarker)
+	if err == nil {
+		t.Fatal("Expected error when .uber is a directory, but got nil")
+	}
+	if !strings.Contains(err.Error(), "to be a file but found a directory") {
+		t.Errorf("Expected a clear error about the directory, got '%s'", err.Error())
+	}
+}
+
+func TestParseArgsForwardSignals(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-forward-signals-args")
+	defer cleanup()
+
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(`forward_signals = ["SIGHUP", "SIGTERM"]`), 0644); err != nil {
+		t.Fatalf("Failed to write .uber file: %v", err)
+	}
+	ctx, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "build"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if len(ctx.ForwardSignals) != 2 {
+		t.Errorf("Expected 2 forwarded signals, got %v", ctx.ForwardSignals)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, ".uber"), []byte(`forward_signals = ["SIGNOPE"]`), 0644); err != nil {
+		t.Fatalf("Failed to write .uber file: %v", err)
+	}
+	_, err = ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "build"}, io.Discard)
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "SIGNOPE") {
+		t.Errorf("Expected ErrConfig naming the unknown signal, got: %v", err)
+	}
+}
+
+func TestParseArgsColor(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-color-args")
+	defer cleanup()
+	defer SetColorMode(ColorModeAuto)
+
+	if _, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--color=never", "build"}, io.Discard); err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if colorMode != ColorModeNever {
+		t.Errorf("Expected color mode 'never', got '%s'", colorMode)
+	}
+
+	_, err := ParseArgs("/dummy/bin/path", []string{"--root", tempDir, "--color=rainbow", "build"}, io.Discard)
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for an invalid --color value, got: %v", err)
+	}
+}
+
+func TestParseArgsRootLevel(t *testing.T) {
+	outer, cleanup := createTempDirWithUberFile(t, "uber-test-root-level")
+	defer cleanup()
+	outer, _ = filepath.EvalSymlinks(outer)
+
+	// outer/.uber encloses outer/sub/inner/.uber, with no project in between
+	inner := filepath.Join(outer, "sub", "inner")
+	if err := os.MkdirAll(inner, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(inner, ".uber"), []byte("tool_paths = [\"bin\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .uber file: %v", err)
+	}
+	t.Chdir(inner)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantRoot string
+		wantErr  error
+	}{
+		{name: "nearest root", args: []string{"build"}, wantRoot: inner},
+		{name: "parent project", args: []string{"--root-level", "1", "build"}, wantRoot: outer},
+		{name: "relative to --root", args: []string{"--root", inner, "--root-level", "1", "build"}, wantRoot: outer},
+		{name: "too many levels", args: []string{"--root-level", "2", "build"}, wantErr: ErrConfig},
+		{name: "negative", args: []string{"--root-level", "-1", "build"}, wantErr: ErrUsage},
+		{name: "with --roots", args: []string{"--roots", inner, "--root-level", "1", "build"}, wantErr: ErrUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := ParseArgs("/dummy/bin/path", tt.args, io.Discard)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got: %v", tt.wantErr, err)
 				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArgs failed: %v", err)
+			}
+			if ctx.Root != tt.wantRoot {
+				t.Errorf("Expected root %s, got %s", tt.wantRoot, ctx.Root)
 			}
 		})
 	}
@@ -0,0 +1,136 @@
+package uber
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version. Build metadata is ignored since it
+// doesn't affect precedence.
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+// parseSemver parses a version like "1.2.3", "v1.2.3" or "1.2.3-rc.1+build".
+// Missing minor and patch components default to zero.
+func parseSemver(version string) (semver, error) {
+	var v semver
+
+	s := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		if s[i+1:] == "" {
+			return v, fmt.Errorf("invalid version '%s': empty pre-release", version)
+		}
+		v.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version '%s'", version)
+	}
+
+	numbers := []*int{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version '%s'", version)
+		}
+		*numbers[i] = n
+	}
+
+	return v, nil
+}
+
+// compareSemver compares two versions and returns -1, 0 or 1 if a is older
+// than, equal to or newer than b.
+func compareSemver(a, b string) (int, error) {
+	va, err := parseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pair := range [][2]int{{va.major, vb.major}, {va.minor, vb.minor}, {va.patch, vb.patch}} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c, nil
+		}
+	}
+
+	return comparePrerelease(va.prerelease, vb.prerelease), nil
+}
+
+// comparePrerelease compares pre-release identifiers following the semver
+// precedence rules. A version without a pre-release is newer than one with.
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if c := compareInts(na, nb); c != 0 {
+				return c
+			}
+		case errA == nil:
+			// Numeric identifiers have lower precedence than alphanumeric ones
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return compareInts(len(a), len(b))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// checkMinVersion returns an error if version is older than minVersion. The
+// check is skipped with a warning for development builds.
+func checkMinVersion(version, minVersion string) error {
+	if minVersion == "" {
+		return nil
+	}
+	if version == "dev" {
+		ColorPrintWarning(fmt.Sprintf("Warning: skipping minimum version check (%s) for a development build\n", minVersion))
+		return nil
+	}
+
+	c, err := compareSemver(version, minVersion)
+	if err != nil {
+		return fmt.Errorf("failed to compare versions: %w", err)
+	}
+	if c < 0 {
+		return fmt.Errorf("uber version %s is older than the minimum version %s required by this project", version, minVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package uber
+
+import (
+	"testing"
+)
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "1.2.3", b: "1.2.3", want: 0},
+		{a: "v1.2.3", b: "1.2.3", want: 0},
+		{a: "1.2.3", b: "1.2.4", want: -1},
+		{a: "1.10.0", b: "1.9.0", want: 1},
+		{a: "2.0.0", b: "1.99.99", want: 1},
+		{a: "1.2", b: "1.2.0", want: 0},
+		{a: "1.2.3+build.5", b: "1.2.3", want: 0},
+		{a: "1.0.0-rc.1", b: "1.0.0", want: -1},
+		{a: "1.0.0", b: "1.0.0-rc.1", want: 1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{a: "1.0.0-alpha.1", b: "1.0.0-alpha.beta", want: -1},
+		{a: "1.0.0-beta.2", b: "1.0.0-beta.11", want: -1},
+		{a: "1.0.0-rc.1", b: "1.0.0-beta", want: 1},
+		{a: "dev", b: "1.0.0", wantErr: true},
+		{a: "1.0.0", b: "1.x", wantErr: true},
+		{a: "1.0.0-", b: "1.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			got, err := compareSemver(tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compareSemver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("compareSemver(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMinVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		minVersion string
+		wantErr    bool
+	}{
+		{name: "no minimum configured", version: "1.0.0", minVersion: "", wantErr: false},
+		{name: "newer than minimum", version: "1.3.0", minVersion: "1.2.0", wantErr: false},
+		{name: "equal to minimum", version: "v1.2.0", minVersion: "1.2.0", wantErr: false},
+		{name: "older than minimum", version: "1.1.9", minVersion: "1.2.0", wantErr: true},
+		{name: "pre-release of minimum", version: "1.2.0-rc.1", minVersion: "1.2.0", wantErr: true},
+		{name: "dev build is skipped", version: "dev", minVersion: "99.0.0", wantErr: false},
+		{name: "invalid minimum", version: "1.0.0", minVersion: "latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMinVersion(tt.version, tt.minVersion)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkMinVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
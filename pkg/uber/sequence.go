@@ -0,0 +1,137 @@
+package uber
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// sequenceStep is a resolved step of a sequence, ready to run
+type sequenceStep struct {
+	name           string
+	toolPath       string
+	executablePath string
+	toolConfig     config.ToolConfig
+	args           []string
+}
+
+// RunCommand runs the sequence named command if the configuration defines
+// one and the tool otherwise.
+func (te *ToolExecutor) RunCommand(ctx context.Context, command string, args []string) error {
+	if _, ok := te.ctx.Config.Sequences[command]; ok {
+		return te.RunSequence(ctx, command, args)
+	}
+	if te.ctx.KeepGoing {
+		return withKind(ErrUsage, fmt.Errorf("--keep-going can only be used with a sequence, and '%s' is not one", command))
+	}
+	return te.FindAndExecuteTool(ctx, command, args)
+}
+
+// RunSequence runs the tools listed for the sequence name in order, stopping
+// at the first failure unless --keep-going was given. The env setup script
+// runs once and every step gets the same environment. The reporting command
+// runs once for the whole sequence with the result of each step.
+func (te *ToolExecutor) RunSequence(ctx context.Context, name string, args []string) error {
+	if len(args) > 0 {
+		return withKind(ErrUsage, fmt.Errorf("sequence '%s' does not accept arguments", name))
+	}
+	if te.ctx.Detach {
+		return withKind(ErrUsage, fmt.Errorf("--detach can't be used with sequence '%s'", name))
+	}
+
+	// Resolve every step up front so that a missing tool is reported before
+	// any of them runs
+	var steps []sequenceStep
+	var findToolMs int64
+	for _, stepName := range te.ctx.Config.Sequences[name] {
+		stepArgs, err := te.prepareTool(stepName, nil)
+		if err != nil {
+			return fmt.Errorf("step '%s' of sequence '%s': %w", stepName, name, err)
+		}
+		findToolMs += te.ctx.TimeFindToolMs
+		steps = append(steps, sequenceStep{
+			name:           stepName,
+			toolPath:       te.ctx.FoundToolPath,
+			executablePath: te.ctx.ExecutablePath,
+			toolConfig:     te.ctx.ToolConfig,
+			args:           stepArgs,
+		})
+	}
+	te.ctx.TimeFindToolMs = findToolMs
+
+	// The env setup script and reporting command see the sequence rather
+	// than one of its tools
+	te.ctx.FoundToolPath = ""
+	te.ctx.ExecutablePath = ""
+	te.ctx.ToolConfig = config.ToolConfig{}
+	te.ctx.RemainingArgs = nil
+
+	cleanup, err := te.createTmpDir()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	env, err := te.setupEnvironment(ctx)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	results := make([]string, 0, len(steps))
+	execStart := time.Now()
+	for i, step := range steps {
+		if (firstErr != nil && !te.ctx.KeepGoing) || ctx.Err() != nil {
+			results = append(results, step.name+"=skipped")
+			continue
+		}
+
+		ColorPrintStatus(ColorCyan, fmt.Sprintf("==> %s (%d/%d)\n", step.name, i+1, len(steps)))
+		te.ctx.FoundToolPath = step.toolPath
+		te.ctx.ExecutablePath = step.executablePath
+		te.ctx.ToolConfig = step.toolConfig
+		err := te.checkRequiredEnv(step.name, env)
+		if err == nil {
+			err = te.checkHealth(ctx, step.name, env)
+		}
+		if err == nil {
+			err = te.executeTool(ctx, step.executablePath, step.args, env)
+		}
+		results = append(results, fmt.Sprintf("%s=%d", step.name, ExitCode(err)))
+		if err != nil {
+			// Without --keep-going the failure ends the sequence and is
+			// reported by the caller
+			if te.ctx.KeepGoing {
+				ColorPrintError(fmt.Sprintf("error: step '%s' failed: %v\n", step.name, err))
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("step '%s' of sequence '%s' failed: %w", step.name, name, err)
+			}
+		}
+	}
+	te.ctx.TimeExecToolMs = time.Since(execStart).Milliseconds()
+	te.ctx.FoundToolPath = ""
+	te.ctx.ExecutablePath = ""
+	te.ctx.ToolConfig = config.ToolConfig{}
+	te.ctx.ToolExitCode = ExitCode(firstErr)
+	te.ctx.SequenceResults = results
+
+	te.report(ctx, firstErr)
+	return firstErr
+}
+
+// sequenceEnvironment returns the variables that describe a running
+// sequence, if there is one.
+func (te *ToolExecutor) sequenceEnvironment() []string {
+	if _, ok := te.ctx.Config.Sequences[te.ctx.Command]; !ok {
+		return nil
+	}
+	env := []string{fmt.Sprintf("UBER_SEQUENCE=%s", te.ctx.Command)}
+	if te.ctx.SequenceResults != nil {
+		env = append(env, fmt.Sprintf("UBER_SEQUENCE_RESULTS=%s", strings.Join(te.ctx.SequenceResults, " ")))
+	}
+	return env
+}
@@ -0,0 +1,160 @@
+package uber
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestRunSequence(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-sequence")
+	defer cleanup()
+
+	// Every script appends a line to the log so the order of events is visible
+	logFile := filepath.Join(tempDir, "log.txt")
+	scripts := map[string]string{
+		"setup.sh":  fmt.Sprintf("#!/bin/sh\necho \"setup $UBER_SEQUENCE\" >> %s\necho SHARED=from-setup\n", logFile),
+		"report.sh": fmt.Sprintf("#!/bin/sh\necho \"report $UBER_EXECUTED_COMMAND $UBER_TOOL_EXIT_CODE $UBER_SEQUENCE_RESULTS\" >> %s\n", logFile),
+		"build":     fmt.Sprintf("#!/bin/sh\necho \"build $SHARED\" >> %s\n", logFile),
+		"test":      fmt.Sprintf("#!/bin/sh\necho \"test $SHARED\" >> %s\nexit 3\n", logFile),
+		"publish":   fmt.Sprintf("#!/bin/sh\necho \"publish $SHARED\" >> %s\n", logFile),
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		keepGoing bool
+		wantLog   string
+	}{
+		{
+			name:    "stops at the first failure",
+			wantLog: "setup release\nbuild from-setup\ntest from-setup\nreport release 3 build=0 test=3 publish=skipped\n",
+		},
+		{
+			name:      "keep going",
+			keepGoing: true,
+			wantLog:   "setup release\nbuild from-setup\ntest from-setup\npublish from-setup\nreport release 3 build=0 test=3 publish=0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(logFile)
+
+			executor := NewToolExecutor(&RunContext{
+				Root:      tempDir,
+				Command:   "release",
+				KeepGoing: tt.keepGoing,
+				Config: &config.Config{
+					ToolPaths:       []string{tempDir},
+					EnvSetup:        filepath.Join(tempDir, "setup.sh"),
+					ReportingCmds:   config.StringList{"report.sh"},
+					ReportOnFailure: true,
+					Sequences:       map[string][]string{"release": {"build", "test", "publish"}},
+				},
+			})
+			err := executor.RunCommand(context.Background(), "release", nil)
+			if err == nil || !strings.Contains(err.Error(), "step 'test' of sequence 'release' failed") {
+				t.Errorf("Expected the test step to fail the sequence, got: %v", err)
+			}
+			if code := ExitCode(err); code != 3 {
+				t.Errorf("Expected exit code 3, got %d", code)
+			}
+
+			output, _ := os.ReadFile(logFile)
+			if string(output) != tt.wantLog {
+				t.Errorf("Log = %q, want %q", string(output), tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestRunSequenceProgress(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-sequence-progress")
+	defer cleanup()
+	if err := os.WriteFile(filepath.Join(tempDir, "build"), []byte("#!/bin/sh\necho built\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	stdoutR, stdoutW, _ := os.Pipe()
+	stderrR, stderrW, _ := os.Pipe()
+	os.Stdout, os.Stderr = stdoutW, stderrW
+	executor := NewToolExecutor(&RunContext{
+		Root:    tempDir,
+		Command: "release",
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			Sequences: map[string][]string{"release": {"build"}},
+		},
+	})
+	err := executor.RunCommand(context.Background(), "release", nil)
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	stdoutW.Close()
+	stderrW.Close()
+	if err != nil {
+		t.Fatalf("RunCommand failed: %v", err)
+	}
+
+	// Only the tool's output goes to stdout, so it can be piped
+	var stdout, stderr bytes.Buffer
+	stdout.ReadFrom(stdoutR)
+	stderr.ReadFrom(stderrR)
+	if stdout.String() != "built\n" {
+		t.Errorf("Expected only the tool's output on stdout, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "==> build (1/1)") {
+		t.Errorf("Expected the step header on stderr, got %q", stderr.String())
+	}
+}
+
+func TestRunSequenceErrors(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-sequence-errors")
+	defer cleanup()
+
+	logFile := filepath.Join(tempDir, "log.txt")
+	if err := os.WriteFile(filepath.Join(tempDir, "build"), []byte(fmt.Sprintf("#!/bin/sh\necho build >> %s\n", logFile)), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	newExecutor := func(keepGoing bool) *ToolExecutor {
+		return NewToolExecutor(&RunContext{
+			Root:      tempDir,
+			KeepGoing: keepGoing,
+			Config: &config.Config{
+				ToolPaths: []string{tempDir},
+				Sequences: map[string][]string{"release": {"build", "missing"}},
+			},
+		})
+	}
+
+	// A missing step is found before any step runs
+	err := newExecutor(false).RunCommand(context.Background(), "release", nil)
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound for the missing step, got: %v", err)
+	}
+	if _, statErr := os.Stat(logFile); statErr == nil {
+		t.Errorf("Expected no step to run when a step is missing")
+	}
+
+	err = newExecutor(false).RunCommand(context.Background(), "release", []string{"extra"})
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for sequence arguments, got: %v", err)
+	}
+
+	err = newExecutor(true).RunCommand(context.Background(), "build", nil)
+	if !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected ErrUsage for --keep-going with a tool, got: %v", err)
+	}
+}
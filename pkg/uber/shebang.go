@@ -0,0 +1,102 @@
+package uber
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// readShebang reads the first line of the file at path and returns the
+// interpreter line following "#!". It returns false if the file cannot be
+// read or doesn't start with a shebang.
+func readShebang(path string) (string, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(line, "#!") {
+		return "", false
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(line, "#!")), true
+}
+
+// binaryMagics are the leading bytes of executable formats the system runs
+// directly: ELF, Mach-O (both byte orders, 32 and 64 bit, and universal) and
+// Windows PE.
+var binaryMagics = [][]byte{
+	[]byte("\x7fELF"),
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+	[]byte("MZ"),
+}
+
+// needsShell reports whether the file at path is a script without a shebang,
+// which the system can't execute directly. Files that can't be read are left
+// for exec to report on.
+func needsShell(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	n, _ := io.ReadFull(file, header)
+	header = header[:n]
+
+	if bytes.HasPrefix(header, []byte("#!")) {
+		return false
+	}
+	for _, magic := range binaryMagics {
+		if bytes.HasPrefix(header, magic) {
+			return false
+		}
+	}
+	return true
+}
+
+// explainStartError turns an error from starting a tool into a clearer one
+// when the tool's shebang names an interpreter that is missing or not
+// executable. Any other error is returned unchanged.
+func explainStartError(toolPath string, err error) error {
+	var execErr *exec.Error
+	var pathErr *os.PathError
+	if !errors.As(err, &execErr) && !errors.As(err, &pathErr) {
+		return err
+	}
+
+	shebang, ok := readShebang(toolPath)
+	if !ok {
+		return err
+	}
+	fields := strings.Fields(shebang)
+	if len(fields) == 0 {
+		return fmt.Errorf("tool '%s' has an empty shebang: %w", toolPath, err)
+	}
+
+	interpreter := fields[0]
+	info, statErr := os.Stat(interpreter)
+	if statErr != nil {
+		return withKind(ErrNotFound, fmt.Errorf("tool '%s' has shebang '#!%s' but '%s' does not exist", toolPath, shebang, interpreter))
+	}
+	if !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+		return withKind(ErrNotExecutable, fmt.Errorf("tool '%s' has shebang '#!%s' but '%s' is not executable", toolPath, shebang, interpreter))
+	}
+
+	return err
+}
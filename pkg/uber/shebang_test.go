@@ -0,0 +1,163 @@
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestReadShebang(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-read-shebang")
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOk  bool
+	}{
+		{
+			name:    "simple shebang",
+			content: "#!/bin/sh\necho hi\n",
+			want:    "/bin/sh",
+			wantOk:  true,
+		},
+		{
+			name:    "shebang with arguments",
+			content: "#!/usr/bin/env python3\nprint('hi')\n",
+			want:    "/usr/bin/env python3",
+			wantOk:  true,
+		},
+		{
+			name:    "shebang without trailing newline",
+			content: "#!/bin/bash",
+			want:    "/bin/bash",
+			wantOk:  true,
+		},
+		{
+			name:    "no shebang",
+			content: "echo hi\n",
+			wantOk:  false,
+		},
+		{
+			name:    "empty file",
+			content: "",
+			wantOk:  false,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, fmt.Sprintf("tool%d", i))
+			if err := os.WriteFile(path, []byte(tt.content), 0755); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			got, ok := readShebang(path)
+			if ok != tt.wantOk {
+				t.Errorf("readShebang() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if got != tt.want {
+				t.Errorf("readShebang() = '%s', want '%s'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteToolMissingInterpreter(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-missing-interpreter")
+	defer cleanup()
+
+	toolPath := filepath.Join(tempDir, "bad-shebang")
+	if err := os.WriteFile(toolPath, []byte("#!/nonexistent/bin/pythn3\nprint('hi')\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+		},
+	})
+
+	err := executor.FindAndExecuteTool(context.Background(), "bad-shebang", []string{})
+	if err == nil {
+		t.Fatal("Expected error for missing interpreter, got nil")
+	}
+	if !strings.Contains(err.Error(), "'/nonexistent/bin/pythn3' does not exist") {
+		t.Errorf("Expected error to name the missing interpreter, got: %v", err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestNeedsShell(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-needs-shell")
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "shebang", content: "#!/bin/sh\necho hi\n", want: false},
+		{name: "elf binary", content: "\x7fELF\x02\x01\x01", want: false},
+		{name: "mach-o binary", content: "\xcf\xfa\xed\xfe\x07", want: false},
+		{name: "script without shebang", content: "echo hi\n", want: true},
+		{name: "empty file", content: "", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, strings.ReplaceAll(tt.name, " ", "-"))
+			if err := os.WriteFile(path, []byte(tt.content), 0755); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			if got := needsShell(path); got != tt.want {
+				t.Errorf("needsShell() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if needsShell(filepath.Join(tempDir, "missing")) {
+		t.Error("Expected needsShell to be false for a missing file")
+	}
+}
+
+func TestExecuteToolDefaultShell(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-default-shell")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "output")
+	script := fmt.Sprintf("echo \"$0 $*\" > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "no-shebang"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	cfg := &config.Config{ToolPaths: []string{tempDir}}
+	executor := NewToolExecutor(&RunContext{Root: tempDir, Config: cfg})
+
+	// Without default_shell, exec can't run the script
+	if err := executor.FindAndExecuteTool(context.Background(), "no-shebang", []string{"a"}); err == nil {
+		t.Fatal("Expected a script without a shebang to fail without default_shell")
+	}
+
+	cfg.DefaultShell = "/bin/sh"
+	if err := executor.FindAndExecuteTool(context.Background(), "no-shebang", []string{"a", "b"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if want := filepath.Join(tempDir, "no-shebang") + " a b"; strings.TrimSpace(string(output)) != want {
+		t.Errorf("Expected '%s', got '%s'", want, strings.TrimSpace(string(output)))
+	}
+}
@@ -0,0 +1,114 @@
+package uber
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+		{
+			name:  "simple words",
+			input: "perf stat --",
+			want:  []string{"perf", "stat", "--"},
+		},
+		{
+			name:  "extra whitespace",
+			input: "  time \t -p  ",
+			want:  []string{"time", "-p"},
+		},
+		{
+			name:  "single quotes",
+			input: "strace -e 'trace=open,read' -o '/tmp/my trace'",
+			want:  []string{"strace", "-e", "trace=open,read", "-o", "/tmp/my trace"},
+		},
+		{
+			name:  "double quotes with escapes",
+			input: `sh -c "echo \"hi\" \$HOME"`,
+			want:  []string{"sh", "-c", `echo "hi" $HOME`},
+		},
+		{
+			name:  "backslash keeps other characters in double quotes",
+			input: `"a\b"`,
+			want:  []string{`a\b`},
+		},
+		{
+			name:  "escaped space",
+			input: `docker run my\ image`,
+			want:  []string{"docker", "run", "my image"},
+		},
+		{
+			name:  "adjacent quoted parts form one word",
+			input: `--name='a b'"c d"e`,
+			want:  []string{"--name=a bc de"},
+		},
+		{
+			name:  "empty quoted word",
+			input: `env ''`,
+			want:  []string{"env", ""},
+		},
+		{
+			name:    "unterminated single quote",
+			input:   "time 'oops",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated double quote",
+			input:   `time "oops`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShellWords(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitShellWords() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitShellWords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "", want: "''"},
+		{input: "simple", want: "simple"},
+		{input: "/usr/bin/tool.sh", want: "/usr/bin/tool.sh"},
+		{input: "--flag=value", want: "--flag=value"},
+		{input: "two words", want: "'two words'"},
+		{input: "it's", want: `'it'\''s'`},
+		{input: "$HOME", want: "'$HOME'"},
+		{input: "a;b|c&d", want: "'a;b|c&d'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := shellQuote(tt.input)
+			if got != tt.want {
+				t.Errorf("shellQuote(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+
+			// Quoting and splitting round-trip
+			words, err := splitShellWords(got)
+			if err != nil || len(words) != 1 || words[0] != tt.input {
+				t.Errorf("splitShellWords(%s) = %q, %v, want [%q]", got, words, err, tt.input)
+			}
+		})
+	}
+}
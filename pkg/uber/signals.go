@@ -0,0 +1,89 @@
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+)
+
+// parseSignals converts signal names such as "SIGHUP" or "hup" into signals.
+func parseSignals(names []string) ([]os.Signal, error) {
+	var signals []os.Signal
+	for _, name := range names {
+		key := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG")
+		sig, ok := signalsByName[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown signal '%s'", name)
+		}
+		signals = append(signals, sig)
+	}
+	return signals, nil
+}
+
+// runForwardingSignals runs cmd and relays the given signals received by uber
+// to it until it exits. uber doesn't act on the forwarded signals itself.
+func runForwardingSignals(cmd *exec.Cmd, signals []os.Signal) error {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-received:
+				// The tool may already be exiting, nothing to do if it's gone
+				cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+// signalCause is the cause of a context canceled by notifyContext.
+type signalCause struct {
+	sig os.Signal
+}
+
+func (c signalCause) Error() string {
+	return fmt.Sprintf("received %s", c.sig)
+}
+
+// notifyContext is like signal.NotifyContext, but records the signal that
+// canceled the context as its cause.
+func notifyContext(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	go func() {
+		select {
+		case sig := <-received:
+			cancel(signalCause{sig})
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(received)
+		cancel(context.Canceled)
+	}
+}
+
+// interruptedFromTerminal reports whether ctx was canceled by an interrupt
+// that most likely came from Ctrl-C in the terminal, which the terminal also
+// sends to every process in uber's process group.
+func interruptedFromTerminal(ctx context.Context) bool {
+	var cause signalCause
+	return errors.As(context.Cause(ctx), &cause) && cause.sig == os.Interrupt && IsTTYStdin()
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package uber
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// signalsByName holds the signals forward_signals accepts, without their SIG
+// prefix. Only these exist on this platform.
+var signalsByName = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+}
+
+// ownProcessGroup reports whether cmd was started in a process group of its
+// own. Process groups are a Unix concept.
+func ownProcessGroup(cmd *exec.Cmd) bool {
+	return false
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package uber
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// signalsByName holds the signals forward_signals accepts, without their SIG
+// prefix.
+var signalsByName = map[string]os.Signal{
+	"HUP":   syscall.SIGHUP,
+	"INT":   syscall.SIGINT,
+	"QUIT":  syscall.SIGQUIT,
+	"TERM":  syscall.SIGTERM,
+	"USR1":  syscall.SIGUSR1,
+	"USR2":  syscall.SIGUSR2,
+	"ALRM":  syscall.SIGALRM,
+	"WINCH": syscall.SIGWINCH,
+}
+
+// ownProcessGroup reports whether cmd was started in a process group of its
+// own, so that signals from the terminal don't reach it.
+func ownProcessGroup(cmd *exec.Cmd) bool {
+	return cmd.SysProcAttr != nil && (cmd.SysProcAttr.Setsid || cmd.SysProcAttr.Setpgid)
+}
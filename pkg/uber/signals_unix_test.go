@@ -0,0 +1,145 @@
+//go:build unix
+
+package uber
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestParseSignals(t *testing.T) {
+	signals, err := parseSignals([]string{"SIGHUP", "term", "Usr1"})
+	if err != nil {
+		t.Fatalf("parseSignals failed: %v", err)
+	}
+	want := []os.Signal{syscall.SIGHUP, syscall.SIGTERM, syscall.SIGUSR1}
+	if len(signals) != len(want) {
+		t.Fatalf("parseSignals() = %v, want %v", signals, want)
+	}
+	for i := range want {
+		if signals[i] != want[i] {
+			t.Errorf("parseSignals()[%d] = %v, want %v", i, signals[i], want[i])
+		}
+	}
+
+	if _, err := parseSignals([]string{"SIGHUP", "SIGBOGUS"}); err == nil || !strings.Contains(err.Error(), "SIGBOGUS") {
+		t.Errorf("Expected an error naming the unknown signal, got: %v", err)
+	}
+}
+
+func TestFindAndExecuteToolForwardSignals(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-forward-signals")
+	defer cleanup()
+
+	readyFile := filepath.Join(tempDir, "ready")
+	outputFile := filepath.Join(tempDir, "output")
+	script := `#!/bin/sh
+trap 'echo hup > ` + outputFile + `; exit 0' HUP
+touch ` + readyFile + `
+i=0
+while [ $i -lt 50 ]; do sleep 0.1; i=$((i+1)); done
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "reload"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:           tempDir,
+		ForwardSignals: []os.Signal{syscall.SIGHUP},
+		Config:         &config.Config{ToolPaths: []string{tempDir}},
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- executor.FindAndExecuteTool(context.Background(), "reload", []string{})
+	}()
+
+	for i := 0; i < 50; i++ {
+		if _, err := os.Stat(readyFile); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if _, err := os.Stat(readyFile); err != nil {
+		t.Fatalf("Tool didn't start: %v", err)
+	}
+
+	// uber relays the signal it receives to the tool
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Expected the tool to exit cleanly after SIGHUP, got: %v", err)
+	}
+	output, err := os.ReadFile(outputFile)
+	if err != nil || strings.TrimSpace(string(output)) != "hup" {
+		t.Errorf("Expected the tool to receive SIGHUP, got '%s' (%v)", string(output), err)
+	}
+}
+
+func TestNotifyContextCause(t *testing.T) {
+	ctx, stop := notifyContext(context.Background(), syscall.SIGUSR1)
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Failed to signal uber: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the signal to cancel the context")
+	}
+	if cause := context.Cause(ctx); cause != (signalCause{syscall.SIGUSR1}) {
+		t.Errorf("Expected the signal as the cause, got %v", cause)
+	}
+	// Only an interrupt looks like a Ctrl-C
+	if interruptedFromTerminal(ctx) {
+		t.Errorf("Expected SIGUSR1 not to count as an interrupt from the terminal")
+	}
+}
+
+func TestOwnProcessGroup(t *testing.T) {
+	cmd := commandContext(context.Background(), "true")
+	if ownProcessGroup(cmd) {
+		t.Errorf("Expected a plain command to share uber's process group")
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if !ownProcessGroup(cmd) {
+		t.Errorf("Expected a command with its own session to have its own process group")
+	}
+}
+
+func TestCommandContextWaitDelay(t *testing.T) {
+	// A command that exits on its own has its output read to the end, even
+	// while a background process it started still holds stdout
+	var out strings.Builder
+	cmd := commandContext(context.Background(), "/bin/sh", "-c", "echo ready; (sleep 1 &)")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if out.String() != "ready\n" || cmd.WaitDelay != 0 {
+		t.Errorf("Expected the whole output without a wait delay, got %q and %v", out.String(), cmd.WaitDelay)
+	}
+
+	// A canceled command gets the grace period before it is killed
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd = commandContext(ctx, "sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	cancel()
+	cmd.Wait()
+	if cmd.WaitDelay != shutdownGracePeriod {
+		t.Errorf("Expected a wait delay of %v once canceled, got %v", shutdownGracePeriod, cmd.WaitDelay)
+	}
+}
@@ -0,0 +1,39 @@
+package uber
+
+import "strings"
+
+// expandArgTemplates substitutes the uber template tokens in the tool's
+// arguments: {{root}} is the project root, {{tool}} the tool name and, when
+// git_env is enabled, {{gitSha}} the checked out commit. Anything else,
+// including unknown tokens and literal braces, is passed through unchanged.
+func (te *ToolExecutor) expandArgTemplates(toolName string, args []string) []string {
+	if !hasArgTemplate(args) {
+		return args
+	}
+
+	pairs := []string{
+		"{{root}}", te.ctx.Root,
+		"{{tool}}", toolName,
+	}
+	if te.ctx.Config.GitEnv {
+		pairs = append(pairs, "{{gitSha}}", te.gitMetadata().SHA)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = replacer.Replace(arg)
+	}
+	return expanded
+}
+
+// hasArgTemplate reports whether any argument may contain a token, so
+// that the common case doesn't compute git metadata.
+func hasArgTemplate(args []string) bool {
+	for _, arg := range args {
+		if strings.Contains(arg, "{{") {
+			return true
+		}
+	}
+	return false
+}
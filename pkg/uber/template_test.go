@@ -0,0 +1,115 @@
+package uber
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestExpandArgTemplates(t *testing.T) {
+	executor := NewToolExecutor(&RunContext{
+		Root:   "/project",
+		Config: &config.Config{GitEnv: true},
+	})
+	executor.git = &gitInfo{SHA: "abc123"}
+
+	got := executor.expandArgTemplates("deploy", []string{
+		"--manifest", "{{root}}/deploy.yaml",
+		"{{tool}}-{{gitSha}}",
+		"{{unknown}}", "{literal}", "{{ root }}",
+	})
+	want := []string{
+		"--manifest", "/project/deploy.yaml",
+		"deploy-abc123",
+		"{{unknown}}", "{literal}", "{{ root }}",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expandArgTemplates() = %v, want %v", got, want)
+	}
+}
+
+func TestExpandArgTemplatesGitShaRequiresGitEnv(t *testing.T) {
+	executor := NewToolExecutor(&RunContext{
+		Root:   "/project",
+		Config: &config.Config{},
+	})
+	executor.git = &gitInfo{SHA: "abc123"}
+
+	got := executor.expandArgTemplates("deploy", []string{"{{gitSha}}"})
+	if !slices.Equal(got, []string{"{{gitSha}}"}) {
+		t.Errorf("Expected {{gitSha}} to be preserved without git_env, got %v", got)
+	}
+}
+
+func TestFindAndExecuteToolExpandsArgTemplates(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-templates")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "output")
+	script := "#!/bin/sh\necho \"$@\" > " + outputFile + "\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "deploy"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "deploy", []string{"--manifest", "{{root}}/deploy.yaml"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	want := "--manifest " + tempDir + "/deploy.yaml"
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != want {
+		t.Errorf("Expected tool args '%s', got '%s'", want, strings.TrimSpace(string(output)))
+	}
+
+	if args, _ := envValue(executor.prepareReportingEnvironment(), "UBER_ARGS"); args != want {
+		t.Errorf("Expected UBER_ARGS '%s', got '%s'", want, args)
+	}
+}
+
+func TestFindAndExecuteToolRewritesArgs(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-arg-rewrite")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "output")
+	script := "#!/bin/sh\necho \"$@\" > " + outputFile + "\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "deploy"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			Tools: map[string]config.ToolConfig{
+				"deploy": {ArgRewrite: []config.ArgRewrite{{From: "--prod", To: config.StringList{"--env", "production"}}}},
+			},
+		},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "deploy", []string{"--prod", "--dry-run"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	want := "--env production --dry-run"
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != want {
+		t.Errorf("Expected tool args '%s', got '%s'", want, strings.TrimSpace(string(output)))
+	}
+	if args, _ := envValue(executor.prepareReportingEnvironment(), "UBER_ARGS"); args != want {
+		t.Errorf("Expected UBER_ARGS '%s', got '%s'", want, args)
+	}
+}
@@ -0,0 +1,18 @@
+package uber
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// terminalSizeEnvironment returns COLUMNS and LINES set to the size of the
+// terminal on stdout, or nothing if stdout isn't a terminal.
+func terminalSizeEnvironment() []string {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("COLUMNS=%d", width), fmt.Sprintf("LINES=%d", height)}
+}
@@ -0,0 +1,89 @@
+package uber
+
+import (
+	"fmt"
+	"os"
+)
+
+// toolCacheFile is the name of the tool discovery cache inside the cache
+// directory.
+const toolCacheFile = "tools.json"
+
+// toolCacheEntry is the on-disk form of the tools found in one tool directory.
+type toolCacheEntry struct {
+	// ModTime is the modification time of the directory, in nanoseconds since
+	// the epoch, when it was scanned.
+	ModTime int64 `json:"mod_time"`
+	// Tools holds the executables found in the directory.
+	Tools []string `json:"tools"`
+}
+
+// toolCache caches the executables found in each tool directory, keyed on
+// the directory's modification time, which changes whenever a file is added,
+// removed or renamed in it.
+type toolCache struct {
+	te      *ToolExecutor
+	path    string
+	enabled bool
+	entries map[string]toolCacheEntry
+	dirty   bool
+}
+
+// newToolCache loads the tool discovery cache. It is only used when tool_cache
+// is enabled and --no-tool-cache wasn't given.
+func (te *ToolExecutor) newToolCache() *toolCache {
+	c := &toolCache{
+		te:      te,
+		path:    te.cachePath(toolCacheFile),
+		enabled: te.ctx.Config.ToolCache && !te.ctx.NoToolCache,
+		entries: make(map[string]toolCacheEntry),
+	}
+	if c.enabled {
+		if err := readCacheFile(c.path, &c.entries); err != nil && te.ctx.Verbose {
+			ColorPrint(ColorCyan, "No cached tool list found\n")
+		}
+	}
+	return c
+}
+
+// listExecutables returns the executables in toolPath, from the cache if the
+// directory hasn't changed since it was last scanned.
+func (c *toolCache) listExecutables(toolPath string) ([]string, error) {
+	if !c.enabled || c.te.isFileToolPath(toolPath) {
+		return c.te.listExecutablesInPath(toolPath)
+	}
+
+	fullPath := c.te.resolveToolFullPath(toolPath, "")
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		delete(c.entries, fullPath)
+		return c.te.listExecutablesInPath(toolPath)
+	}
+
+	modTime := info.ModTime().UnixNano()
+	if entry, ok := c.entries[fullPath]; ok && entry.ModTime == modTime {
+		return entry.Tools, nil
+	}
+
+	if c.te.ctx.Verbose {
+		ColorPrint(ColorCyan, fmt.Sprintf("Scanning tool path '%s'\n", toolPath))
+	}
+	tools, err := c.te.listExecutablesInPath(toolPath)
+	if err != nil {
+		return nil, err
+	}
+	c.entries[fullPath] = toolCacheEntry{ModTime: modTime, Tools: tools}
+	c.dirty = true
+	return tools, nil
+}
+
+// save writes the cache back to disk if any directory was rescanned. Failing
+// to write it only costs a rescan next time.
+func (c *toolCache) save() {
+	if !c.enabled || !c.dirty {
+		return
+	}
+	if err := writeCacheFile(c.path, c.entries); err != nil && c.te.ctx.Verbose {
+		ColorPrint(ColorYellow, fmt.Sprintf("Warning: failed to write tool cache: %v\n", err))
+	}
+}
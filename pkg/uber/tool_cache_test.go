@@ -0,0 +1,100 @@
+package uber
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestToolCache(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-tool-cache")
+	defer cleanup()
+
+	writeTool := func(file string) {
+		path := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+	writeTool("a/build")
+	writeTool("b/lint")
+
+	ctx := &RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"a", "b"}, ToolCache: true},
+	}
+	executor := NewToolExecutor(ctx)
+
+	toolNames := func() []string {
+		tools, err := executor.GetAllAvailableTools()
+		if err != nil {
+			t.Fatalf("GetAllAvailableTools failed: %v", err)
+		}
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Path+"/"+tool.Name)
+		}
+		return names
+	}
+
+	if got, want := toolNames(), []string{"a/build", "b/lint"}; !slices.Equal(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	// Plant different results in the cache to see when they are used
+	cacheFile := filepath.Join(tempDir, cacheDirName, toolCacheFile)
+	entries := make(map[string]toolCacheEntry)
+	if err := readCacheFile(cacheFile, &entries); err != nil {
+		t.Fatalf("Expected the tool cache to be written: %v", err)
+	}
+	for dir, entry := range entries {
+		entry.Tools = []string{"cached-" + filepath.Base(dir)}
+		entries[dir] = entry
+	}
+	if err := writeCacheFile(cacheFile, entries); err != nil {
+		t.Fatalf("Failed to write cache: %v", err)
+	}
+
+	if got, want := toolNames(), []string{"a/cached-a", "b/cached-b"}; !slices.Equal(got, want) {
+		t.Errorf("Expected the cached tools %v, got %v", want, got)
+	}
+
+	// Changing a directory only rescans that directory
+	writeTool("a/test")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(tempDir, "a"), future, future); err != nil {
+		t.Fatalf("Failed to change directory time: %v", err)
+	}
+	if got, want := toolNames(), []string{"a/build", "a/test", "b/cached-b"}; !slices.Equal(got, want) {
+		t.Errorf("Expected only 'a' to be rescanned, got %v", got)
+	}
+
+	ctx.NoToolCache = true
+	if got, want := toolNames(), []string{"a/build", "a/test", "b/lint"}; !slices.Equal(got, want) {
+		t.Errorf("Expected --no-tool-cache to rescan every path, got %v", got)
+	}
+}
+
+func TestToolCacheDisabledByDefault(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-tool-cache-off")
+	defer cleanup()
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+	if _, err := executor.GetAllAvailableTools(); err != nil {
+		t.Fatalf("GetAllAvailableTools failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, cacheDirName, toolCacheFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no tool cache without tool_cache, got: %v", err)
+	}
+}
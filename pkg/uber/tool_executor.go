@@ -3,18 +3,50 @@ package uber
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+// Limits on the output of the env setup script, to avoid unbounded memory use
+// when a script misbehaves.
+const (
+	defaultEnvSetupMaxLineBytes = 1 << 20
+	envSetupMaxOutputBytes      = 16 << 20
 )
 
+// inEnvSetupEnvVar is set in the environment of the env setup script. An uber
+// started with it set was run by an env setup script and refuses to run env
+// setup itself, which would recurse forever.
+const inEnvSetupEnvVar = "UBER_IN_ENV_SETUP"
+
+// shutdownGracePeriod is how long a child process has to exit after being
+// interrupted by a canceled context before it is killed.
+const shutdownGracePeriod = 5 * time.Second
+
+// originalArgvSeparator delimits the arguments in UBER_ORIGINAL_ARGV. It is the
+// ASCII unit separator, which is unlikely to appear in an argument and, unlike a
+// null byte, is allowed in environment variables.
+const originalArgvSeparator = "\x1f"
+
 // ToolExecutor handles finding and executing tools based on the configuration
 type ToolExecutor struct {
 	ctx *RunContext
+	git *gitInfo
+	// timedOutDirs holds the directories whose scan exceeded
+	// --dir-scan-timeout
+	timedOutDirs map[string]bool
 }
 
 // NewToolExecutor creates a new ToolExecutor instance
@@ -34,17 +66,35 @@ type AvailableTool struct {
 // in the order they appear in the tool_paths configuration
 func (te *ToolExecutor) GetAllAvailableTools() ([]AvailableTool, error) {
 	// If no tool paths configured, return error
-	if te.ctx.Config.ToolPaths == nil || len(te.ctx.Config.ToolPaths) == 0 {
-		return nil, fmt.Errorf("no tool paths configured in .uber file")
+	if len(te.ctx.Config.ToolPaths) == 0 && len(te.ctx.Config.Commands) == 0 {
+		return nil, withKind(ErrConfig, fmt.Errorf("no tool paths configured in .uber file"))
+	}
+	if err := te.checkRequiredToolPaths(); err != nil {
+		return nil, err
 	}
 
 	var allTools []AvailableTool
 
+	// Inline commands come first since they take precedence
+	for _, command := range te.ctx.Config.Commands {
+		if te.ctx.Config.ToolAllowed(command.Name) {
+			allTools = append(allTools, AvailableTool{Name: command.Name, Path: inlineCommandsPath})
+		}
+	}
+
+	// Reuse the scans of directories that haven't changed if enabled
+	cache := te.newToolCache()
+	defer cache.save()
+
 	// Search for tools in each configured path in order
 	for _, toolPath := range te.ctx.Config.ToolPaths {
-		tools, err := te.listExecutablesInPath(toolPath)
+		tools, err := cache.listExecutables(toolPath)
 		if err != nil {
-			if te.ctx.Verbose {
+			if errors.Is(err, fs.ErrPermission) {
+				if err := te.unreadableToolPath(toolPath, err); err != nil {
+					return nil, err
+				}
+			} else if te.ctx.Verbose {
 				ColorPrint(ColorYellow, fmt.Sprintf("Error scanning path '%s': %v\n", toolPath, err))
 			}
 			continue
@@ -52,6 +102,9 @@ func (te *ToolExecutor) GetAllAvailableTools() ([]AvailableTool, error) {
 
 		// Add all tools from this path to the list
 		for _, toolName := range tools {
+			if !te.ctx.Config.ToolAllowed(toolName) || te.isHiddenTool(toolName) {
+				continue
+			}
 			allTools = append(allTools, AvailableTool{
 				Name: toolName,
 				Path: toolPath,
@@ -63,205 +116,871 @@ func (te *ToolExecutor) GetAllAvailableTools() ([]AvailableTool, error) {
 }
 
 // FindAndExecuteTool searches for the specified tool in the configured tool paths
-// and executes it with the given arguments
-func (te *ToolExecutor) FindAndExecuteTool(toolName string, args []string) error {
+// and executes it with the given arguments. Canceling ctx interrupts any running
+// child process.
+func (te *ToolExecutor) FindAndExecuteTool(ctx context.Context, toolName string, args []string) error {
+	args, err := te.prepareTool(toolName, args)
+	if err != nil {
+		return err
+	}
+
+	// Found the tool, execute it
+	if te.ctx.Verbose {
+		ColorPrint(ColorGreen, fmt.Sprintf("Executing with args: %v\n", args))
+	}
+
+	cleanup, err := te.createTmpDir()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// A detached tool records where its PID and output go
+	if te.ctx.Detach {
+		te.ctx.PidFile = te.toolStatePath(te.ctx.ToolConfig.Pidfile, toolName, ".pid")
+		te.ctx.LogFile = te.toolStatePath(te.ctx.ToolConfig.LogFile, toolName, ".log")
+		if pid, ok := runningPid(te.ctx.PidFile); ok {
+			return withKind(ErrUsage, fmt.Errorf("tool '%s' is already running (pid %d, pidfile %s)", toolName, pid, te.ctx.PidFile))
+		}
+	}
+
+	// Execute the env setup script if it's defined
+	env, err := te.setupEnvironment(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the tool is usable before running it
+	if err := te.checkRequiredEnv(toolName, env); err != nil {
+		return err
+	}
+	if err := te.checkHealth(ctx, toolName, env); err != nil {
+		return err
+	}
+
+	execStart := time.Now()
+	err = te.executeTool(ctx, te.ctx.ExecutablePath, args, env)
+	te.ctx.TimeExecToolMs = time.Since(execStart).Milliseconds()
+	te.ctx.ToolExitCode = ExitCode(err)
+
+	// A detached tool is still running, so there is nothing to report yet
+	if te.ctx.Detach {
+		return err
+	}
+
+	te.report(ctx, err)
+	return err
+}
+
+// prepareTool locates the tool, checks that it may run and processes its
+// arguments, returning the arguments to run it with. The tool and its
+// arguments are recorded in the run context.
+func (te *ToolExecutor) prepareTool(toolName string, args []string) ([]string, error) {
+	// Time the search until the tool's executable has been located
 	findToolStart := time.Now()
+	toolPath, executablePath, err := te.findTool(toolName)
+	if err != nil {
+		return nil, err
+	}
+	te.ctx.TimeFindToolMs = time.Since(findToolStart).Milliseconds()
+
+	// Refuse tools excluded by allow_tools/deny_tools. An inline command
+	// runs the shell, so only its name counts.
+	inline, isInline := te.ctx.Config.Command(toolName)
+	allowNames := []string{toolName}
+	if !isInline {
+		allowNames = append(allowNames, filepath.Base(executablePath))
+	}
+	if !te.ctx.Config.ToolAllowed(allowNames...) {
+		return nil, withKind(ErrNotPermitted, fmt.Errorf("tool '%s' is not permitted to run by the allow_tools/deny_tools configuration", toolName))
+	}
+
+	// On shared machines, refuse tools another user could have planted. An
+	// inline command is part of the configuration rather than a file.
+	if te.ctx.Config.RequireOwner && !isInline {
+		if err := checkOwner(executablePath); err != nil {
+			return nil, err
+		}
+	}
+
+	te.ctx.FoundToolPath = toolPath
+	te.ctx.ExecutablePath = executablePath
+	te.ctx.ToolConfig = te.ctx.Config.Tool(toolName)
+
+	// Nudge users of retired tools towards their replacement, but still run
+	// the tool
+	if message := te.ctx.ToolConfig.Deprecated; message != "" {
+		ColorPrintWarning(fmt.Sprintf("Warning: tool '%s' is deprecated: %s\n", toolName, message))
+	}
+
+	// Guard tools that misbehave when given too many arguments
+	if maxArgs := te.ctx.ToolConfig.MaxArgs; maxArgs != nil && len(args) > *maxArgs {
+		return nil, withKind(ErrUsage, fmt.Errorf("tool '%s' accepts at most %d argument(s) but got %d; check for an unintended glob expansion or raise max_args in [tools.%s]", toolName, *maxArgs, len(args), toolName))
+	}
 
-	// Search for the tool in each configured path in order
+	// Rewrite legacy arguments, substitute template tokens such as {{root}}
+	// and read @file arguments before the tool sees them, and report the
+	// resulting arguments in UBER_ARGS
+	args = te.ctx.ToolConfig.RewriteArgs(args)
+	args = te.expandArgTemplates(toolName, args)
+
+	// Read @file arguments for tools that opted in, after the templates so
+	// that file contents are passed verbatim
+	if te.ctx.ToolConfig.FileArgs {
+		if args, err = expandFileArgs(args, os.Stdin); err != nil {
+			return nil, err
+		}
+	}
+
+	// Refuse arguments that don't match the tool's args_schema
+	if err := te.validateArgs(toolName, args); err != nil {
+		return nil, err
+	}
+	te.ctx.RemainingArgs = args
+
+	if isInline {
+		args = inlineCommandArgs(toolName, inline.Run, args)
+	}
+	return args, nil
+}
+
+// createTmpDir creates the per-run temporary directory if --isolate-tmp was
+// given. The returned cleanup function removes it once the tool and
+// reporting command have finished.
+func (te *ToolExecutor) createTmpDir() (cleanup func(), err error) {
+	if !te.ctx.IsolateTmp {
+		return func() {}, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "uber-tmp-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	te.ctx.TmpDir = tmpDir
+	if te.ctx.Verbose {
+		ColorPrint(ColorGreen, fmt.Sprintf("Using temporary directory: %s\n", tmpDir))
+	}
+	return func() {
+		os.RemoveAll(tmpDir)
+		te.ctx.TmpDir = ""
+	}, nil
+}
+
+// report runs the reporting commands for the outcome of a run that ended
+// with toolErr, unless reporting was disabled for this run. Failing
+// reporting commands don't change the result of the run.
+func (te *ToolExecutor) report(ctx context.Context, toolErr error) {
+	reportingCmds := te.reportingCmds(toolErr)
+	if len(reportingCmds) == 0 {
+		return
+	}
+	if reason := te.reportingDisabledReason(); reason != "" {
+		if te.ctx.Verbose {
+			ColorPrint(ColorYellow, fmt.Sprintf("Skipping reporting command: disabled by %s\n", reason))
+		}
+	} else if reportErr := te.executeReportingCmds(ctx, reportingCmds); reportErr != nil {
+		if te.ctx.Verbose {
+			ColorPrint(ColorYellow, fmt.Sprintf("Warning: reporting command failed: %v\n", reportErr))
+		}
+	}
+}
+
+// findTool searches for the tool in each configured tool path in order, stopping
+// at the first match. It returns the tool path the tool was found in and the
+// full path to its executable.
+func (te *ToolExecutor) findTool(toolName string) (string, string, error) {
+	// Commands defined inline in .uber take precedence over the tool paths
+	if command, ok := te.ctx.Config.Command(toolName); ok {
+		source := te.inlineCommandSource(command)
+		if te.ctx.Verbose {
+			ColorPrint(ColorGreen, fmt.Sprintf("Found inline command '%s' in %s\n", toolName, source))
+		}
+		return source, te.shell(), nil
+	}
+
+	// Only bare names, or namespaced commands made of bare names, can be run
+	// so that a tool name can't escape the tool paths
+	_, _, namespaced := te.ctx.Config.Namespace(toolName)
+	if !namespaced && isPathCommand(toolName) && te.ctx.Config.PathCommands {
+		return te.findPathCommand(toolName)
+	}
+	if !validToolName(toolName) && !namespaced {
+		if isPathCommand(toolName) {
+			return "", "", withKind(ErrUsage, fmt.Errorf("invalid tool name '%s': tool names can't contain path separators; set path_commands = true in the .uber file to run files by path", toolName))
+		}
+		return "", "", withKind(ErrUsage, fmt.Errorf("invalid tool name '%s': tool names can't contain path separators or be '.' or '..'", toolName))
+	}
+
+	if err := te.checkRequiredToolPaths(); err != nil {
+		return "", "", err
+	}
+
+	toolPath, resolvedName, ok := te.locateTool(toolName)
+	if !ok {
+		return "", "", te.toolNotFoundError(toolName)
+	}
+
+	if te.ctx.Verbose {
+		ColorPrint(ColorGreen, fmt.Sprintf("Found tool '%s' (resolved to '%s') in path '%s'\n", toolName, resolvedName, toolPath))
+	}
+	return toolPath, te.toolExecutablePath(toolPath, resolvedName), nil
+}
+
+// locateTool returns the tool path holding toolName and the file name it
+// resolves to there, without printing anything. ok is false if no tool path
+// has the tool.
+func (te *ToolExecutor) locateTool(toolName string) (toolPath, resolvedName string, ok bool) {
 	for _, toolPath := range te.ctx.Config.ToolPaths {
 		// Try to resolve the tool name (handles extensions)
 		resolvedName, err := te.resolveToolName(toolPath, toolName)
-		if err != nil {
+		if err == nil {
+			return toolPath, resolvedName, true
+		}
+		if errors.Is(err, errDirScanTimeout) && te.ctx.Verbose {
+			ColorPrint(ColorYellow, fmt.Sprintf("Warning: skipping tool path '%s': %v\n", toolPath, err))
+		}
+
+		// A namespaced command like "git:status" can also live in a
+		// subdirectory of the tool path, e.g. "git/status"
+		nestedPath, nestedName, isNamespaced := te.namespacedToolPath(toolPath, toolName)
+		if !isNamespaced {
 			// Continue to next path if tool not found in this one
 			continue
 		}
+		if resolvedName, err = te.resolveToolName(nestedPath, nestedName); err == nil {
+			return nestedPath, resolvedName, true
+		}
+	}
 
-		te.ctx.TimeFindToolMs = time.Since(findToolStart).Milliseconds()
+	return "", "", false
+}
 
-		// Found the tool, execute it
-		if te.ctx.Verbose {
-			ColorPrint(ColorGreen, fmt.Sprintf("Found tool '%s' (resolved to '%s') in path '%s'\n", toolName, resolvedName, toolPath))
-			ColorPrint(ColorGreen, fmt.Sprintf("Executing with args: %v\n", args))
-		}
-		te.ctx.FoundToolPath = toolPath
+// namespacedToolPath maps a namespaced command to the subdirectory of toolPath
+// that holds it and the tool name within that directory. ok is false if the
+// command isn't namespaced or toolPath is a single executable.
+func (te *ToolExecutor) namespacedToolPath(toolPath, toolName string) (nestedPath, nestedName string, ok bool) {
+	namespace, name, ok := te.ctx.Config.Namespace(toolName)
+	if !ok || te.isFileToolPath(toolPath) {
+		return "", "", false
+	}
+	return filepath.Join(append([]string{toolPath}, namespace...)...), name, true
+}
 
-		// Execute the env setup script if it's defined
-		envSetupStart := time.Now()
-		env, err := te.executeEnvSetup()
-		if err != nil {
-			return fmt.Errorf("failed to execute env setup script: %w", err)
+// checkRequiredToolPaths returns an error if a tool path marked required
+// doesn't exist, which usually means the checkout is incomplete.
+func (te *ToolExecutor) checkRequiredToolPaths() error {
+	for _, toolPath := range te.ctx.Config.RequiredToolPaths {
+		fullPath := te.resolveToolFullPath(toolPath, "")
+		if _, err := os.Stat(fullPath); err != nil {
+			return withKind(ErrConfig, fmt.Errorf("required tool path '%s' is not available: %w", toolPath, err))
 		}
-		te.ctx.TimeEnvSetupMs = time.Since(envSetupStart).Milliseconds()
+	}
+	return nil
+}
 
-		// Construct the full path to the executable
-		var fullPath string
-		if !filepath.IsAbs(toolPath) {
-			fullPath = filepath.Join(te.ctx.Root, toolPath)
-		} else {
-			fullPath = toolPath
-		}
-		executablePath := filepath.Join(fullPath, resolvedName)
+// unreadableToolPath reports a tool path that exists but can't be read
+// because of its permissions, given the error from reading it. This is an
+// error in strict mode and a warning otherwise. Other errors are left to the
+// caller.
+func (te *ToolExecutor) unreadableToolPath(toolPath string, err error) error {
+	if !errors.Is(err, fs.ErrPermission) {
+		return nil
+	}
+	err = fmt.Errorf("tool path '%s' (%s) is not readable: permission denied", toolPath, te.resolveToolFullPath(toolPath, ""))
+	if te.ctx.Config.Strict {
+		return withKind(ErrConfig, err)
+	}
+	ColorPrintWarning(fmt.Sprintf("Warning: %v; its tools are skipped\n", err))
+	return nil
+}
 
-		execStart := time.Now()
-		err = te.executeTool(executablePath, args, env)
-		te.ctx.TimeExecToolMs = time.Since(execStart).Milliseconds()
-		if err != nil {
-			return err // Return original error
+// toolNotFoundError builds the error returned when a tool isn't in any tool path
+func (te *ToolExecutor) toolNotFoundError(toolName string) error {
+	// When none of the tool paths exist, the layout is wrong rather than the
+	// tool name
+	var missing []string
+	for _, toolPath := range te.ctx.Config.ToolPaths {
+		fullPath := te.resolveToolFullPath(toolPath, "")
+		_, err := os.Stat(fullPath)
+		if te.ctx.Verbose {
+			status := "exists"
+			if err != nil {
+				status = "missing"
+			}
+			ColorPrint(ColorYellow, fmt.Sprintf("Checked tool path '%s' (%s): %s\n", toolPath, fullPath, status))
+		}
+		if os.IsNotExist(err) {
+			missing = append(missing, fullPath)
 		}
+	}
+	if len(missing) > 0 && len(missing) == len(te.ctx.Config.ToolPaths) {
+		return withKind(ErrConfig, fmt.Errorf("tool '%s' not found because none of the configured tool paths exist: %s; check tool_paths in the .uber file", toolName, strings.Join(missing, ", ")))
+	}
 
-		// After executing the tool, run the reporting command
-		if reportErr := te.executeReportingCmd(); reportErr != nil {
-			if te.ctx.Verbose {
-				ColorPrint(ColorYellow, fmt.Sprintf("Warning: reporting command failed: %v\n", reportErr))
+	// Dotfiles are skipped on purpose, so don't report them as not
+	// executable below
+	if te.isHiddenTool(toolName) {
+		return toolNotFound(toolName, nil, fmt.Errorf("tool '%s' not found: executables whose name starts with '.' are hidden unless include_hidden is set in the .uber file", toolName))
+	}
+
+	// A tool path that can't be read may well hold the tool
+	for _, toolPath := range te.ctx.Config.ToolPaths {
+		if _, err := te.readDir(te.resolveToolFullPath(toolPath, "")); err != nil {
+			if err := te.unreadableToolPath(toolPath, err); err != nil {
+				return err
 			}
-			// Do not return this error, as the main tool succeeded
 		}
-
-		return nil
 	}
 
-	// If we get here, the tool wasn't found in any path
-	// Try to provide a helpful error message by checking if the tool exists with extensions
-	var suggestions []string
+	// A symlink whose target was removed looks like a missing tool to
+	// os.Stat, so point at the broken link instead
 	for _, toolPath := range te.ctx.Config.ToolPaths {
-		files, err := os.ReadDir(te.resolveToolFullPath(toolPath, ""))
-		if err != nil {
+		fullPath := te.resolveToolFullPath(toolPath, toolName)
+		info, err := os.Lstat(fullPath)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
 			continue
 		}
+		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+			target, _ := os.Readlink(fullPath)
+			return toolNotFound(toolName, nil, fmt.Errorf("tool '%s' is a symlink pointing to a missing target %s (%s)", toolName, target, fullPath))
+		}
+	}
 
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-			fileName := file.Name()
-			if strings.HasPrefix(fileName, toolName+".") {
-				fullPath := filepath.Join(te.resolveToolFullPath(toolPath, ""), fileName)
-				if te.isExecutable(fullPath) {
-					suggestions = append(suggestions, fileName)
-				}
-			}
+	// If a file with the exact name exists but isn't executable, say so
+	// rather than "not found".
+	for _, toolPath := range te.ctx.Config.ToolPaths {
+		fullPath := te.resolveToolFullPath(toolPath, toolName)
+		if info, err := os.Stat(fullPath); err == nil && info.Mode().IsRegular() {
+			return withKind(ErrNotExecutable, fmt.Errorf("tool '%s' found at '%s' but it is not executable", toolName, fullPath))
 		}
 	}
 
-	if len(suggestions) > 0 {
-		return fmt.Errorf("tool '%s' not found in any configured tool path. Did you mean: %s?",
-			toolName, strings.Join(suggestions, ", "))
+	// --strict-match doesn't guess what was meant
+	if te.ctx.StrictMatch {
+		return toolNotFound(toolName, nil, fmt.Errorf("tool '%s' not found in any configured tool path (--strict-match requires an exact file name)", toolName))
+	}
+
+	// Try to provide a helpful error message by suggesting similar tools
+	if suggestions := te.toolSuggestions(toolName); len(suggestions) > 0 {
+		return toolNotFound(toolName, suggestions, fmt.Errorf("tool '%s' not found in any configured tool path. Did you mean: %s?",
+			toolName, strings.Join(suggestions, ", ")))
 	}
 
-	return fmt.Errorf("tool '%s' not found in any configured tool path", toolName)
+	return toolNotFound(toolName, nil, fmt.Errorf("tool '%s' not found in any configured tool path", toolName))
+}
+
+// setupEnvironment returns the environment the tool runs with: uber's own
+// environment with the UBER_ variables and those set by the env setup script.
+// With --bare it is uber's environment unmodified and env setup is skipped.
+func (te *ToolExecutor) setupEnvironment(ctx context.Context) ([]string, error) {
+	if te.ctx.Bare {
+		if te.ctx.Verbose {
+			ColorPrint(ColorYellow, "Bare mode: skipping env setup and running with the inherited environment\n")
+		}
+		return os.Environ(), nil
+	}
+
+	envSetupStart := time.Now()
+	env, err := te.executeEnvSetup(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute env setup script: %w", err)
+	}
+	te.ctx.TimeEnvSetupMs = time.Since(envSetupStart).Milliseconds()
+	return env, nil
 }
 
 // executeEnvSetup executes the environment setup script if it is defined
 // in the .uber configuration file and returns the resulting environment.
-func (te *ToolExecutor) executeEnvSetup() ([]string, error) {
-	if te.ctx.Config.EnvSetup == "" {
+func (te *ToolExecutor) executeEnvSetup(ctx context.Context) ([]string, error) {
+	if te.ctx.Config.EnvSetup == "" && te.ctx.Config.EnvSetupCmd == "" {
 		return nil, nil // No script defined
 	}
+	if os.Getenv(inEnvSetupEnvVar) != "" {
+		return nil, withKind(ErrConfig, fmt.Errorf("uber was run from an env setup script (%s is set) and refuses to run env setup again, which would recurse forever; don't call uber from env_setup", inEnvSetupEnvVar))
+	}
 
-	// Resolve the script path
-	scriptPath := te.ctx.Config.EnvSetup
-	if !filepath.IsAbs(scriptPath) {
-		scriptPath = filepath.Join(te.ctx.Root, scriptPath)
+	// source names the env setup in messages and argv runs it
+	var source, fingerprint string
+	var argv []string
+	if command := te.ctx.Config.EnvSetupCmd; command != "" {
+		// An inline command is run by the shell
+		source = command
+		argv = []string{te.shell(), "-c", command}
+		fingerprint = command
+	} else {
+		// Resolve the script path
+		scriptPath := te.ctx.Config.EnvSetup
+		if !filepath.IsAbs(scriptPath) {
+			scriptPath = filepath.Join(te.ctx.Root, scriptPath)
+		}
+
+		// Check if the script exists and is executable
+		if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
+			return nil, withKind(ErrNotFound, fmt.Errorf("script '%s' not found", scriptPath))
+		}
+		if !te.isExecutable(scriptPath) {
+			return nil, withKind(ErrNotExecutable, fmt.Errorf("script '%s' is not executable", scriptPath))
+		}
+		source = scriptPath
+		argv = []string{scriptPath}
+		fingerprint = fingerprintFile(scriptPath)
 	}
 
-	// Check if the script exists and is executable
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("script '%s' not found", scriptPath)
+	// Reuse the cached output of the script if none of its inputs changed
+	var cache *envCache
+	var scriptVars map[string]string
+	if len(te.ctx.Config.EnvCacheInputs) > 0 {
+		cache = te.newEnvCache(source, fingerprint)
+		scriptVars = cache.lookup()
 	}
-	if !te.isExecutable(scriptPath) {
-		return nil, fmt.Errorf("script '%s' is not executable", scriptPath)
+
+	if scriptVars == nil {
+		var succeeded bool
+		var err error
+		scriptVars, succeeded, err = te.runEnvSetupScript(ctx, source, argv)
+		if err != nil {
+			return nil, err
+		}
+		// Don't cache the partial output of a failed script
+		if cache != nil && succeeded {
+			if err := cache.store(scriptVars); err != nil && te.ctx.Verbose {
+				ColorPrint(ColorYellow, fmt.Sprintf("Warning: failed to write env setup cache: %v\n", err))
+			}
+		}
 	}
 
+	// The current environment
+	currentEnv := te.prepareEnvironment()
+	envMap := make(map[string]string)
+	for _, v := range currentEnv {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) == 2 {
+			envMap[parts[0]] = parts[1]
+		}
+	}
+
+	// Update the environment with the variables set by the script
+	for key, value := range scriptVars {
+		envMap[key] = value
+	}
+
+	// Convert the map back to a slice of strings
+	var newEnv []string
+	for key, value := range envMap {
+		newEnv = append(newEnv, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	return newEnv, nil
+}
+
+// runEnvSetupScript runs argv, the env setup script or env_setup_cmd, and
+// returns the variables it printed. source names it in messages. When
+// env_setup_on_error is "warn", a non-zero exit is reported as a warning and
+// the variables printed before the failure are returned with succeeded set
+// to false.
+func (te *ToolExecutor) runEnvSetupScript(ctx context.Context, source string, argv []string) (scriptVars map[string]string, succeeded bool, err error) {
 	// Execute the script directly. It is expected to print environment variables
 	// to stdout, one per line, in KEY=VALUE format.
-	cmd := exec.Command(scriptPath)
-	cmd.Env = te.prepareEnvironment()
+	cmd := commandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = te.prepareEnvSetupEnvironment()
 
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
+	stdout := &cappedBuffer{limit: envSetupMaxOutputBytes}
+	cmd.Stdout = stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
 	if te.ctx.Verbose {
-		ColorPrint(ColorCyan, fmt.Sprintf("Executing env setup script: %s\n", scriptPath))
+		ColorPrint(ColorCyan, fmt.Sprintf("Executing env setup script: %s\n", source))
 	}
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("error executing env setup script '%s': %w", scriptPath, err)
+	// Show that uber is still working while a slow script runs. The script
+	// keeps the terminal as its stderr, so its colors and prompts work.
+	var indicator *progress
+	if IsTTYStderr() {
+		indicator = startProgress(os.Stderr, messagePrefix+"Running env setup", progressDelay)
 	}
 
-	// The current environment
-	currentEnv := te.prepareEnvironment()
-	envMap := make(map[string]string)
-	for _, v := range currentEnv {
-		parts := strings.SplitN(v, "=", 2)
-		if len(parts) == 2 {
-			envMap[parts[0]] = parts[1]
+	succeeded = true
+	start := time.Now()
+	err = te.traceRun(tracePhaseEnvSetup, cmd, cmd.Run)
+	if indicator != nil {
+		indicator.stop()
+	}
+	te.printPhaseSummary(tracePhaseEnvSetup, err, time.Since(start))
+	if err != nil {
+		if stdout.exceeded {
+			return nil, false, fmt.Errorf("env setup script '%s' printed more than %d bytes to stdout", source, envSetupMaxOutputBytes)
+		}
+		// Only a script that ran and exited non-zero can be tolerated
+		var exitErr *exec.ExitError
+		if te.ctx.Config.EnvSetupOnError != config.EnvSetupOnErrorWarn || !errors.As(err, &exitErr) || ctx.Err() != nil {
+			return nil, false, fmt.Errorf("error executing env setup script '%s': %w", source, err)
+		}
+		ColorPrintWarning(fmt.Sprintf("Warning: env setup script '%s' failed (%v); continuing with the variables it printed\n", source, err))
+		succeeded = false
+	}
+
+	// Parse the output of the script
+	scriptVars = make(map[string]string)
+	if err := te.parseEnvOutput(&stdout.buf, source, scriptVars); err != nil {
+		return nil, false, err
+	}
+
+	// Scripts that write their variables to a file instead of stdout name
+	// it in env_setup_output_file. Its variables override those printed.
+	if outputFile := te.ctx.Config.EnvSetupOutputFile; outputFile != "" {
+		if err := te.readEnvSetupOutputFile(outputFile, source, scriptVars); err != nil {
+			return nil, false, err
 		}
 	}
 
-	// Parse the output of the script and update the environment
-	scanner := bufio.NewScanner(&stdout)
+	return scriptVars, succeeded, nil
+}
+
+// readEnvSetupOutputFile parses the KEY=VALUE lines of the file the env setup
+// script wrote its variables to into envMap. path is relative to the project
+// root.
+func (te *ToolExecutor) readEnvSetupOutputFile(path, scriptPath string, envMap map[string]string) error {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(te.ctx.Root, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return withKind(ErrNotFound, fmt.Errorf("env_setup_output_file '%s' not found after running env setup script '%s'", path, scriptPath))
+		}
+		return fmt.Errorf("failed to read env_setup_output_file: %w", err)
+	}
+	defer file.Close()
+
+	if te.ctx.Verbose {
+		ColorPrint(ColorCyan, fmt.Sprintf("Reading env setup variables from %s\n", path))
+	}
+	return te.parseEnvOutput(file, scriptPath, envMap)
+}
+
+// shell returns the shell that runs inline commands: default_shell, or
+// /bin/sh if it isn't set.
+func (te *ToolExecutor) shell() string {
+	if te.ctx.Config.DefaultShell != "" {
+		return te.ctx.Config.DefaultShell
+	}
+	return "/bin/sh"
+}
+
+// parseEnvOutput parses the KEY=VALUE lines printed by the env setup script
+// into envMap. A key printed more than once is reported as a warning in
+// verbose mode, or returned as an error in strict mode.
+func (te *ToolExecutor) parseEnvOutput(r io.Reader, scriptPath string, envMap map[string]string) error {
+	seen := make(map[string]string)
+
+	maxLineBytes := te.ctx.Config.EnvSetupMaxLineBytes
+	if maxLineBytes <= 0 {
+		maxLineBytes = defaultEnvSetupMaxLineBytes
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, maxLineBytes)), maxLineBytes)
 	for scanner.Scan() {
-		line := scanner.Text()
-		if !strings.Contains(line, "=") {
+		key, value, ok := parseEnvLine(scanner.Text())
+		if !ok {
 			continue
 		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			key, value := parts[0], parts[1]
-			envMap[key] = value
+		if previous, ok := seen[key]; ok {
+			if te.ctx.Config.Strict {
+				return fmt.Errorf("env setup script '%s' set '%s' more than once ('%s' and '%s')", scriptPath, key, previous, value)
+			}
+			if te.ctx.Verbose {
+				ColorPrint(ColorYellow, fmt.Sprintf("Warning: env setup script set '%s' more than once, '%s' overridden by '%s'\n", key, previous, value))
+			}
 		}
+		seen[key] = value
+		envMap[key] = value
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading env setup script output: %w", err)
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("env setup script '%s' printed a line longer than %d bytes (see env_setup_max_line_bytes)", scriptPath, maxLineBytes)
+		}
+		return fmt.Errorf("error reading env setup script output: %w", err)
 	}
 
-	// Convert the map back to a slice of strings
-	var newEnv []string
-	for key, value := range envMap {
-		newEnv = append(newEnv, fmt.Sprintf("%s=%s", key, value))
+	return nil
+}
+
+// parseEnvLine splits a KEY=VALUE line printed by the env setup script. An
+// optional leading "export " is ignored and matching single or double quotes
+// around the value are removed, so scripts can print shell syntax such as
+// export FLAGS="-a=1 -b=2". ok is false for lines without a '='.
+func parseEnvLine(line string) (key, value string, ok bool) {
+	if rest, found := strings.CutPrefix(line, "export "); found {
+		line = strings.TrimLeft(rest, " \t")
 	}
 
-	return newEnv, nil
+	key, value, ok = strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return key, value, true
+}
+
+// cappedBuffer is a buffer that fails writes which would grow it beyond limit.
+type cappedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.limit {
+		b.exceeded = true
+		return 0, fmt.Errorf("output exceeds %d bytes", b.limit)
+	}
+	return b.buf.Write(p)
 }
 
 // executeTool executes the tool with the given arguments
-func (te *ToolExecutor) executeTool(executablePath string, args []string, env []string) error {
-	// Create the command
-	cmd := exec.Command(executablePath, args...)
+func (te *ToolExecutor) executeTool(ctx context.Context, executablePath string, args []string, env []string) error {
+	// A detached tool must outlive uber, so it isn't tied to the context
+	if te.ctx.Detach {
+		ctx = context.Background()
+	}
 
-	// Set up stdin, stdout, and stderr to be the same as the parent process
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Run scripts without a shebang with the default shell if there is one
+	argv := append([]string{executablePath}, args...)
+	if shell := te.ctx.Config.DefaultShell; shell != "" && needsShell(executablePath) {
+		if te.ctx.Verbose {
+			ColorPrint(ColorGreen, fmt.Sprintf("No shebang, running with default shell: %s\n", shell))
+		}
+		argv = append([]string{shell}, argv...)
+	}
 
-	// Set environment variables for context
-	if env != nil {
-		cmd.Env = env
-	} else {
-		cmd.Env = te.prepareEnvironment()
+	if env == nil {
+		env = te.prepareEnvironment()
+	}
+
+	// Create the command, prefixed by the wrapper if there is one. The
+	// wrapper is looked up in the tool's PATH, which the env setup may have
+	// changed.
+	if len(te.ctx.Wrapper) > 0 {
+		wrapper, err := lookPathIn(te.ctx.Wrapper[0], env)
+		if err != nil {
+			return err
+		}
+		argv = append(append([]string{wrapper}, te.ctx.Wrapper[1:]...), argv...)
+	}
+
+	// Lower the priority of the tool, and of the wrapper, if asked to
+	nice := te.niceness()
+	niced, prefixed := false, false
+	if nice != 0 {
+		prefix, ok, err := niceCommand(nice)
+		if err != nil {
+			return withKind(ErrConfig, err)
+		}
+		// Errors starting the program would come from the prefix, so they
+		// are reported up front
+		if prefix != nil {
+			if _, err := exec.LookPath(argv[0]); err != nil {
+				return explainStartError(executablePath, err)
+			}
+			argv = append(prefix, argv...)
+			prefixed = true
+		}
+		if ok && te.ctx.Verbose {
+			ColorPrint(ColorGreen, fmt.Sprintf("Nice adjustment: %d\n", nice))
+		}
+		niced = ok
 	}
+	cmd := commandContext(ctx, argv[0], argv[1:]...)
+
+	// Run the tool in its configured working directory
+	if te.ctx.ToolConfig.Cwd != "" {
+		cmd.Dir = te.ctx.ToolConfig.Cwd
+		if !filepath.IsAbs(cmd.Dir) {
+			cmd.Dir = filepath.Join(te.ctx.Root, cmd.Dir)
+		}
+		if te.ctx.Verbose {
+			ColorPrint(ColorGreen, fmt.Sprintf("Working directory: %s\n", cmd.Dir))
+		}
+	}
+
+	// Set up stdin, stdout, and stderr to be the same as the parent process.
+	// A benchmarked tool gets no input and its output is discarded.
+	if te.ctx.Benchmark == 0 {
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	// Set environment variables for context
+	cmd.Env = env
 
 	// Execute the command
 	if te.ctx.Verbose {
+		if len(te.ctx.Wrapper) > 0 {
+			ColorPrint(ColorGreen, fmt.Sprintf("Wrapper: %v\n", te.ctx.Wrapper))
+		}
 		ColorPrint(ColorGreen, fmt.Sprintf("Executing: %s %v\n", executablePath, args))
 		ColorPrint(ColorGreen, fmt.Sprintf("UBER_BIN_PATH=%s\n", te.ctx.UberBinPath))
 		ColorPrint(ColorGreen, fmt.Sprintf("UBER_PROJECT_ROOT=%s\n", te.ctx.Root))
 	}
 
-	return cmd.Run()
+	// Print a command line that reproduces this invocation from a shell
+	if te.ctx.Repro {
+		fmt.Fprintln(os.Stderr, te.reproCommand(cmd))
+	}
+
+	// Save the invocation for --replay
+	if te.ctx.Record != "" {
+		if err := writeRecording(te.ctx.Record, cmd); err != nil {
+			return err
+		}
+	}
+
+	// Apply the configured umask while the tool is started so that it is
+	// inherited by the child process
+	mask, ok, err := te.ctx.Config.ParseUmask()
+	if err != nil {
+		return withKind(ErrConfig, err)
+	}
+	if ok {
+		if te.ctx.Verbose {
+			ColorPrint(ColorGreen, fmt.Sprintf("Umask: %03o\n", mask))
+		}
+		restore := applyUmask(mask)
+		defer restore()
+	}
+
+	// Cap the memory of the tool and its children when max_memory is set
+	cgroup, err := te.memoryLimitCgroup()
+	if err != nil {
+		return err
+	}
+	if cgroup != nil {
+		cgroup.apply(cmd)
+	}
+
+	run := cmd.Run
+	if te.ctx.Detach {
+		run = func() error { return te.startDetached(cmd) }
+	} else if te.ctx.PTY && ptyAvailable() {
+		if te.ctx.Verbose {
+			ColorPrint(ColorGreen, "Running in a pseudo-terminal\n")
+		}
+		run = func() error { return runWithPTY(cmd) }
+	} else if len(te.ctx.ForwardSignals) > 0 {
+		run = func() error { return runForwardingSignals(cmd, te.ctx.ForwardSignals) }
+	}
+	if cgroup != nil {
+		limitedRun := run
+		run = func() error { return te.releaseMemoryLimit(cgroup, limitedRun()) }
+	}
+	if niced {
+		nicedRun := run
+		run = func() error { return runNiced(nice, nicedRun) }
+	}
+
+	if err := te.traceRun(tracePhaseTool, cmd, run); err != nil {
+		if len(te.ctx.Wrapper) > 0 || prefixed {
+			return err
+		}
+		return explainStartError(executablePath, err)
+	}
+
+	return nil
+}
+
+// niceness returns the nice adjustment to run the tool with: --nice if it
+// was given, otherwise the tool's nice option. 0 keeps uber's priority.
+func (te *ToolExecutor) niceness() int {
+	if te.ctx.Nice != nil {
+		return *te.ctx.Nice
+	}
+	return te.ctx.ToolConfig.Nice
+}
+
+// reportingCmds returns the reporting commands to run after the tool
+// finished with toolErr: reporting_cmd when the tool succeeded or
+// report_on_failure is set, followed by after_success_cmd or
+// after_failure_cmd.
+func (te *ToolExecutor) reportingCmds(toolErr error) []string {
+	var commands []string
+	if toolErr == nil || te.ctx.Config.ReportOnFailure {
+		commands = append(commands, te.ctx.Config.ReportingCommands()...)
+	}
+	if toolErr == nil {
+		commands = append(commands, te.ctx.Config.AfterSuccessCmd...)
+	} else {
+		commands = append(commands, te.ctx.Config.AfterFailureCmd...)
+	}
+	return commands
 }
 
-// executeReportingCmd runs the reporting command if it's defined in the .uber configuration
-func (te *ToolExecutor) executeReportingCmd() error {
-	if te.ctx.Config.ReportingCmd == "" {
-		return nil // No reporting command defined
+// executeReportingCmds runs the given reporting commands in order. A failing
+// command doesn't prevent the remaining ones from running; all failures are
+// returned together.
+func (te *ToolExecutor) executeReportingCmds(ctx context.Context, commands []string) error {
+	var errs []error
+	for _, reportingCmd := range commands {
+		if reportingCmd == "" {
+			continue
+		}
+
+		// Name the command when there are several to tell them apart
+		phase := tracePhaseReporting
+		if len(commands) > 1 {
+			phase = fmt.Sprintf("%s '%s'", tracePhaseReporting, reportingCmd)
+		}
+		start := time.Now()
+		err := te.runReportingCmd(ctx, reportingCmd)
+		te.printPhaseSummary(phase, err, time.Since(start))
+		if err != nil {
+			errs = append(errs, err)
+		}
 	}
 
+	return errors.Join(errs...)
+}
+
+// printPhaseSummary prints, in verbose mode, how a phase that runs a
+// subprocess ended and how long it took, e.g. "env_setup: ok (812ms)" or
+// "reporting: failed exit 2 (14ms)".
+func (te *ToolExecutor) printPhaseSummary(phase string, err error, elapsed time.Duration) {
+	if !te.ctx.Verbose {
+		return
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		ColorPrint(ColorGreen, fmt.Sprintf("%s: ok (%dms)\n", phase, elapsed.Milliseconds()))
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		ColorPrint(ColorYellow, fmt.Sprintf("%s: failed exit %d (%dms)\n", phase, exitErr.ExitCode(), elapsed.Milliseconds()))
+	default:
+		ColorPrint(ColorYellow, fmt.Sprintf("%s: failed (%dms): %v\n", phase, elapsed.Milliseconds(), err))
+	}
+}
+
+// reportingDisabledReason returns the override that disabled the reporting
+// command for this run, or an empty string if reporting is enabled.
+func (te *ToolExecutor) reportingDisabledReason() string {
+	if te.ctx.NoReporting {
+		return "--no-reporting"
+	}
+	if disabled, err := strconv.ParseBool(os.Getenv("UBER_NO_REPORTING")); err == nil && disabled {
+		return "UBER_NO_REPORTING"
+	}
+	return ""
+}
+
+// runReportingCmd runs a single reporting command with the reporting environment
+func (te *ToolExecutor) runReportingCmd(ctx context.Context, reportingCmd string) error {
 	// Resolve the reporting command path
-	executablePath := te.ctx.Config.ReportingCmd
+	executablePath := reportingCmd
 	if !filepath.IsAbs(executablePath) {
 		executablePath = filepath.Join(te.ctx.Root, executablePath)
 	}
@@ -275,7 +994,7 @@ func (te *ToolExecutor) executeReportingCmd() error {
 	}
 
 	// The reporting command doesn't take arguments from the command line
-	cmd := exec.Command(executablePath)
+	cmd := commandContext(ctx, executablePath)
 
 	// The environment is prepared with additional reporting variables
 	cmd.Env = te.prepareReportingEnvironment()
@@ -289,13 +1008,13 @@ func (te *ToolExecutor) executeReportingCmd() error {
 	if te.ctx.Verbose {
 		ColorPrint(ColorCyan, fmt.Sprintf("Executing reporting command: %s\n", executablePath))
 		for _, envVar := range cmd.Env {
-			if strings.HasPrefix(envVar, "UBER_TIMING") || strings.HasPrefix(envVar, "UBER_EXECUTED_") || strings.HasPrefix(envVar, "UBER_ARGS") {
+			if strings.HasPrefix(envVar, "UBER_TIMING") || strings.HasPrefix(envVar, "UBER_EXECUTED_") || strings.HasPrefix(envVar, "UBER_ARGS") || strings.HasPrefix(envVar, "UBER_TOOL_EXIT_CODE") {
 				ColorPrint(ColorCyan, fmt.Sprintf("  %s\n", envVar))
 			}
 		}
 	}
 
-	err := cmd.Run()
+	err := te.traceRun(tracePhaseReporting, cmd, cmd.Run)
 	if te.ctx.Verbose && err != nil {
 		ColorPrint(ColorYellow, fmt.Sprintf("Reporting command STDOUT: %s\n", stdout.String()))
 		ColorPrint(ColorYellow, fmt.Sprintf("Reporting command STDERR: %s\n", stderr.String()))
@@ -308,6 +1027,66 @@ func (te *ToolExecutor) executeReportingCmd() error {
 	return nil
 }
 
+// commandContext creates a command that is interrupted when ctx is canceled
+// and killed if it has not exited within shutdownGracePeriod.
+func commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		// The delay is only set once canceled: otherwise it would also cut off
+		// reading the output of a command that exited normally but left a
+		// background process holding its stdout, as an env setup starting a
+		// daemon does. os/exec reads it after calling Cancel.
+		cmd.WaitDelay = shutdownGracePeriod
+
+		// A Ctrl-C already interrupted a command in uber's process group, and
+		// a second interrupt makes many tools quit without cleaning up
+		if interruptedFromTerminal(ctx) && !ownProcessGroup(cmd) {
+			return nil
+		}
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	return cmd
+}
+
+// lookPathIn is exec.LookPath searching the PATH set in env instead of
+// uber's own. Names containing a path separator are returned as they are.
+func lookPathIn(file string, env []string) (string, error) {
+	if filepath.Base(file) != file {
+		return file, nil
+	}
+	path, ok := parseEnv(env)["PATH"]
+	if !ok {
+		return exec.LookPath(file)
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		// Keep a separator in the candidate, e.g. "./tool" for ".", or
+		// LookPath would search uber's own PATH for it. The result is made
+		// absolute since the tool may run in another directory.
+		candidate := dir + string(filepath.Separator) + file
+		if found, err := exec.LookPath(candidate); err == nil {
+			return filepath.Abs(found)
+		}
+	}
+	return "", withKind(ErrNotFound, &exec.Error{Name: file, Err: exec.ErrNotFound})
+}
+
+// prepareEnvSetupEnvironment creates the environment for the env setup
+// script. Besides the variables every tool gets, it describes the tool about
+// to run like the reporting environment does, so the script can configure
+// each tool differently.
+func (te *ToolExecutor) prepareEnvSetupEnvironment() []string {
+	env := append(te.prepareEnvironment(),
+		fmt.Sprintf("UBER_EXECUTED_COMMAND=%s", te.ctx.Command),
+		fmt.Sprintf("UBER_EXECUTED_TOOL_PATH=%s", te.ctx.FoundToolPath),
+		fmt.Sprintf("UBER_ARGS=%s", strings.Join(te.ctx.RemainingArgs, " ")),
+		fmt.Sprintf("%s=1", inEnvSetupEnvVar),
+	)
+	return append(env, te.sequenceEnvironment()...)
+}
+
 // prepareReportingEnvironment creates the environment for the reporting command
 func (te *ToolExecutor) prepareReportingEnvironment() []string {
 	// Start with the base environment
@@ -324,7 +1103,10 @@ func (te *ToolExecutor) prepareReportingEnvironment() []string {
 		fmt.Sprintf("UBER_TIMING_ENV_SETUP_MS=%d", te.ctx.TimeEnvSetupMs),
 		fmt.Sprintf("UBER_TIMING_EXECUTION_MS=%d", te.ctx.TimeExecToolMs),
 		fmt.Sprintf("UBER_TOTAL_TIME_MS=%d", totalTime),
+		fmt.Sprintf("UBER_TOOL_EXIT_CODE=%d", te.ctx.ToolExitCode),
+		fmt.Sprintf("UBER_ORIGINAL_ARGV=%s", strings.Join(te.ctx.OriginalArgs, originalArgvSeparator)),
 	)
+	env = append(env, te.sequenceEnvironment()...)
 
 	return env
 }
@@ -346,9 +1128,101 @@ func (te *ToolExecutor) prepareEnvironment() []string {
 		env = append(env, fmt.Sprintf("UBER_GLOBAL_COMMAND_ARGS=%s", te.ctx.GlobalCommandArgs))
 	}
 
+	// Export git metadata if enabled
+	if te.ctx.Config.GitEnv {
+		git := te.gitMetadata()
+		env = append(env,
+			fmt.Sprintf("UBER_GIT_SHA=%s", git.SHA),
+			fmt.Sprintf("UBER_GIT_BRANCH=%s", git.Branch),
+		)
+	}
+
+	// Tell tools that read COLUMNS and LINES the size of the terminal
+	if te.ctx.Config.TerminalSizeEnv {
+		env = append(env, terminalSizeEnvironment()...)
+	}
+
+	// Let tools call their siblings by name
+	if te.ctx.Config.PrependToolPaths {
+		env = append(env, fmt.Sprintf("PATH=%s", te.pathWithToolPaths(os.Getenv("PATH"))))
+	}
+
+	// Point the usual temporary directory variables at the per-run directory
+	if te.ctx.TmpDir != "" {
+		env = append(env,
+			fmt.Sprintf("TMPDIR=%s", te.ctx.TmpDir),
+			fmt.Sprintf("TMP=%s", te.ctx.TmpDir),
+			fmt.Sprintf("TEMP=%s", te.ctx.TmpDir),
+		)
+	}
+
 	return env
 }
 
+// checkRequiredEnv returns an error naming every variable in the tool's
+// requires_env that is unset or empty in env, the environment the tool will
+// run with.
+func (te *ToolExecutor) checkRequiredEnv(toolName string, env []string) error {
+	required := te.ctx.ToolConfig.RequiresEnv
+	if len(required) == 0 {
+		return nil
+	}
+	if env == nil {
+		env = te.prepareEnvironment()
+	}
+
+	// Later entries override earlier ones, as they do for the tool
+	values := make(map[string]string, len(env))
+	for _, entry := range env {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			values[key] = value
+		}
+	}
+	var missing []string
+	for _, variable := range required {
+		if values[variable] == "" {
+			missing = append(missing, variable)
+		}
+	}
+
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return withKind(ErrConfig, fmt.Errorf("missing required environment variable %s for tool '%s'", missing[0], toolName))
+	default:
+		return withKind(ErrConfig, fmt.Errorf("missing required environment variables %s for tool '%s'", strings.Join(missing, ", "), toolName))
+	}
+}
+
+// pathWithToolPaths returns path with the tool_paths directories prepended in
+// declaration order. Entries that point at a single file, don't exist or are
+// already on path are skipped.
+func (te *ToolExecutor) pathWithToolPaths(path string) string {
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(path) {
+		seen[dir] = true
+	}
+
+	var dirs []string
+	for _, toolPath := range te.ctx.Config.ToolPaths {
+		dir := te.resolveToolFullPath(toolPath, "")
+		if seen[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+
+	if path != "" {
+		dirs = append(dirs, path)
+	}
+	return strings.Join(dirs, string(filepath.ListSeparator))
+}
+
 // ListAvailableTools scans all configured tool paths and lists all executable tools
 func (te *ToolExecutor) ListAvailableTools() error {
 	// Get all available tools
@@ -360,36 +1234,47 @@ func (te *ToolExecutor) ListAvailableTools() error {
 	fmt.Println("Available tools:")
 	fmt.Println()
 
-	// Group tools by path and then by base name
+	// Group tools by path and then by base name. The paths keep the order of
+	// tool_paths so that the output is the same on every run.
+	var paths []string
 	toolsByPath := make(map[string][]AvailableTool)
 	for _, tool := range availableTools {
+		if _, ok := toolsByPath[tool.Path]; !ok {
+			paths = append(paths, tool.Path)
+		}
 		toolsByPath[tool.Path] = append(toolsByPath[tool.Path], tool)
 	}
 
-	for path, tools := range toolsByPath {
-		ColorPrint(ColorCyan, fmt.Sprintf("From %s:\n", path))
-
-		// Group by base name
-		baseNameMap := make(map[string][]string)
-		for _, tool := range tools {
-			base := strings.TrimSuffix(tool.Name, filepath.Ext(tool.Name))
-			baseNameMap[base] = append(baseNameMap[base], tool.Name)
-		}
-
-		// Print tools, using base name if unambiguous
-		var printed []string
-		for base, names := range baseNameMap {
-			if len(names) == 1 {
-				printed = append(printed, base)
-			} else {
-				// Multiple tools with same base, print all full names
-				printed = append(printed, names...)
+	for _, path := range paths {
+		tools := toolsByPath[path]
+		// The listing is output rather than a message, so it isn't prefixed
+		colorPrint(os.Stdout, IsTTY(), ColorCyan, fmt.Sprintf("From %s:\n", path))
+
+		// Inline commands are listed by name in the order they are defined
+		if path == inlineCommandsPath {
+			for _, tool := range tools {
+				if te.ctx.Long {
+					command, _ := te.ctx.Config.Command(tool.Name)
+					source := te.inlineCommandSource(command)
+					if rel, err := filepath.Rel(te.ctx.Root, source); err == nil {
+						source = rel
+					}
+					fmt.Printf("* %s  %s: %s%s\n", tool.Name, source, command.Run, te.deprecatedMarker(tool.Name))
+					continue
+				}
+				fmt.Printf("  %s%s\n", tool.Name, te.deprecatedMarker(tool.Name))
 			}
+			fmt.Println()
+			continue
 		}
-		// Sort for consistent output
-		sort.Strings(printed)
+
+		printed, fileNames := toolCommandNames(tools)
 		for _, name := range printed {
-			fmt.Printf("  %s\n", name)
+			if te.ctx.Long {
+				te.printLongToolEntry(path, name, fileNames[name])
+				continue
+			}
+			fmt.Printf("  %s%s\n", name, te.deprecatedMarker(name))
 		}
 		fmt.Println()
 	}
@@ -397,6 +1282,68 @@ func (te *ToolExecutor) ListAvailableTools() error {
 	return nil
 }
 
+// toolCommandNames returns the sorted names to run the tools found in a
+// single tool path by: the base name if it is unambiguous, otherwise the full
+// file names. fileNames maps each name to the file it refers to.
+func toolCommandNames(tools []AvailableTool) (names []string, fileNames map[string]string) {
+	// Group by base name
+	baseNameMap := make(map[string][]string)
+	for _, tool := range tools {
+		base := strings.TrimSuffix(tool.Name, filepath.Ext(tool.Name))
+		baseNameMap[base] = append(baseNameMap[base], tool.Name)
+	}
+
+	fileNames = make(map[string]string)
+	for base, files := range baseNameMap {
+		if len(files) == 1 {
+			names = append(names, base)
+			fileNames[base] = files[0]
+		} else {
+			// Multiple tools with same base, use all full names
+			names = append(names, files...)
+			for _, file := range files {
+				fileNames[file] = file
+			}
+		}
+	}
+	// Sort for consistent output
+	sort.Strings(names)
+	return names, fileNames
+}
+
+// printLongToolEntry prints a --list-tools --long line for the tool that
+// fileName holds in toolPath: its full path, marked with a '*' if running
+// 'uber name' selects it and with the selected path if it is shadowed.
+func (te *ToolExecutor) printLongToolEntry(toolPath, name, fileName string) {
+	fullPath := te.toolExecutablePath(toolPath, fileName)
+
+	var selectedPath string
+	if command, ok := te.ctx.Config.Command(name); ok {
+		selectedPath = fmt.Sprintf("the [[command]] in %s", te.inlineCommandSource(command))
+	} else if selectedToolPath, resolvedName, ok := te.locateTool(name); ok {
+		selectedPath = te.toolExecutablePath(selectedToolPath, resolvedName)
+	}
+
+	marker := te.deprecatedMarker(name)
+	switch selectedPath {
+	case fullPath:
+		fmt.Printf("* %s  %s%s\n", name, fullPath, marker)
+	case "":
+		fmt.Printf("  %s  %s (not selected by 'uber %s')%s\n", name, fullPath, name, marker)
+	default:
+		fmt.Printf("  %s  %s (shadowed by %s)%s\n", name, fullPath, selectedPath, marker)
+	}
+}
+
+// deprecatedMarker returns the suffix that marks a deprecated tool in
+// --list-tools, or an empty string if the tool isn't deprecated.
+func (te *ToolExecutor) deprecatedMarker(name string) string {
+	if te.ctx.Config.Tool(name).Deprecated != "" {
+		return " (deprecated)"
+	}
+	return ""
+}
+
 func (te *ToolExecutor) resolveToolFullPath(toolPath, toolName string) string {
 	if filepath.IsAbs(toolPath) {
 		return filepath.Join(toolPath, toolName)
@@ -404,6 +1351,22 @@ func (te *ToolExecutor) resolveToolFullPath(toolPath, toolName string) string {
 	return filepath.Join(te.ctx.Root, toolPath, toolName)
 }
 
+// isFileToolPath reports whether a tool_paths entry points directly at a file
+// rather than a directory of tools.
+func (te *ToolExecutor) isFileToolPath(toolPath string) bool {
+	info, err := os.Stat(te.resolveToolFullPath(toolPath, ""))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// toolExecutablePath returns the full path of a resolved tool within a tool path.
+// For tool_paths entries that point at a file, this is the file itself.
+func (te *ToolExecutor) toolExecutablePath(toolPath, resolvedName string) string {
+	if te.isFileToolPath(toolPath) {
+		return te.resolveToolFullPath(toolPath, "")
+	}
+	return te.resolveToolFullPath(toolPath, resolvedName)
+}
+
 func (te *ToolExecutor) findExecutableInPath(toolPath, toolName string) (string, error) {
 	fullPath := te.resolveToolFullPath(toolPath, toolName)
 
@@ -457,7 +1420,15 @@ func (te *ToolExecutor) listExecutablesInPath(toolPath string) ([]string, error)
 		fullPath = filepath.Join(te.ctx.Root, toolPath)
 	}
 
-	files, err := os.ReadDir(fullPath)
+	// A tool path can point directly at a single executable
+	if te.isFileToolPath(toolPath) {
+		if te.isExecutable(fullPath) {
+			return []string{filepath.Base(fullPath)}, nil
+		}
+		return nil, nil
+	}
+
+	files, err := te.readDir(fullPath)
 	if err != nil {
 		// Suppress error if path does not exist, as it's a common scenario
 		if os.IsNotExist(err) {
@@ -480,6 +1451,12 @@ func (te *ToolExecutor) listExecutablesInPath(toolPath string) ([]string, error)
 	return executables, nil
 }
 
+// isHiddenTool reports whether name is a dotfile that isn't treated as a tool
+// because include_hidden is off.
+func (te *ToolExecutor) isHiddenTool(name string) bool {
+	return strings.HasPrefix(name, ".") && !te.ctx.Config.IncludeHidden
+}
+
 // isExecutable checks if a file at the given path is an executable.
 func (te *ToolExecutor) isExecutable(filePath string) bool {
 	info, err := os.Stat(filePath)
@@ -492,33 +1469,80 @@ func (te *ToolExecutor) isExecutable(filePath string) bool {
 
 // ToolMatch represents a potential tool match with its full path and priority
 type ToolMatch struct {
-	Name     string
-	Path     string
-	FullPath string
-	Priority int // Lower number = higher priority
+	Name       string
+	Path       string
+	FullPath   string
+	Priority   int // Lower number = higher priority
+	Executable bool
 }
 
 // resolveToolName handles the extension resolution logic
 // Returns the resolved tool name and any error
 func (te *ToolExecutor) resolveToolName(toolPath, requestedName string) (string, error) {
+	candidates, err := te.toolCandidates(toolPath, requestedName)
+	if err != nil {
+		return "", err
+	}
+	return chooseToolMatch(toolPath, requestedName, candidates)
+}
+
+// validToolName reports whether name is a bare file name that stays inside
+// the tool path it is joined to.
+func validToolName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// toolCandidates returns the files in a tool path whose name matches the
+// requested tool name, sorted by priority. Files that aren't executable are
+// included with Executable set to false so that callers can explain why they
+// were skipped.
+func (te *ToolExecutor) toolCandidates(toolPath, requestedName string) ([]ToolMatch, error) {
+	if !validToolName(requestedName) {
+		return nil, fmt.Errorf("invalid tool name '%s'", requestedName)
+	}
+	if te.isHiddenTool(requestedName) {
+		return nil, nil
+	}
+
+	// A tool path pointing at a file provides a single tool, matched by its
+	// file name with or without the extension
+	if te.isFileToolPath(toolPath) {
+		fullPath := te.resolveToolFullPath(toolPath, "")
+		fileName := filepath.Base(fullPath)
+		if requestedName != fileName && (te.ctx.StrictMatch || requestedName != strings.TrimSuffix(fileName, filepath.Ext(fileName))) {
+			return nil, nil
+		}
+		return []ToolMatch{{
+			Name:       fileName,
+			Path:       toolPath,
+			FullPath:   fullPath,
+			Executable: te.isExecutable(fullPath),
+		}}, nil
+	}
+
 	// If the requested name already has an extension, use it as-is
 	if filepath.Ext(requestedName) != "" {
 		fullPath := te.resolveToolFullPath(toolPath, requestedName)
-		if te.isExecutable(fullPath) {
-			return requestedName, nil
+		if info, err := os.Stat(fullPath); err != nil || !info.Mode().IsRegular() {
+			return nil, nil
 		}
-		return "", fmt.Errorf("tool '%s' not found in '%s'", requestedName, toolPath)
+		return []ToolMatch{{
+			Name:       requestedName,
+			Path:       toolPath,
+			FullPath:   fullPath,
+			Executable: te.isExecutable(fullPath),
+		}}, nil
 	}
 
-	// Find all executable files that could match this name
+	// Find all files that could match this name
 	var matches []ToolMatch
 
-	files, err := os.ReadDir(te.resolveToolFullPath(toolPath, ""))
+	files, err := te.readDir(te.resolveToolFullPath(toolPath, ""))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", fmt.Errorf("tool path '%s' does not exist", toolPath)
+			return nil, fmt.Errorf("tool path '%s' does not exist", toolPath)
 		}
-		return "", fmt.Errorf("failed to read directory '%s': %w", toolPath, err)
+		return nil, fmt.Errorf("failed to read directory '%s': %w", toolPath, err)
 	}
 
 	for _, file := range files {
@@ -527,33 +1551,47 @@ func (te *ToolExecutor) resolveToolName(toolPath, requestedName string) (string,
 		}
 
 		fileName := file.Name()
-		// Check if this file matches our requested name (with or without extension)
-		if fileName == requestedName || strings.HasPrefix(fileName, requestedName+".") {
+		// Check if this file matches our requested name (with or without
+		// extension, unless --strict-match requires the exact name)
+		if fileName == requestedName || (!te.ctx.StrictMatch && strings.HasPrefix(fileName, requestedName+".")) {
 			fullPath := filepath.Join(te.resolveToolFullPath(toolPath, ""), fileName)
-			if te.isExecutable(fullPath) {
-				priority := 1 // Default priority for files with extensions
-				if fileName == requestedName {
-					priority = 0 // Highest priority for files without extension
-				}
-				matches = append(matches, ToolMatch{
-					Name:     fileName,
-					Path:     toolPath,
-					FullPath: fullPath,
-					Priority: priority,
-				})
+			priority := 1 // Default priority for files with extensions
+			if fileName == requestedName {
+				priority = 0 // Highest priority for files without extension
 			}
+			matches = append(matches, ToolMatch{
+				Name:       fileName,
+				Path:       toolPath,
+				FullPath:   fullPath,
+				Priority:   priority,
+				Executable: te.isExecutable(fullPath),
+			})
 		}
 	}
 
-	if len(matches) == 0 {
-		return "", fmt.Errorf("tool '%s' not found in '%s'", requestedName, toolPath)
-	}
-
 	// Sort by priority (lower number = higher priority)
-	sort.Slice(matches, func(i, j int) bool {
+	sort.SliceStable(matches, func(i, j int) bool {
 		return matches[i].Priority < matches[j].Priority
 	})
 
+	return matches, nil
+}
+
+// chooseToolMatch picks the candidate to run among the executable ones.
+// Returns the chosen file name, or an error if there is none or the choice is
+// ambiguous.
+func chooseToolMatch(toolPath, requestedName string, candidates []ToolMatch) (string, error) {
+	var matches []ToolMatch
+	for _, candidate := range candidates {
+		if candidate.Executable {
+			matches = append(matches, candidate)
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("tool '%s' not found in '%s'", requestedName, toolPath)
+	}
+
 	// If we have exactly one match, or the first match has priority 0 (no extension), use it
 	if len(matches) == 1 || matches[0].Priority == 0 {
 		return matches[0].Name, nil
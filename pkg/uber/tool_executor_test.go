@@ -1,11 +1,17 @@
 package uber
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/chaselatta/uber/config"
 )
@@ -115,7 +121,7 @@ func TestFindAndExecuteToolNoToolPaths(t *testing.T) {
 		},
 	}
 
-	err := executor.FindAndExecuteTool("test-tool", []string{})
+	err := executor.FindAndExecuteTool(context.Background(), "test-tool", []string{})
 	if err == nil {
 		t.Errorf("Expected error when no tool paths configured, got nil")
 	}
@@ -130,7 +136,7 @@ func TestFindAndExecuteToolEmptyToolPaths(t *testing.T) {
 		},
 	}
 
-	err := executor.FindAndExecuteTool("test-tool", []string{})
+	err := executor.FindAndExecuteTool(context.Background(), "test-tool", []string{})
 	if err == nil {
 		t.Errorf("Expected error when tool paths is empty, got nil")
 	}
@@ -151,7 +157,7 @@ func TestFindAndExecuteToolNotFound(t *testing.T) {
 		},
 	}
 
-	err := executor.FindAndExecuteTool("nonexistent-tool", []string{})
+	err := executor.FindAndExecuteTool(context.Background(), "nonexistent-tool", []string{})
 	if err == nil {
 		t.Errorf("Expected error when tool not found, got nil")
 	}
@@ -182,7 +188,7 @@ func TestExecuteNonExecutableFile(t *testing.T) {
 	}
 
 	// Test that execution fails when trying to run a non-executable file
-	err = executor.FindAndExecuteTool("test-tool", []string{})
+	err = executor.FindAndExecuteTool(context.Background(), "test-tool", []string{})
 	if err == nil {
 		t.Errorf("Expected error when trying to execute non-executable file, got nil")
 	}
@@ -233,7 +239,7 @@ fi
 		}
 
 		// Execute the tool that writes environment variables to a file
-		err := executor.FindAndExecuteTool("env-writer-tool", []string{})
+		err := executor.FindAndExecuteTool(context.Background(), "env-writer-tool", []string{})
 		if err != nil {
 			t.Fatalf("Failed to execute test tool: %v", err)
 		}
@@ -268,7 +274,7 @@ fi
 		}
 
 		// Execute the tool
-		err := executor.FindAndExecuteTool("env-writer-tool", []string{})
+		err := executor.FindAndExecuteTool(context.Background(), "env-writer-tool", []string{})
 		if err != nil {
 			t.Fatalf("Failed to execute test tool: %v", err)
 		}
@@ -324,7 +330,7 @@ echo "MY_VAR is: $MY_VAR" > %s
 	}
 
 	executor := NewToolExecutor(ctx)
-	err = executor.FindAndExecuteTool("print_env_tool", []string{})
+	err = executor.FindAndExecuteTool(context.Background(), "print_env_tool", []string{})
 	if err != nil {
 		t.Fatalf("FindAndExecuteTool failed: %v", err)
 	}
@@ -341,6 +347,47 @@ echo "MY_VAR is: $MY_VAR" > %s
 	}
 }
 
+func TestExecuteEnvSetupToolContext(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-setup-context")
+	defer cleanup()
+
+	// The setup script passes what it knows about the tool on to the tool
+	setupScript := filepath.Join(tempDir, "setup.sh")
+	setupScriptContent := `#!/bin/sh
+echo "SETUP_CONTEXT=$UBER_EXECUTED_COMMAND|$UBER_EXECUTED_TOOL_PATH|$UBER_ARGS|$UBER_PROJECT_ROOT"
+`
+	if err := os.WriteFile(setupScript, []byte(setupScriptContent), 0755); err != nil {
+		t.Fatalf("Failed to create setup script: %v", err)
+	}
+
+	outputFile := filepath.Join(tempDir, "output.txt")
+	toolContent := fmt.Sprintf("#!/bin/sh\necho \"$SETUP_CONTEXT\" > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "lint"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:    tempDir,
+		Command: "lint",
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			EnvSetup:  setupScript,
+		},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "lint", []string{"--fix", "src"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	want := fmt.Sprintf("lint|%s|--fix src|%s\n", tempDir, tempDir)
+	if string(output) != want {
+		t.Errorf("Expected output %q, got %q", want, string(output))
+	}
+}
+
 func TestExecuteWithPythonEnvSetup(t *testing.T) {
 	// Create a temp project root
 	tempDir, cleanup := createTempDirWithTool(t, "uber-test-python-env-setup")
@@ -378,7 +425,7 @@ echo "MY_VAR is: $MY_VAR" > %s
 	}
 
 	executor := NewToolExecutor(ctx)
-	err = executor.FindAndExecuteTool("print_env_tool", []string{})
+	err = executor.FindAndExecuteTool(context.Background(), "print_env_tool", []string{})
 	if err != nil {
 		t.Fatalf("FindAndExecuteTool failed: %v", err)
 	}
@@ -583,3 +630,1567 @@ func TestGetAllAvailableToolsWithExtensions(t *testing.T) {
 		}
 	}
 }
+
+func TestFindAndExecuteToolContextCancel(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-context-cancel")
+	defer cleanup()
+
+	// Create a tool that runs much longer than the test's deadline
+	toolPath := filepath.Join(tempDir, "slow-tool")
+	if err := os.WriteFile(toolPath, []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := executor.FindAndExecuteTool(ctx, "slow-tool", []string{})
+	if err == nil {
+		t.Fatal("Expected error when context is canceled, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected FindAndExecuteTool to return promptly after cancel, took %v", elapsed)
+	}
+}
+
+func TestExecuteEnvSetupCmd(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-setup-cmd")
+	defer cleanup()
+
+	t.Run("InlineCommand", func(t *testing.T) {
+		executor := NewToolExecutor(&RunContext{
+			Root:   tempDir,
+			Config: &config.Config{EnvSetupCmd: `echo "MY_VAR=$UBER_PROJECT_ROOT"; echo 'export OTHER="a b"'`},
+		})
+
+		env, err := executor.executeEnvSetup(context.Background())
+		if err != nil {
+			t.Fatalf("executeEnvSetup failed: %v", err)
+		}
+		if value, _ := envValue(env, "MY_VAR"); value != tempDir {
+			t.Errorf("Expected MY_VAR to be '%s', got '%s'", tempDir, value)
+		}
+		if value, _ := envValue(env, "OTHER"); value != "a b" {
+			t.Errorf("Expected OTHER to be 'a b', got '%s'", value)
+		}
+	})
+
+	t.Run("DefaultShell", func(t *testing.T) {
+		// The command runs with default_shell when it is set
+		shell := filepath.Join(tempDir, "myshell")
+		if err := os.WriteFile(shell, []byte("#!/bin/sh\necho \"SHELL_ARGS=$*\"\n"), 0755); err != nil {
+			t.Fatalf("Failed to create shell: %v", err)
+		}
+		executor := NewToolExecutor(&RunContext{
+			Root:   tempDir,
+			Config: &config.Config{EnvSetupCmd: "direnv export bash", DefaultShell: shell},
+		})
+
+		env, err := executor.executeEnvSetup(context.Background())
+		if err != nil {
+			t.Fatalf("executeEnvSetup failed: %v", err)
+		}
+		if value, _ := envValue(env, "SHELL_ARGS"); value != "-c direnv export bash" {
+			t.Errorf("Expected the command to be passed to default_shell, got '%s'", value)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		executor := NewToolExecutor(&RunContext{
+			Root:   tempDir,
+			Config: &config.Config{EnvSetupCmd: "exit 3"},
+		})
+
+		if _, err := executor.executeEnvSetup(context.Background()); err == nil || !strings.Contains(err.Error(), "exit 3") {
+			t.Errorf("Expected an error naming the failed command, got: %v", err)
+		}
+	})
+}
+
+func TestEnvSetupRecursionGuard(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-setup-recursion")
+	defer cleanup()
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{EnvSetupCmd: `echo "SEEN=$UBER_IN_ENV_SETUP"`},
+	})
+
+	// The script is told that it runs as env setup, the tool isn't
+	env, err := executor.executeEnvSetup(context.Background())
+	if err != nil {
+		t.Fatalf("executeEnvSetup failed: %v", err)
+	}
+	if value, _ := envValue(env, "SEEN"); value != "1" {
+		t.Errorf("Expected UBER_IN_ENV_SETUP=1 for the script, got '%s'", value)
+	}
+	if _, ok := envValue(env, "UBER_IN_ENV_SETUP"); ok {
+		t.Errorf("Expected UBER_IN_ENV_SETUP not to be set for the tool")
+	}
+
+	// An uber started by the script refuses to run env setup again
+	t.Setenv("UBER_IN_ENV_SETUP", "1")
+	_, err = executor.executeEnvSetup(context.Background())
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "UBER_IN_ENV_SETUP") {
+		t.Errorf("Expected a configuration error naming UBER_IN_ENV_SETUP, got: %v", err)
+	}
+}
+
+func TestEnvSetupOutputFile(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-setup-output-file")
+	defer cleanup()
+
+	t.Run("ReadsFile", func(t *testing.T) {
+		executor := NewToolExecutor(&RunContext{
+			Root: tempDir,
+			Config: &config.Config{
+				EnvSetupCmd:        `echo PRINTED=yes; echo SHARED=stdout; printf 'export FROM_FILE="a b"\nSHARED=file\n' > .envrc.generated`,
+				EnvSetupOutputFile: ".envrc.generated",
+			},
+		})
+		// The command writes the file relative to the working directory
+		t.Chdir(tempDir)
+
+		env, err := executor.executeEnvSetup(context.Background())
+		if err != nil {
+			t.Fatalf("executeEnvSetup failed: %v", err)
+		}
+		for key, want := range map[string]string{"PRINTED": "yes", "FROM_FILE": "a b", "SHARED": "file"} {
+			if value, _ := envValue(env, key); value != want {
+				t.Errorf("Expected %s to be '%s', got '%s'", key, want, value)
+			}
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		executor := NewToolExecutor(&RunContext{
+			Root: tempDir,
+			Config: &config.Config{
+				EnvSetupCmd:        "true",
+				EnvSetupOutputFile: "missing.env",
+			},
+		})
+
+		_, err := executor.executeEnvSetup(context.Background())
+		if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), filepath.Join(tempDir, "missing.env")) {
+			t.Errorf("Expected ErrNotFound naming the missing file, got: %v", err)
+		}
+	})
+}
+
+func TestFindAndExecuteToolBare(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-bare")
+	defer cleanup()
+
+	envFile := filepath.Join(tempDir, "env.txt")
+	reportFile := filepath.Join(tempDir, "report.txt")
+	scripts := map[string]string{
+		"printenv":  fmt.Sprintf("#!/bin/sh\nenv > %s\n", envFile),
+		"setup.sh":  "#!/bin/sh\necho FROM_SETUP=1\n",
+		"report.sh": fmt.Sprintf("#!/bin/sh\necho \"$UBER_EXECUTED_COMMAND\" > %s\n", reportFile),
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	t.Setenv("UBER_TEST_INHERITED", "kept")
+
+	executor := NewToolExecutor(&RunContext{
+		Root:        tempDir,
+		Command:     "printenv",
+		Bare:        true,
+		UberBinPath: "/dummy/bin/path",
+		Config: &config.Config{
+			ToolPaths:     []string{tempDir},
+			EnvSetup:      filepath.Join(tempDir, "setup.sh"),
+			ReportingCmds: config.StringList{"report.sh"},
+		},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "printenv", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	output, _ := os.ReadFile(envFile)
+	env := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if value, _ := envValue(env, "UBER_TEST_INHERITED"); value != "kept" {
+		t.Errorf("Expected the inherited environment, got:\n%s", output)
+	}
+	for _, key := range []string{"UBER_BIN_PATH", "UBER_PROJECT_ROOT", "FROM_SETUP"} {
+		if _, ok := envValue(env, key); ok {
+			t.Errorf("Expected %s not to be set in bare mode", key)
+		}
+	}
+
+	// Reporting still runs with its usual environment
+	if report, _ := os.ReadFile(reportFile); string(report) != "printenv\n" {
+		t.Errorf("Expected the reporting command to run, got %q", string(report))
+	}
+}
+
+func TestParseEnvOutputDuplicateKeys(t *testing.T) {
+	output := "MY_VAR=first\nOTHER=value\nMY_VAR=second\n"
+
+	t.Run("LastValueWins", func(t *testing.T) {
+		executor := NewToolExecutor(&RunContext{
+			Config: &config.Config{},
+		})
+
+		envMap := make(map[string]string)
+		if err := executor.parseEnvOutput(strings.NewReader(output), "setup.sh", envMap); err != nil {
+			t.Fatalf("parseEnvOutput() error = %v", err)
+		}
+		if envMap["MY_VAR"] != "second" {
+			t.Errorf("Expected MY_VAR to be 'second', got '%s'", envMap["MY_VAR"])
+		}
+		if envMap["OTHER"] != "value" {
+			t.Errorf("Expected OTHER to be 'value', got '%s'", envMap["OTHER"])
+		}
+	})
+
+	t.Run("StrictModeFails", func(t *testing.T) {
+		executor := NewToolExecutor(&RunContext{
+			Config: &config.Config{Strict: true},
+		})
+
+		err := executor.parseEnvOutput(strings.NewReader(output), "setup.sh", make(map[string]string))
+		if err == nil {
+			t.Fatal("Expected error for duplicate key in strict mode, got nil")
+		}
+		if !strings.Contains(err.Error(), "'MY_VAR' more than once") {
+			t.Errorf("Expected error to mention the duplicate key, got: %v", err)
+		}
+	})
+}
+
+func TestParseEnvOutputShellSyntax(t *testing.T) {
+	output := strings.Join([]string{
+		`export FLAGS="-a=1 -b=2"`,
+		`export  SINGLE='single quoted'`,
+		`PLAIN=a=b=c`,
+		`MISMATCHED="open`,
+		`LONE="`,
+		`EMPTY=""`,
+		`INNER=say "hi"`,
+		`exported=value`,
+		`not a variable`,
+	}, "\n")
+
+	executor := NewToolExecutor(&RunContext{
+		Config: &config.Config{},
+	})
+	envMap := make(map[string]string)
+	if err := executor.parseEnvOutput(strings.NewReader(output), "setup.sh", envMap); err != nil {
+		t.Fatalf("parseEnvOutput() error = %v", err)
+	}
+
+	want := map[string]string{
+		"FLAGS":      "-a=1 -b=2",
+		"SINGLE":     "single quoted",
+		"PLAIN":      "a=b=c",
+		"MISMATCHED": `"open`,
+		"LONE":       `"`,
+		"EMPTY":      "",
+		"INNER":      `say "hi"`,
+		"exported":   "value",
+	}
+	if len(envMap) != len(want) {
+		t.Errorf("Expected %d variables, got %v", len(want), envMap)
+	}
+	for key, value := range want {
+		if got, ok := envMap[key]; !ok || got != value {
+			t.Errorf("Expected %s=%q, got %q (set: %v)", key, value, got, ok)
+		}
+	}
+}
+
+func TestFindAndExecuteToolIsolateTmp(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-isolate-tmp")
+	defer cleanup()
+
+	// Create a tool that records its TMPDIR and whether it exists
+	outputFile := filepath.Join(tempDir, "output.txt")
+	toolContent := fmt.Sprintf(`#!/bin/sh
+echo "$TMPDIR" > %s
+test -d "$TMPDIR" && test "$TMP" = "$TMPDIR" && test "$TEMP" = "$TMPDIR"
+`, outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "tmp-tool"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	ctx := &RunContext{
+		Root:       tempDir,
+		IsolateTmp: true,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+		},
+	}
+
+	executor := NewToolExecutor(ctx)
+	if err := executor.FindAndExecuteTool(context.Background(), "tmp-tool", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	tmpDir := strings.TrimSpace(string(output))
+	if tmpDir == "" || tmpDir == os.TempDir() {
+		t.Fatalf("Expected a per-run TMPDIR, got '%s'", tmpDir)
+	}
+	if _, err := os.Stat(tmpDir); !os.IsNotExist(err) {
+		t.Errorf("Expected temporary directory '%s' to be removed, stat error: %v", tmpDir, err)
+	}
+	if ctx.TmpDir != "" {
+		t.Errorf("Expected TmpDir to be cleared after execution, got '%s'", ctx.TmpDir)
+	}
+}
+
+func TestExecuteReportingCmdList(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-reporting-list")
+	defer cleanup()
+
+	// The first reporter fails, the second records that it ran
+	outputFile := filepath.Join(tempDir, "output.txt")
+	if err := os.WriteFile(filepath.Join(tempDir, "failing.sh"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	auditScript := fmt.Sprintf("#!/bin/sh\necho \"$UBER_EXECUTED_COMMAND\" > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "audit.sh"), []byte(auditScript), 0755); err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:    tempDir,
+		Command: "deploy",
+		Config: &config.Config{
+			ReportingCmds: config.StringList{"failing.sh", "audit.sh"},
+		},
+	})
+
+	err := executor.executeReportingCmds(context.Background(), executor.ctx.Config.ReportingCmds)
+	if err == nil {
+		t.Fatal("Expected error from failing reporter, got nil")
+	}
+	if !strings.Contains(err.Error(), "failing.sh") {
+		t.Errorf("Expected error to name the failing reporter, got: %v", err)
+	}
+
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Expected second reporter to run despite the first failing: %v", err)
+	}
+	if string(output) != "deploy\n" {
+		t.Errorf("Expected reporter output 'deploy', got '%s'", string(output))
+	}
+}
+
+func TestFileToolPath(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-file-tool-path")
+	defer cleanup()
+
+	// A standalone script configured directly as a tool path, next to an
+	// unrelated executable that must not be picked up
+	outputFile := filepath.Join(tempDir, "output.txt")
+	scriptsDir := filepath.Join(tempDir, "scripts")
+	if err := os.MkdirAll(scriptsDir, 0755); err != nil {
+		t.Fatalf("Failed to create scripts directory: %v", err)
+	}
+	toolContent := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(scriptsDir, "standalone.sh"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(scriptsDir, "unrelated"), []byte("#!/bin/sh\ntrue\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{"scripts/standalone.sh"},
+		},
+	})
+
+	tools, err := executor.GetAllAvailableTools()
+	if err != nil {
+		t.Fatalf("GetAllAvailableTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "standalone.sh" {
+		t.Errorf("Expected only 'standalone.sh' to be available, got %v", tools)
+	}
+
+	for _, name := range []string{"standalone", "standalone.sh"} {
+		os.Remove(outputFile)
+		if err := executor.FindAndExecuteTool(context.Background(), name, []string{"hello"}); err != nil {
+			t.Fatalf("FindAndExecuteTool(%s) failed: %v", name, err)
+		}
+		output, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output file: %v", err)
+		}
+		if string(output) != "hello\n" {
+			t.Errorf("Expected output 'hello', got '%s'", string(output))
+		}
+	}
+
+	if err := executor.FindAndExecuteTool(context.Background(), "unrelated", []string{}); err == nil {
+		t.Error("Expected error for tool outside the file tool path, got nil")
+	}
+}
+
+func TestParseEnvOutputLineTooLong(t *testing.T) {
+	executor := NewToolExecutor(&RunContext{
+		Config: &config.Config{EnvSetupMaxLineBytes: 1024},
+	})
+
+	output := "SMALL=ok\nHUGE=" + strings.Repeat("x", 2048) + "\n"
+	err := executor.parseEnvOutput(strings.NewReader(output), "setup.sh", make(map[string]string))
+	if err == nil {
+		t.Fatal("Expected error for a line longer than the limit, got nil")
+	}
+	if !strings.Contains(err.Error(), "setup.sh") || !strings.Contains(err.Error(), "longer than 1024 bytes") {
+		t.Errorf("Expected error to name the script and the limit, got: %v", err)
+	}
+
+	// Lines longer than the scanner's default token size work by default
+	executor.ctx.Config.EnvSetupMaxLineBytes = 0
+	envMap := make(map[string]string)
+	longValue := strings.Repeat("y", 128*1024)
+	if err := executor.parseEnvOutput(strings.NewReader("LONG="+longValue+"\n"), "setup.sh", envMap); err != nil {
+		t.Fatalf("parseEnvOutput() error = %v", err)
+	}
+	if envMap["LONG"] != longValue {
+		t.Errorf("Expected LONG to be parsed in full, got %d bytes", len(envMap["LONG"]))
+	}
+}
+
+func TestEnvSetupOutputTooLarge(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-setup-too-large")
+	defer cleanup()
+
+	// Print more than the total output cap
+	setupScript := filepath.Join(tempDir, "setup.sh")
+	setupContent := fmt.Sprintf("#!/bin/sh\nhead -c %d /dev/zero | tr '\\\\0' 'x'\n", envSetupMaxOutputBytes+1024)
+	if err := os.WriteFile(setupScript, []byte(setupContent), 0755); err != nil {
+		t.Fatalf("Failed to create setup script: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{EnvSetup: setupScript},
+	})
+
+	_, err := executor.executeEnvSetup(context.Background())
+	if err == nil {
+		t.Fatal("Expected error for oversized env setup output, got nil")
+	}
+	if !strings.Contains(err.Error(), "printed more than") {
+		t.Errorf("Expected error about output size, got: %v", err)
+	}
+}
+
+func TestExecuteToolWithCwd(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-tool-cwd")
+	defer cleanup()
+	tempDir, err := filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to evaluate symlinks: %v", err)
+	}
+
+	workDir := filepath.Join(tempDir, "work")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatalf("Failed to create work directory: %v", err)
+	}
+
+	outputFile := filepath.Join(tempDir, "output.txt")
+	toolContent := fmt.Sprintf("#!/bin/sh\npwd > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "pwd-tool.sh"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			Tools: map[string]config.ToolConfig{
+				"pwd-tool": {Cwd: "work"},
+			},
+		},
+	})
+
+	if err := executor.FindAndExecuteTool(context.Background(), "pwd-tool", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != workDir {
+		t.Errorf("Expected tool to run in '%s', got '%s'", workDir, strings.TrimSpace(string(output)))
+	}
+}
+
+func TestFindAndExecuteToolRecordsFindTime(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-find-timing")
+	defer cleanup()
+
+	// The tool sleeps so that the find time can be compared with the
+	// execution time
+	if err := os.WriteFile(filepath.Join(tempDir, "sleepy"), []byte("#!/bin/sh\nsleep 0.2\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	ctx := &RunContext{
+		Root:           tempDir,
+		TimeFindToolMs: -1,
+		Config: &config.Config{
+			ToolPaths: []string{"/nonexistent/path", tempDir},
+		},
+	}
+
+	executor := NewToolExecutor(ctx)
+	if err := executor.FindAndExecuteTool(context.Background(), "sleepy", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	if ctx.TimeFindToolMs < 0 {
+		t.Errorf("Expected TimeFindToolMs to be recorded, got %d", ctx.TimeFindToolMs)
+	}
+	if ctx.TimeFindToolMs > 1000 {
+		t.Errorf("Expected TimeFindToolMs to be small, got %d", ctx.TimeFindToolMs)
+	}
+	if ctx.TimeExecToolMs < 200 {
+		t.Errorf("Expected TimeExecToolMs to include the tool's run time, got %d", ctx.TimeExecToolMs)
+	}
+	if ctx.TimeFindToolMs > ctx.TimeExecToolMs {
+		t.Errorf("Expected find time (%d ms) to exclude execution (%d ms)", ctx.TimeFindToolMs, ctx.TimeExecToolMs)
+	}
+}
+
+func TestFindAndExecuteToolAllowDeny(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-allow-deny")
+	defer cleanup()
+
+	for _, name := range []string{"build", "deploy.sh"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("#!/bin/sh\ntrue\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			DenyTools: []string{"deploy"},
+		},
+	})
+
+	if err := executor.FindAndExecuteTool(context.Background(), "build", []string{}); err != nil {
+		t.Errorf("Expected 'build' to run, got: %v", err)
+	}
+
+	err := executor.FindAndExecuteTool(context.Background(), "deploy", []string{})
+	if !errors.Is(err, ErrNotPermitted) {
+		t.Fatalf("Expected ErrNotPermitted, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "not permitted") {
+		t.Errorf("Expected a clear 'not permitted' message, got: %v", err)
+	}
+
+	tools, err := executor.GetAllAvailableTools()
+	if err != nil {
+		t.Fatalf("GetAllAvailableTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "build" {
+		t.Errorf("Expected only 'build' to be listed, got %v", tools)
+	}
+}
+
+func TestPrepareReportingEnvironmentOriginalArgv(t *testing.T) {
+	executor := NewToolExecutor(&RunContext{
+		Root:         "/test/project",
+		Config:       &config.Config{},
+		OriginalArgs: []string{"/usr/bin/uber", "--verbose", "build", "--target", "a b"},
+	})
+
+	env := executor.prepareReportingEnvironment()
+	value, ok := envValue(env, "UBER_ORIGINAL_ARGV")
+	if !ok {
+		t.Fatal("Expected UBER_ORIGINAL_ARGV to be set")
+	}
+	got := strings.Split(value, originalArgvSeparator)
+	want := []string{"/usr/bin/uber", "--verbose", "build", "--target", "a b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("UBER_ORIGINAL_ARGV = %q, want %q", got, want)
+	}
+}
+
+func TestEnvSetupOnError(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-env-setup-on-error")
+	defer cleanup()
+
+	// The script prints a variable and then fails
+	setupScript := filepath.Join(tempDir, "setup.sh")
+	if err := os.WriteFile(setupScript, []byte("#!/bin/sh\necho PARTIAL=yes\nexit 3\n"), 0755); err != nil {
+		t.Fatalf("Failed to create setup script: %v", err)
+	}
+
+	tests := []struct {
+		policy  string
+		wantErr bool
+	}{
+		{policy: "", wantErr: true},
+		{policy: config.EnvSetupOnErrorAbort, wantErr: true},
+		{policy: config.EnvSetupOnErrorWarn, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			executor := NewToolExecutor(&RunContext{
+				Root: tempDir,
+				Config: &config.Config{
+					EnvSetup:        setupScript,
+					EnvSetupOnError: tt.policy,
+				},
+			})
+
+			env, err := executor.executeEnvSetup(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("executeEnvSetup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if value, ok := envValue(env, "PARTIAL"); !ok || value != "yes" {
+				t.Errorf("Expected PARTIAL=yes from the failed script, got '%s'", value)
+			}
+		})
+	}
+}
+
+func TestExecuteToolWithWrapper(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-wrapper")
+	defer cleanup()
+
+	// The wrapper records its arguments and the environment, then runs the tool
+	outputFile := filepath.Join(tempDir, "output.txt")
+	wrapperContent := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\necho \"$UBER_PROJECT_ROOT\" >> %s\nshift\nexec \"$@\"\n", outputFile, outputFile)
+	wrapperPath := filepath.Join(tempDir, "wrapper.sh")
+	if err := os.WriteFile(wrapperPath, []byte(wrapperContent), 0755); err != nil {
+		t.Fatalf("Failed to create wrapper: %v", err)
+	}
+	toolContent := fmt.Sprintf("#!/bin/sh\necho \"tool $@\" >> %s\n", outputFile)
+	toolPath := filepath.Join(tempDir, "my-tool")
+	if err := os.WriteFile(toolPath, []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:    tempDir,
+		Wrapper: []string{wrapperPath, "--flag"},
+		Config:  &config.Config{ToolPaths: []string{tempDir}},
+	})
+
+	if err := executor.FindAndExecuteTool(context.Background(), "my-tool", []string{"arg1"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	want := fmt.Sprintf("--flag %s arg1\n%s\ntool arg1\n", toolPath, tempDir)
+	if string(output) != want {
+		t.Errorf("Expected output %q, got %q", want, string(output))
+	}
+}
+
+func TestExecuteToolWithWrapperFromEnvSetup(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-wrapper-env-setup")
+	defer cleanup()
+
+	// The wrapper is only in the PATH printed by the env setup
+	wrapperDir := filepath.Join(tempDir, "wrappers")
+	if err := os.MkdirAll(wrapperDir, 0755); err != nil {
+		t.Fatalf("Failed to create wrapper directory: %v", err)
+	}
+	outputFile := filepath.Join(tempDir, "output.txt")
+	wrapperContent := fmt.Sprintf("#!/bin/sh\necho wrapped > %s\nexec \"$@\"\n", outputFile)
+	if err := os.WriteFile(filepath.Join(wrapperDir, "my-wrapper"), []byte(wrapperContent), 0755); err != nil {
+		t.Fatalf("Failed to create wrapper: %v", err)
+	}
+	setupScript := filepath.Join(tempDir, "setup.sh")
+	setupContent := fmt.Sprintf("#!/bin/sh\necho \"PATH=%s:$PATH\"\n", wrapperDir)
+	if err := os.WriteFile(setupScript, []byte(setupContent), 0755); err != nil {
+		t.Fatalf("Failed to create setup script: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "my-tool"), []byte("#!/bin/sh\ntrue\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:    tempDir,
+		Wrapper: []string{"my-wrapper"},
+		Config:  &config.Config{ToolPaths: []string{tempDir}, EnvSetup: setupScript},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "my-tool", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	if output, _ := os.ReadFile(outputFile); string(output) != "wrapped\n" {
+		t.Errorf("Expected the wrapper to run, got %q", string(output))
+	}
+
+	// A wrapper that is nowhere in the PATH is reported as not found
+	executor.ctx.Wrapper = []string{"no-such-wrapper"}
+	err := executor.FindAndExecuteTool(context.Background(), "my-tool", []string{})
+	if code := ExitCode(err); code != ExitNotFound {
+		t.Errorf("Expected exit code %d, got %d (%v)", ExitNotFound, code, err)
+	}
+}
+
+func TestLookPathIn(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-look-path-in")
+	defer cleanup()
+	tempDir, _ = filepath.EvalSymlinks(tempDir)
+	if err := os.WriteFile(filepath.Join(tempDir, "my-wrapper"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create wrapper: %v", err)
+	}
+	t.Chdir(tempDir)
+
+	// A relative entry is searched relative to uber's working directory
+	env := []string{"PATH=."}
+	if got, err := lookPathIn("my-wrapper", env); err != nil || got != filepath.Join(tempDir, "my-wrapper") {
+		t.Errorf("lookPathIn(my-wrapper) = %q, %v; want %q", got, err, filepath.Join(tempDir, "my-wrapper"))
+	}
+	// uber's own PATH isn't searched
+	if got, err := lookPathIn("sh", env); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected sh not to be found in PATH=., got %q, %v", got, err)
+	}
+}
+
+func TestFindAndExecuteToolNoReporting(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-no-reporting")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "reported.txt")
+	reportScript := fmt.Sprintf("#!/bin/sh\necho reported > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "report.sh"), []byte(reportScript), 0755); err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "my-tool"), []byte("#!/bin/sh\ntrue\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		noReporting bool
+		envValue    string
+		wantReport  bool
+	}{
+		{name: "enabled", wantReport: true},
+		{name: "flag", noReporting: true, wantReport: false},
+		{name: "env", envValue: "1", wantReport: false},
+		{name: "env false", envValue: "0", wantReport: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(outputFile)
+			t.Setenv("UBER_NO_REPORTING", tt.envValue)
+
+			executor := NewToolExecutor(&RunContext{
+				Root:        tempDir,
+				NoReporting: tt.noReporting,
+				Config: &config.Config{
+					ToolPaths:     []string{tempDir},
+					ReportingCmds: config.StringList{"report.sh"},
+				},
+			})
+			if err := executor.FindAndExecuteTool(context.Background(), "my-tool", []string{}); err != nil {
+				t.Fatalf("FindAndExecuteTool failed: %v", err)
+			}
+
+			_, err := os.Stat(outputFile)
+			if reported := err == nil; reported != tt.wantReport {
+				t.Errorf("Reporting command ran = %v, want %v", reported, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestFindAndExecuteToolReportOnFailure(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-report-on-failure")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "reported.txt")
+	reportScript := fmt.Sprintf("#!/bin/sh\necho \"$UBER_TOOL_EXIT_CODE\" > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "report.sh"), []byte(reportScript), 0755); err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "fails"), []byte("#!/bin/sh\nexit 7\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "succeeds"), []byte("#!/bin/sh\ntrue\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		tool            string
+		reportOnFailure bool
+		wantExitCode    int
+		wantReport      string
+	}{
+		{name: "success", tool: "succeeds", wantExitCode: 0, wantReport: "0\n"},
+		{name: "failure without report_on_failure", tool: "fails", wantExitCode: 7, wantReport: ""},
+		{name: "failure with report_on_failure", tool: "fails", reportOnFailure: true, wantExitCode: 7, wantReport: "7\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(outputFile)
+
+			executor := NewToolExecutor(&RunContext{
+				Root: tempDir,
+				Config: &config.Config{
+					ToolPaths:       []string{tempDir},
+					ReportingCmds:   config.StringList{"report.sh"},
+					ReportOnFailure: tt.reportOnFailure,
+				},
+			})
+			err := executor.FindAndExecuteTool(context.Background(), tt.tool, []string{})
+			if got := ExitCode(err); got != tt.wantExitCode {
+				t.Errorf("ExitCode() = %d, want %d (err: %v)", got, tt.wantExitCode, err)
+			}
+
+			output, _ := os.ReadFile(outputFile)
+			if string(output) != tt.wantReport {
+				t.Errorf("Reporter output = %q, want %q", string(output), tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestFindAndExecuteToolOutcomeReporting(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-outcome-reporting")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "reported.txt")
+	for _, hook := range []string{"report", "success", "failure"} {
+		script := fmt.Sprintf("#!/bin/sh\necho \"%s $UBER_TOOL_EXIT_CODE\" >> %s\n", hook, outputFile)
+		if err := os.WriteFile(filepath.Join(tempDir, hook+".sh"), []byte(script), 0755); err != nil {
+			t.Fatalf("Failed to create reporter: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "fails"), []byte("#!/bin/sh\nexit 7\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "succeeds"), []byte("#!/bin/sh\ntrue\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		tool            string
+		reportOnFailure bool
+		wantReport      string
+	}{
+		{name: "success", tool: "succeeds", wantReport: "report 0\nsuccess 0\n"},
+		{name: "failure", tool: "fails", wantReport: "failure 7\n"},
+		{name: "failure with report_on_failure", tool: "fails", reportOnFailure: true, wantReport: "report 7\nfailure 7\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(outputFile)
+
+			executor := NewToolExecutor(&RunContext{
+				Root: tempDir,
+				Config: &config.Config{
+					ToolPaths:       []string{tempDir},
+					ReportingCmds:   config.StringList{"report.sh"},
+					AfterSuccessCmd: config.StringList{"success.sh"},
+					AfterFailureCmd: config.StringList{"failure.sh"},
+					ReportOnFailure: tt.reportOnFailure,
+				},
+			})
+			executor.FindAndExecuteTool(context.Background(), tt.tool, []string{})
+
+			output, _ := os.ReadFile(outputFile)
+			if string(output) != tt.wantReport {
+				t.Errorf("Reporter output = %q, want %q", string(output), tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestFindToolDanglingSymlink(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-dangling-symlink")
+	defer cleanup()
+
+	target := filepath.Join(tempDir, "deploy-v2")
+	if err := os.Symlink(target, filepath.Join(tempDir, "deploy")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+	_, _, err := executor.findTool("deploy")
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound, got: %v", err)
+	}
+	want := fmt.Sprintf("tool 'deploy' is a symlink pointing to a missing target %s", target)
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, got: %v", want, err)
+	}
+}
+
+func TestIncludeHidden(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-include-hidden")
+	defer cleanup()
+
+	for _, name := range []string{".hidden-tool", "build"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+
+	for _, includeHidden := range []bool{false, true} {
+		executor := NewToolExecutor(&RunContext{
+			Root:   tempDir,
+			Config: &config.Config{ToolPaths: []string{tempDir}, IncludeHidden: includeHidden},
+		})
+
+		tools, err := executor.GetAllAvailableTools()
+		if err != nil {
+			t.Fatalf("GetAllAvailableTools failed: %v", err)
+		}
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		if listed := slices.Contains(names, ".hidden-tool"); listed != includeHidden {
+			t.Errorf("include_hidden=%v: expected .hidden-tool listed %v, got %v", includeHidden, includeHidden, names)
+		}
+		if !slices.Contains(names, "build") {
+			t.Errorf("include_hidden=%v: expected build to be listed, got %v", includeHidden, names)
+		}
+
+		_, _, err = executor.findTool(".hidden-tool")
+		if includeHidden && err != nil {
+			t.Errorf("Expected .hidden-tool to be found with include_hidden, got: %v", err)
+		}
+		if !includeHidden && (!errors.Is(err, ErrToolNotFound) || !strings.Contains(err.Error(), "include_hidden")) {
+			t.Errorf("Expected ErrToolNotFound mentioning include_hidden, got: %v", err)
+		}
+	}
+}
+
+func TestStrictMatch(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-strict-match")
+	defer cleanup()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "deploy.sh"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+	if _, _, err := executor.findTool("deploy"); err != nil {
+		t.Fatalf("Expected deploy to resolve to deploy.sh by default, got: %v", err)
+	}
+
+	executor.ctx.StrictMatch = true
+	_, _, err := executor.findTool("deploy")
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound with --strict-match, got: %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "Did you mean") {
+		t.Errorf("Expected no suggestions with --strict-match, got: %v", err)
+	}
+	if _, executablePath, err := executor.findTool("deploy.sh"); err != nil || filepath.Base(executablePath) != "deploy.sh" {
+		t.Errorf("Expected the exact name to resolve with --strict-match, got %s, %v", executablePath, err)
+	}
+
+	// A tool path pointing at a file also needs its exact name
+	executor.ctx.Config.ToolPaths = []string{filepath.Join(tempDir, "deploy.sh")}
+	if _, _, err := executor.findTool("deploy"); err == nil {
+		t.Errorf("Expected a single-executable tool path to require its exact name with --strict-match")
+	}
+}
+
+func TestPathWithToolPaths(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-path-prepend")
+	defer cleanup()
+
+	for _, dir := range []string{"bin", "scripts", "on-path"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "single.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths:        []string{"scripts", "missing", "single.sh", "bin", "on-path", "scripts"},
+			PrependToolPaths: true,
+		},
+	})
+
+	onPath := filepath.Join(tempDir, "on-path")
+	path := strings.Join([]string{"/usr/bin", onPath}, string(filepath.ListSeparator))
+	got := executor.pathWithToolPaths(path)
+	want := strings.Join([]string{filepath.Join(tempDir, "scripts"), filepath.Join(tempDir, "bin"), "/usr/bin", onPath}, string(filepath.ListSeparator))
+	if got != want {
+		t.Errorf("pathWithToolPaths() = %q, want %q", got, want)
+	}
+}
+
+func TestPrepareEnvironmentPrependToolPaths(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-path-prepend-env")
+	defer cleanup()
+
+	binDir := filepath.Join(tempDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	t.Setenv("PATH", "/usr/bin")
+
+	cfg := &config.Config{ToolPaths: []string{"bin"}}
+	executor := NewToolExecutor(&RunContext{Root: tempDir, Config: cfg})
+
+	if value, _ := envValue(executor.prepareEnvironment(), "PATH"); value != "/usr/bin" {
+		t.Errorf("Expected PATH to be unchanged by default, got %q", value)
+	}
+
+	cfg.PrependToolPaths = true
+	want := binDir + string(filepath.ListSeparator) + "/usr/bin"
+	if value, _ := envValue(executor.prepareEnvironment(), "PATH"); value != want {
+		t.Errorf("PATH = %q, want %q", value, want)
+	}
+}
+
+func TestListAvailableToolsPathOrder(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-list-order")
+	defer cleanup()
+
+	// Enough paths that random map iteration would almost surely reorder them
+	var toolPaths []string
+	for i := 0; i < 8; i++ {
+		dir := fmt.Sprintf("dir%d", 7-i)
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, dir, "tool"), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+		toolPaths = append(toolPaths, dir)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: toolPaths},
+	})
+
+	listTools := func() string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		defer func() {
+			os.Stdout = oldStdout
+		}()
+
+		if err := executor.ListAvailableTools(); err != nil {
+			t.Fatalf("ListAvailableTools failed: %v", err)
+		}
+		w.Close()
+		var buf strings.Builder
+		io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	first := listTools()
+	var lastIndex int
+	for _, dir := range toolPaths {
+		index := strings.Index(first, fmt.Sprintf("From %s:", dir))
+		if index < lastIndex {
+			t.Fatalf("Expected paths in tool_paths order, got:\n%s", first)
+		}
+		lastIndex = index
+	}
+
+	for i := 0; i < 5; i++ {
+		if got := listTools(); got != first {
+			t.Fatalf("Expected the same output on every run, got:\n%s\nthen:\n%s", first, got)
+		}
+	}
+}
+
+func TestListAvailableToolsLong(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-list-long")
+	defer cleanup()
+
+	for _, file := range []string{"first/deploy", "second/deploy.sh", "second/build"} {
+		path := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Long:   true,
+		Config: &config.Config{ToolPaths: []string{"first", "second"}},
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := executor.ListAvailableTools()
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("ListAvailableTools failed: %v", err)
+	}
+	var buf strings.Builder
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	firstDeploy := filepath.Join(tempDir, "first", "deploy")
+	for _, want := range []string{
+		"* deploy  " + firstDeploy + "\n",
+		"  deploy  " + filepath.Join(tempDir, "second", "deploy.sh") + " (shadowed by " + firstDeploy + ")\n",
+		"* build  " + filepath.Join(tempDir, "second", "build") + "\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestVerbosePhaseSummary(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-phase-summary")
+	defer cleanup()
+
+	scripts := map[string]string{
+		"setup.sh":  "#!/bin/sh\necho FOO=bar\n",
+		"report.sh": "#!/bin/sh\nexit 2\n",
+		"build":     "#!/bin/sh\n",
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:    tempDir,
+		Verbose: true,
+		Config: &config.Config{
+			ToolPaths:     []string{tempDir},
+			EnvSetup:      "setup.sh",
+			ReportingCmds: config.StringList{"report.sh"},
+		},
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := executor.FindAndExecuteTool(context.Background(), "build", []string{})
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	var buf strings.Builder
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	for _, want := range []string{
+		`env_setup: ok \(\d+ms\)`,
+		`reporting: failed exit 2 \(\d+ms\)`,
+	} {
+		if !regexp.MustCompile(want).MatchString(output) {
+			t.Errorf("Expected output to match %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestDeprecatedTool(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-deprecated")
+	defer cleanup()
+
+	for _, name := range []string{"old-deploy", "deploy"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			Tools: map[string]config.ToolConfig{
+				"old-deploy": {Deprecated: "use deploy instead"},
+			},
+		},
+	})
+
+	// The tool still runs, after a warning
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	err := executor.FindAndExecuteTool(context.Background(), "old-deploy", []string{})
+	w.Close()
+	os.Stderr = oldStderr
+	if err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	var stderr strings.Builder
+	io.Copy(&stderr, r)
+	if want := "Warning: tool 'old-deploy' is deprecated: use deploy instead\n"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("Expected stderr to contain %q, got %q", want, stderr.String())
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ = os.Pipe()
+	os.Stdout = w
+	err = executor.ListAvailableTools()
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("ListAvailableTools failed: %v", err)
+	}
+	var stdout strings.Builder
+	io.Copy(&stdout, r)
+	for _, want := range []string{"  old-deploy (deprecated)\n", "  deploy\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected the tool list to contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestFindAndExecuteToolInvalidName(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-invalid-name")
+	defer cleanup()
+
+	// A tool outside of the tool path that must not be reachable
+	markerFile := filepath.Join(tempDir, "ran")
+	outside := filepath.Join(tempDir, "outside.sh")
+	if err := os.WriteFile(outside, []byte("#!/bin/sh\ntouch "+markerFile+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tempDir, "tools"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"tools"}},
+	})
+
+	for _, name := range []string{"../outside.sh", "../outside", outside, "..", `..\outside.sh`} {
+		err := executor.FindAndExecuteTool(context.Background(), name, []string{})
+		if !errors.Is(err, ErrUsage) || !strings.Contains(err.Error(), "invalid tool name") {
+			t.Errorf("Expected an invalid tool name error for '%s', got: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(markerFile); !os.IsNotExist(err) {
+		t.Error("Expected the tool outside of the tool path not to run")
+	}
+}
+
+func TestFindAndExecuteToolAllToolPathsMissing(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-paths-missing")
+	defer cleanup()
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"bin", "/nonexistent/uber/tools"}},
+	})
+
+	err := executor.FindAndExecuteTool(context.Background(), "build", []string{})
+	if !errors.Is(err, ErrConfig) {
+		t.Fatalf("Expected ErrConfig when no tool path exists, got: %v", err)
+	}
+	for _, want := range []string{filepath.Join(tempDir, "bin"), "/nonexistent/uber/tools"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to list missing path '%s', got: %v", want, err)
+		}
+	}
+
+	// With one existing path, a missing tool is just not found
+	if err := os.Mkdir(filepath.Join(tempDir, "bin"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	err = executor.FindAndExecuteTool(context.Background(), "build", []string{})
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound once a tool path exists, got: %v", err)
+	}
+}
+
+func TestFindAndExecuteToolRequiredToolPath(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-required-path")
+	defer cleanup()
+
+	if err := os.Mkdir(filepath.Join(tempDir, "bin"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "bin", "build"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	// A missing optional path is skipped
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"bin", "generated"}},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "build", []string{}); err != nil {
+		t.Fatalf("Expected a missing optional tool path to be skipped, got: %v", err)
+	}
+
+	// A missing required path fails even though the tool is found elsewhere
+	executor.ctx.Config.RequiredToolPaths = []string{"generated"}
+	err := executor.FindAndExecuteTool(context.Background(), "build", []string{})
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "required tool path 'generated'") {
+		t.Errorf("Expected ErrConfig for a missing required tool path, got: %v", err)
+	}
+	if _, err := executor.GetAllAvailableTools(); !errors.Is(err, ErrConfig) {
+		t.Errorf("Expected GetAllAvailableTools to fail for a missing required tool path, got: %v", err)
+	}
+}
+
+func TestUnreadableToolPath(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-unreadable-path")
+	defer cleanup()
+
+	for _, dir := range []string{"bin", "locked"} {
+		if err := os.Mkdir(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "bin", "build"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	lockedDir := filepath.Join(tempDir, "locked")
+	if err := os.Chmod(lockedDir, 0000); err != nil {
+		t.Fatalf("Failed to change permissions: %v", err)
+	}
+	defer os.Chmod(lockedDir, 0755)
+	if _, err := os.ReadDir(lockedDir); err == nil {
+		t.Skip("Running with privileges that ignore directory permissions")
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"locked", "bin"}},
+	})
+
+	// Without strict mode the unreadable path is skipped with a warning
+	tools, err := executor.GetAllAvailableTools()
+	if err != nil {
+		t.Fatalf("GetAllAvailableTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "build" {
+		t.Errorf("Expected only the readable tools, got %v", tools)
+	}
+	if err := executor.FindAndExecuteTool(context.Background(), "missing", []string{}); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound, got: %v", err)
+	}
+
+	// In strict mode it is an error naming the directory
+	executor.ctx.Config.Strict = true
+	_, err = executor.GetAllAvailableTools()
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), lockedDir) {
+		t.Errorf("Expected ErrConfig naming %s, got: %v", lockedDir, err)
+	}
+	err = executor.FindAndExecuteTool(context.Background(), "missing", []string{})
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "not readable") {
+		t.Errorf("Expected ErrConfig for the unreadable tool path, got: %v", err)
+	}
+}
+
+func TestFindAndExecuteToolMaxArgs(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-max-args")
+	defer cleanup()
+
+	markerFile := filepath.Join(tempDir, "ran")
+	toolContent := fmt.Sprintf("#!/bin/sh\ntouch %s\n", markerFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "purge"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	maxArgs := 2
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			Tools:     map[string]config.ToolConfig{"purge": {MaxArgs: &maxArgs}},
+		},
+	})
+
+	err := executor.FindAndExecuteTool(context.Background(), "purge", []string{"a", "b", "c"})
+	if !errors.Is(err, ErrUsage) || !strings.Contains(err.Error(), "at most 2 argument(s) but got 3") {
+		t.Errorf("Expected ErrUsage for too many arguments, got: %v", err)
+	}
+	if _, err := os.Stat(markerFile); err == nil {
+		t.Errorf("Expected the tool not to run with too many arguments")
+	}
+
+	if err := executor.FindAndExecuteTool(context.Background(), "purge", []string{"a", "b"}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+	if _, err := os.Stat(markerFile); err != nil {
+		t.Errorf("Expected the tool to run within max_args")
+	}
+}
+
+func TestFindAndExecuteToolRequiresEnv(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-requires-env")
+	defer cleanup()
+
+	markerFile := filepath.Join(tempDir, "ran")
+	scripts := map[string]string{
+		"deploy":   fmt.Sprintf("#!/bin/sh\ntouch %s\n", markerFile),
+		"setup.sh": "#!/bin/sh\necho AWS_REGION=us-east-1\n",
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("UBER_TEST_DEPLOY_KEY", "")
+
+	tests := []struct {
+		name     string
+		envSetup string
+		required []string
+		wantErr  string
+	}{
+		{
+			name:     "one missing variable",
+			required: []string{"AWS_REGION"},
+			wantErr:  "missing required environment variable AWS_REGION for tool 'deploy'",
+		},
+		{
+			name:     "unset and empty variables",
+			required: []string{"AWS_REGION", "UBER_TEST_DEPLOY_KEY"},
+			wantErr:  "missing required environment variables AWS_REGION, UBER_TEST_DEPLOY_KEY for tool 'deploy'",
+		},
+		{
+			name:     "set by env setup",
+			envSetup: filepath.Join(tempDir, "setup.sh"),
+			required: []string{"AWS_REGION", "UBER_PROJECT_ROOT"},
+		},
+		{
+			name: "no requirements",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(markerFile)
+			executor := NewToolExecutor(&RunContext{
+				Root: tempDir,
+				Config: &config.Config{
+					ToolPaths: []string{tempDir},
+					EnvSetup:  tt.envSetup,
+					Tools:     map[string]config.ToolConfig{"deploy": {RequiresEnv: tt.required}},
+				},
+			})
+
+			err := executor.FindAndExecuteTool(context.Background(), "deploy", []string{})
+			_, statErr := os.Stat(markerFile)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("FindAndExecuteTool failed: %v", err)
+				}
+				if statErr != nil {
+					t.Errorf("Expected the tool to run")
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrConfig) || err.Error() != tt.wantErr {
+				t.Errorf("Expected ErrConfig %q, got: %v", tt.wantErr, err)
+			}
+			if statErr == nil {
+				t.Errorf("Expected the tool not to run")
+			}
+		})
+	}
+}
+
+func TestFindAndExecuteToolNamespaced(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-namespaced")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "output.txt")
+	if err := os.MkdirAll(filepath.Join(tempDir, "bin", "git"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	tools := map[string]string{
+		"bin/git/status.sh": "nested",
+		"bin/git:log":       "flattened",
+		"bin/git/log":       "nested",
+	}
+	for path, label := range tools {
+		content := fmt.Sprintf("#!/bin/sh\necho %s \"$@\" > %s\n", label, outputFile)
+		if err := os.WriteFile(filepath.Join(tempDir, path), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{"bin"}},
+	})
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "git:status", want: "nested arg\n"},
+		// A flattened file name takes precedence over the subdirectory
+		{command: "git:log", want: "flattened arg\n"},
+	}
+	for _, tt := range tests {
+		os.Remove(outputFile)
+		if err := executor.FindAndExecuteTool(context.Background(), tt.command, []string{"arg"}); err != nil {
+			t.Fatalf("FindAndExecuteTool(%s) failed: %v", tt.command, err)
+		}
+		output, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output file: %v", err)
+		}
+		if string(output) != tt.want {
+			t.Errorf("FindAndExecuteTool(%s) output = %q, want %q", tt.command, string(output), tt.want)
+		}
+	}
+
+	if err := executor.FindAndExecuteTool(context.Background(), "git:missing", []string{}); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound for a missing namespaced tool, got: %v", err)
+	}
+}
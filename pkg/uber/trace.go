@@ -0,0 +1,48 @@
+package uber
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Phases reported by --trace for the subprocesses uber spawns.
+const (
+	tracePhaseEnvSetup    = "env_setup"
+	tracePhaseTool        = "tool"
+	tracePhaseReporting   = "reporting"
+	tracePhaseGit         = "git"
+	tracePhaseHealthcheck = "healthcheck"
+)
+
+// traceRun calls run, which starts cmd, and with --trace logs a start event
+// with the resolved path and arguments before it and an end event with the
+// exit code and elapsed time after it.
+func (te *ToolExecutor) traceRun(phase string, cmd *exec.Cmd, run func() error) error {
+	if te.ctx.TraceOutput == nil {
+		return run()
+	}
+
+	quoted := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		quoted[i] = shellQuote(arg)
+	}
+	start := time.Now()
+	te.trace(start, "start phase=%s path=%s argv=[%s]", phase, shellQuote(cmd.Path), strings.Join(quoted, " "))
+
+	err := run()
+
+	end := time.Now()
+	pid := 0
+	if cmd.Process != nil {
+		pid = cmd.Process.Pid
+	}
+	te.trace(end, "end phase=%s pid=%d exit=%d elapsed=%s", phase, pid, ExitCode(err), end.Sub(start))
+	return err
+}
+
+// trace writes one timestamped event line to the trace output.
+func (te *ToolExecutor) trace(at time.Time, format string, args ...any) {
+	fmt.Fprintf(te.ctx.TraceOutput, "%s uber trace: %s\n", at.UTC().Format(time.RFC3339Nano), fmt.Sprintf(format, args...))
+}
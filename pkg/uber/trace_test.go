@@ -0,0 +1,76 @@
+package uber
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestTrace(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-trace")
+	defer cleanup()
+
+	for name, content := range map[string]string{
+		"setup.sh":  "#!/bin/sh\necho FOO=bar\n",
+		"report.sh": "#!/bin/sh\n",
+		"build":     "#!/bin/sh\nexit 3\n",
+	} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	var output strings.Builder
+	executor := NewToolExecutor(&RunContext{
+		Root:        tempDir,
+		TraceOutput: &output,
+		Config: &config.Config{
+			ToolPaths:       []string{tempDir},
+			EnvSetup:        "setup.sh",
+			ReportingCmds:   config.StringList{"report.sh"},
+			ReportOnFailure: true,
+		},
+	})
+	executor.FindAndExecuteTool(context.Background(), "build", []string{"--target", "a b"})
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	patterns := []string{
+		`start phase=env_setup path=\S+/setup.sh argv=\[\S+/setup.sh\]$`,
+		`end phase=env_setup pid=[1-9]\d* exit=0 elapsed=\S+$`,
+		`start phase=tool path=\S+/build argv=\[\S+/build --target 'a b'\]$`,
+		`end phase=tool pid=[1-9]\d* exit=3 elapsed=\S+$`,
+		`start phase=reporting path=\S+/report.sh argv=\[\S+/report.sh\]$`,
+		`end phase=reporting pid=[1-9]\d* exit=0 elapsed=\S+$`,
+	}
+	if len(lines) != len(patterns) {
+		t.Fatalf("Expected %d trace events, got:\n%s", len(patterns), output.String())
+	}
+	timestamp := `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+)?Z uber trace: `
+	for i, pattern := range patterns {
+		if !regexp.MustCompile(timestamp + pattern).MatchString(lines[i]) {
+			t.Errorf("Trace line %d = %q, want match for %q", i, lines[i], pattern)
+		}
+	}
+}
+
+func TestTraceDisabled(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-trace-off")
+	defer cleanup()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "build"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	executor := NewToolExecutor(&RunContext{
+		Root:   tempDir,
+		Config: &config.Config{ToolPaths: []string{tempDir}},
+	})
+	if err := executor.FindAndExecuteTool(context.Background(), "build", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed without a trace output: %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package uber
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
 )
 
 // These variables will be set by the linker during build
@@ -16,27 +19,80 @@ var (
 // Run executes the main uber logic
 func Run() error {
 	// Get the absolute path to the uber binary
-	binPath, err := filepath.Abs(os.Args[0])
+	binPath, err := binaryPath(os.Args[0])
 	if err != nil {
 		return fmt.Errorf("error getting binary path: %w", err)
 	}
 
+	// The hidden completion command bypasses regular parsing so it stays
+	// fast and silent on errors
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		runComplete(binPath, os.Args[2:], os.Stdout)
+		return nil
+	}
+
 	ctx, err := ParseArgs(binPath, os.Args[1:], nil)
 	if err != nil {
 		return fmt.Errorf("error: %w", err)
 	}
+	ctx.OriginalArgs = os.Args
+
+	// Handle the hidden --dump-args flag before anything else runs
+	if ctx.DumpArgs {
+		ctx.writeParsedArgs(os.Stdout)
+		return nil
+	}
+
+	// Send --trace events to stderr or the --trace-file
+	if ctx.TraceFile != "" {
+		traceFile, err := os.OpenFile(ctx.TraceFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return fmt.Errorf("error: failed to open trace file: %w", err)
+		}
+		defer traceFile.Close()
+		ctx.TraceOutput = traceFile
+	} else if ctx.Trace {
+		ctx.TraceOutput = os.Stderr
+	}
 
 	// Handle version flag
 	if ctx.ShowVersion {
-		fmt.Printf("uber version %s\n", Version)
-		fmt.Printf("commit: %s\n", Commit)
-		fmt.Printf("date: %s\n", Date)
+		if err := PrintVersion(os.Stdout, ctx.JSON); err != nil {
+			return fmt.Errorf("error: failed to write version: %w", err)
+		}
+		if ctx.CheckVersion {
+			return checkMinVersion(Version, ctx.Config.MinUberVersion)
+		}
 		return nil
 	}
 
 	// Create tool executor
 	executor := NewToolExecutor(ctx)
 
+	// Handle --doctor flag
+	if ctx.Doctor {
+		if err := executor.Doctor(os.Stdout, ctx.JSON); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		return nil
+	}
+
+	// Handle --print-config flag
+	if ctx.PrintConfig {
+		if err := executor.PrintConfig(os.Stdout, ctx.JSON); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		return nil
+	}
+
+	// Handle --explain flag
+	if ctx.Explain != "" {
+		if err := executor.Explain(os.Stdout, ctx.Explain, ctx.JSON); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		return nil
+	}
+
 	// Handle --list-tools flag
 	if ctx.ListTools {
 		if err := executor.ListAvailableTools(); err != nil {
@@ -45,8 +101,113 @@ func Run() error {
 		return nil
 	}
 
-	// Find and execute the tool
-	if err := executor.FindAndExecuteTool(ctx.Command, ctx.RemainingArgs); err != nil {
+	// Handle "uber stop <tool>" for tools started with --detach, unless the
+	// project has its own tool named "stop"
+	if ctx.Command == stopCommand && len(ctx.RemainingArgs) == 1 && len(ctx.Roots) == 0 {
+		if _, _, err := executor.findTool(stopCommand); err != nil {
+			if err := executor.StopTool(ctx.RemainingArgs[0]); err != nil {
+				return fmt.Errorf("error: %w", err)
+			}
+			return nil
+		}
+	}
+
+	// Handle "uber edit" to open the .uber file, unless the project has its
+	// own tool named "edit"
+	if ctx.Command == editCommand && len(ctx.RemainingArgs) == 0 && len(ctx.Roots) == 0 {
+		if _, _, err := executor.findTool(editCommand); err != nil {
+			if err := executor.EditConfig(); err != nil {
+				return fmt.Errorf("error: %w", err)
+			}
+			return nil
+		}
+	}
+
+	// Handle "uber stats" to summarize the usage log, unless the project has
+	// its own tool named "stats"
+	if ctx.Command == statsCommand && len(ctx.RemainingArgs) == 0 && len(ctx.Roots) == 0 {
+		if _, _, err := executor.findTool(statsCommand); err != nil {
+			if err := executor.PrintStats(os.Stdout); err != nil {
+				return fmt.Errorf("error: %w", err)
+			}
+			return nil
+		}
+	}
+
+	// Let the user choose the tool when none was given
+	if ctx.Pick {
+		toolName, err := executor.PickTool(os.Stdin, os.Stderr)
+		if err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		ctx.Command = toolName
+	}
+
+	// Cancel the running tool when uber is interrupted or terminated, so that
+	// cleanup such as removing the --isolate-tmp directory still happens.
+	// Signals listed in forward_signals are relayed to the tool instead.
+	var cancelSignals []os.Signal
+	for _, sig := range []os.Signal{os.Interrupt, syscall.SIGTERM} {
+		if !slices.Contains(ctx.ForwardSignals, sig) {
+			cancelSignals = append(cancelSignals, sig)
+		}
+	}
+	execCtx, stop := context.WithCancel(context.Background())
+	if len(cancelSignals) > 0 {
+		execCtx, stop = notifyContext(execCtx, cancelSignals...)
+	}
+	defer stop()
+
+	// Run the tool saved with --record
+	if ctx.Replay != "" {
+		if err := Replay(execCtx, ctx.Replay); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		return nil
+	}
+
+	// Run the tool in each root given with --roots
+	if len(ctx.Roots) > 0 {
+		if err := ctx.RunInRoots(execCtx); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		return nil
+	}
+
+	// Show what the env setup changes without running the tool
+	if ctx.EnvDiff {
+		if err := executor.EnvDiff(execCtx, os.Stdout, ctx.Command, ctx.RemainingArgs); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		return nil
+	}
+
+	// Run the tool repeatedly and report its timings
+	if ctx.Benchmark > 0 {
+		if err := executor.Benchmark(execCtx, os.Stdout, ctx.Command, ctx.RemainingArgs, ctx.Benchmark); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		return nil
+	}
+
+	// Run the sequence or find and execute the tool
+	start := time.Now()
+	err = executor.RunCommand(execCtx, ctx.Command, ctx.RemainingArgs)
+	if ctx.Profile != "" {
+		if profileErr := executor.appendProfile(ctx.Profile, start, ExitCode(err)); profileErr != nil {
+			ColorPrintWarning(fmt.Sprintf("Warning: failed to write profile: %v\n", profileErr))
+		}
+	}
+	if ctx.Config.TrackUsage {
+		if usageErr := executor.recordUsage(start, ExitCode(err)); usageErr != nil {
+			ColorPrintWarning(fmt.Sprintf("Warning: failed to record usage: %v\n", usageErr))
+		}
+	}
+	if err != nil {
+		// Let editors and other integrations offer the suggestions
+		if ctx.JSON {
+			writeToolNotFoundJSON(os.Stdout, err)
+		}
 		return fmt.Errorf("error: %w", err)
 	}
 
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package uber
+
+// applyUmask is a no-op on platforms without a umask.
+func applyUmask(mask int) func() {
+	ColorPrintWarning("Warning: the umask option is not supported on this platform and is ignored\n")
+	return func() {}
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package uber
+
+import "syscall"
+
+// applyUmask sets the process umask and returns a function that restores the
+// previous one.
+func applyUmask(mask int) func() {
+	previous := syscall.Umask(mask)
+	return func() {
+		syscall.Umask(previous)
+	}
+}
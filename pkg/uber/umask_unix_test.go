@@ -0,0 +1,55 @@
+//go:build unix
+
+package uber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestExecuteToolUmask(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-umask")
+	defer cleanup()
+
+	outputFile := filepath.Join(tempDir, "output.txt")
+	toolContent := fmt.Sprintf("#!/bin/sh\numask > %s\n", outputFile)
+	if err := os.WriteFile(filepath.Join(tempDir, "umask-tool"), []byte(toolContent), 0755); err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	// Make sure the configured umask differs from the process umask
+	previous := syscall.Umask(0o022)
+	defer syscall.Umask(previous)
+
+	executor := NewToolExecutor(&RunContext{
+		Root: tempDir,
+		Config: &config.Config{
+			ToolPaths: []string{tempDir},
+			Umask:     "027",
+		},
+	})
+
+	if err := executor.FindAndExecuteTool(context.Background(), "umask-tool", []string{}); err != nil {
+		t.Fatalf("FindAndExecuteTool failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "0027" {
+		t.Errorf("Expected tool to run with umask 0027, got '%s'", got)
+	}
+
+	// The process umask is restored afterwards
+	if current := syscall.Umask(0o022); current != 0o022 {
+		t.Errorf("Expected umask to be restored to 022, got %03o", current)
+	}
+}
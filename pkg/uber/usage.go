@@ -0,0 +1,140 @@
+package uber
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// usageFile is the name of the usage log inside the cache directory.
+const usageFile = "usage.jsonl"
+
+// statsCommand is the command that summarizes the usage log, unless a tool
+// with the same name exists.
+const statsCommand = "stats"
+
+// usageEntry is one line of the usage log written when track_usage is set.
+type usageEntry struct {
+	Timestamp  string `json:"timestamp"`
+	Tool       string `json:"tool"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// recordUsage appends a line for this run to the usage log. The file is
+// locked while writing so that concurrent uber runs can share it.
+func (te *ToolExecutor) recordUsage(start time.Time, exitCode int) error {
+	entry := usageEntry{
+		Timestamp:  start.UTC().Format(time.RFC3339),
+		Tool:       te.ctx.Command,
+		ExitCode:   exitCode,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := te.cachePath(usageFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage log: %w", err)
+	}
+	defer file.Close()
+
+	unlock, err := lockFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to lock usage log: %w", err)
+	}
+	defer unlock()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write usage log: %w", err)
+	}
+	return nil
+}
+
+// toolUsage summarizes the runs of one tool in the usage log.
+type toolUsage struct {
+	Tool      string
+	Runs      int
+	Failures  int
+	TotalTime time.Duration
+}
+
+// PrintStats writes the number of runs, failures and the mean duration of
+// each tool in the usage log to w, most used first. Lines that can't be
+// parsed, e.g. from an interrupted write, are skipped.
+func (te *ToolExecutor) PrintStats(w io.Writer) error {
+	path := te.cachePath(usageFile)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		if !te.ctx.Config.TrackUsage {
+			fmt.Fprintln(w, "No usage recorded. Set track_usage = true in the .uber file to record tool runs.")
+		} else {
+			fmt.Fprintln(w, "No usage recorded yet.")
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open usage log: %w", err)
+	}
+	defer file.Close()
+
+	byTool := make(map[string]*toolUsage)
+	var since string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry usageEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Tool == "" {
+			continue
+		}
+		if since == "" {
+			since = entry.Timestamp
+		}
+		usage, ok := byTool[entry.Tool]
+		if !ok {
+			usage = &toolUsage{Tool: entry.Tool}
+			byTool[entry.Tool] = usage
+		}
+		usage.Runs++
+		if entry.ExitCode != 0 {
+			usage.Failures++
+		}
+		usage.TotalTime += time.Duration(entry.DurationMs) * time.Millisecond
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read usage log: %w", err)
+	}
+
+	usages := make([]*toolUsage, 0, len(byTool))
+	for _, usage := range byTool {
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Runs != usages[j].Runs {
+			return usages[i].Runs > usages[j].Runs
+		}
+		return usages[i].Tool < usages[j].Tool
+	})
+
+	if len(usages) == 0 {
+		fmt.Fprintln(w, "No usage recorded yet.")
+		return nil
+	}
+	fmt.Fprintf(w, "Tool usage since %s:\n", since)
+	fmt.Fprintf(w, "  %-24s %6s %8s %10s\n", "TOOL", "RUNS", "FAILURES", "MEAN")
+	for _, usage := range usages {
+		mean := usage.TotalTime / time.Duration(usage.Runs)
+		fmt.Fprintf(w, "  %-24s %6d %8d %10s\n", usage.Tool, usage.Runs, usage.Failures, mean.Round(time.Millisecond))
+	}
+	return nil
+}
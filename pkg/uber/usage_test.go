@@ -0,0 +1,100 @@
+package uber
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chaselatta/uber/config"
+)
+
+func TestRecordUsage(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-usage")
+	defer cleanup()
+
+	// Concurrent runs append whole lines
+	const runs = 20
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			executor := NewToolExecutor(&RunContext{Root: tempDir, Command: "build", Config: &config.Config{TrackUsage: true}})
+			if err := executor.recordUsage(time.Now().Add(-10*time.Millisecond), i%2); err != nil {
+				t.Errorf("recordUsage failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	file, err := os.Open(NewToolExecutor(&RunContext{Root: tempDir}).cachePath(usageFile))
+	if err != nil {
+		t.Fatalf("Failed to open usage log: %v", err)
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry usageEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid usage line %q: %v", scanner.Text(), err)
+		}
+		if entry.Tool != "build" || entry.DurationMs < 10 || entry.Timestamp == "" {
+			t.Errorf("Unexpected usage entry: %+v", entry)
+		}
+		lines++
+	}
+	if lines != runs {
+		t.Errorf("Expected %d usage lines, got %d", runs, lines)
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	tempDir, cleanup := createTempDirWithTool(t, "uber-test-stats")
+	defer cleanup()
+	executor := NewToolExecutor(&RunContext{Root: tempDir, Config: &config.Config{}})
+
+	var out bytes.Buffer
+	if err := executor.PrintStats(&out); err != nil {
+		t.Fatalf("PrintStats failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "track_usage = true") {
+		t.Errorf("Expected a hint to enable track_usage, got %q", out.String())
+	}
+
+	log := strings.Join([]string{
+		`{"timestamp":"2026-01-01T00:00:00Z","tool":"lint","exit_code":0,"duration_ms":100}`,
+		`{"timestamp":"2026-01-01T00:01:00Z","tool":"build","exit_code":0,"duration_ms":1000}`,
+		`{"timestamp":"2026-01-01T00:02:00Z","tool":"build","exit_code":2,"duration_ms":3000}`,
+		`{"timestamp":"2026-01-01T00:03:00Z","tool":"bui`,
+	}, "\n")
+	if err := os.MkdirAll(executor.cachePath(""), 0755); err != nil {
+		t.Fatalf("Failed to create cache directory: %v", err)
+	}
+	if err := os.WriteFile(executor.cachePath(usageFile), []byte(log), 0644); err != nil {
+		t.Fatalf("Failed to write usage log: %v", err)
+	}
+
+	out.Reset()
+	if err := executor.PrintStats(&out); err != nil {
+		t.Fatalf("PrintStats failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and two tools, got:\n%s", out.String())
+	}
+	if !strings.Contains(lines[0], "2026-01-01T00:00:00Z") {
+		t.Errorf("Expected the first timestamp in the header, got %q", lines[0])
+	}
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != "build 2 1 2s" {
+		t.Errorf("Expected build to come first with 2 runs, 1 failure and a 2s mean, got %q", lines[2])
+	}
+	if fields := strings.Fields(lines[3]); strings.Join(fields, " ") != "lint 1 0 100ms" {
+		t.Errorf("Expected lint with 1 run and a 100ms mean, got %q", lines[3])
+	}
+}
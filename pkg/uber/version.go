@@ -0,0 +1,24 @@
+package uber
+
+import (
+	"fmt"
+	"io"
+)
+
+// versionInfo is the document printed by --version-json.
+type versionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// PrintVersion writes the version, commit and build date of uber to w, as
+// JSON when asJSON is set. Every entry point prints the version through it
+// so that the format stays the same.
+func PrintVersion(w io.Writer, asJSON bool) error {
+	if asJSON {
+		return writeJSON(w, versionInfo{Version: Version, Commit: Commit, Date: Date})
+	}
+	_, err := fmt.Fprintf(w, "uber version %s\ncommit: %s\ndate: %s\n", Version, Commit, Date)
+	return err
+}
@@ -0,0 +1,51 @@
+package uber
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestPrintVersion(t *testing.T) {
+	oldVersion, oldCommit, oldDate := Version, Commit, Date
+	Version, Commit, Date = "1.2.3", "abc123", "2026-01-02"
+	defer func() {
+		Version, Commit, Date = oldVersion, oldCommit, oldDate
+	}()
+
+	var plain bytes.Buffer
+	if err := PrintVersion(&plain, false); err != nil {
+		t.Fatalf("PrintVersion failed: %v", err)
+	}
+	if want := "uber version 1.2.3\ncommit: abc123\ndate: 2026-01-02\n"; plain.String() != want {
+		t.Errorf("Expected %q, got %q", want, plain.String())
+	}
+
+	var asJSON bytes.Buffer
+	if err := PrintVersion(&asJSON, true); err != nil {
+		t.Fatalf("PrintVersion failed: %v", err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(asJSON.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", asJSON.String(), err)
+	}
+	if got["version"] != "1.2.3" || got["commit"] != "abc123" || got["date"] != "2026-01-02" || len(got) != 3 {
+		t.Errorf("Unexpected version JSON: %v", got)
+	}
+}
+
+func TestParseArgsVersionJSON(t *testing.T) {
+	tempDir, cleanup := createTempDirWithUberFile(t, "uber-test-version-json")
+	defer cleanup()
+
+	for _, args := range [][]string{{"--version-json"}, {"--version", "--json"}} {
+		ctx, err := ParseArgs("/dummy/bin/path", append([]string{"--root", tempDir}, args...), io.Discard)
+		if err != nil {
+			t.Fatalf("ParseArgs(%v) failed: %v", args, err)
+		}
+		if !ctx.ShowVersion || !ctx.JSON {
+			t.Errorf("ParseArgs(%v): expected ShowVersion and JSON, got %v and %v", args, ctx.ShowVersion, ctx.JSON)
+		}
+	}
+}